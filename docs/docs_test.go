@@ -0,0 +1,64 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+func TestSwaggerDocJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger/doc.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("doc.json 返回 %d", w.Code)
+	}
+	var doc struct {
+		BasePath    string                                `json:"basePath"`
+		Paths       map[string]map[string]json.RawMessage `json:"paths"`
+		Definitions map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("doc.json 不是有效的 JSON: %v", err)
+	}
+	if doc.BasePath != "/api" {
+		t.Errorf("basePath 为 %q，期望 /api", doc.BasePath)
+	}
+
+	cases := []struct {
+		path    string
+		methods []string
+	}{
+		{"/tasks", []string{"get", "post"}},
+		{"/tasks/{id}", []string{"get", "put", "patch", "delete"}},
+		{"/tasks/{id}/run", []string{"post"}},
+		{"/tasks/{id}/logs", []string{"get"}},
+		{"/tasks/search", []string{"get"}},
+	}
+	for _, c := range cases {
+		for _, method := range c.methods {
+			if _, ok := doc.Paths[c.path][method]; !ok {
+				t.Errorf("文档缺少 %s %s", method, c.path)
+			}
+		}
+	}
+
+	for _, field := range []string{"name", "spec", "command", "exec_type", "version"} {
+		if _, ok := doc.Definitions["model.Task"].Properties[field]; !ok {
+			t.Errorf("model.Task 的文档缺少字段 %s", field)
+		}
+	}
+	if _, ok := doc.Definitions["service.TaskPage"]; !ok {
+		t.Error("文档缺少分页列表 service.TaskPage")
+	}
+}
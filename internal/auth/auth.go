@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Config 认证配置
+type Config struct {
+	JWTSecret string `mapstructure:"jwt_secret"` // JWT 签名密钥
+	TokenTTL  int    `mapstructure:"token_ttl"`  // 令牌有效期（秒），默认2小时
+	Users     []User `mapstructure:"users"`      // 允许登录的用户
+}
+
+// User 登录用户
+type User struct {
+	Username     string `mapstructure:"username"`
+	PasswordHash string `mapstructure:"password_hash"` // bcrypt 哈希后的密码
+	Role         string `mapstructure:"role"`          // 角色：viewer、operator、admin，未配置时为 admin
+}
+
+const defaultTokenTTL = 2 * time.Hour
+
+// 签名密钥的要求：HS256 的密钥不应短于其输出长度，示例配置中的占位符不能用于部署
+const (
+	minJWTSecretLength   = 32
+	placeholderJWTSecret = "change-me"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	ErrInvalidToken       = errors.New("无效的令牌")
+	ErrTokenExpired       = errors.New("令牌已过期")
+)
+
+// Claims JWT 声明
+type Claims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Authenticator 负责登录校验和令牌签发、验证
+type Authenticator struct {
+	secret  []byte
+	ttl     time.Duration
+	users   map[string]User
+	apiKeys APIKeyValidator
+}
+
+// NewAuthenticator 根据配置创建认证器，签名密钥未配置、为示例占位符或短于32字节，或用户未配置密码哈希时返回错误
+func NewAuthenticator(config *Config) (*Authenticator, error) {
+	switch {
+	case config.JWTSecret == "":
+		return nil, fmt.Errorf("未配置JWT签名密钥")
+	case config.JWTSecret == placeholderJWTSecret:
+		return nil, fmt.Errorf("JWT签名密钥仍为示例值 %q，请修改 auth.jwt_secret", placeholderJWTSecret)
+	case len(config.JWTSecret) < minJWTSecretLength:
+		return nil, fmt.Errorf("JWT签名密钥长度为 %d 字节，至少需要 %d 字节", len(config.JWTSecret), minJWTSecretLength)
+	}
+
+	ttl := defaultTokenTTL
+	if config.TokenTTL > 0 {
+		ttl = time.Duration(config.TokenTTL) * time.Second
+	}
+
+	users := make(map[string]User, len(config.Users))
+	for _, u := range config.Users {
+		if u.Role == "" {
+			u.Role = RoleAdmin
+		}
+		if !ValidRole(u.Role) {
+			return nil, fmt.Errorf("用户 %s 的角色无效: %s", u.Username, u.Role)
+		}
+		if u.PasswordHash == "" {
+			return nil, fmt.Errorf("用户 %s 未配置密码哈希", u.Username)
+		}
+		users[u.Username] = u
+	}
+
+	return &Authenticator{
+		secret: []byte(config.JWTSecret),
+		ttl:    ttl,
+		users:  users,
+	}, nil
+}
+
+// Login 校验用户名密码并签发令牌
+func (a *Authenticator) Login(username, password string) (string, time.Time, error) {
+	user, ok := a.users[username]
+	if !ok || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+	return a.IssueToken(username, user.Role)
+}
+
+// IssueToken 为用户签发令牌
+func (a *Authenticator) IssueToken(username, role string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(a.ttl)
+	claims := &Claims{
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("签发令牌失败: %v", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ParseToken 验证令牌签名和有效期，返回声明
+func (a *Authenticator) ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
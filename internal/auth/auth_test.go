@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newTestAuthenticator 创建带一个用户的认证器，密码为 secret-password
+func newTestAuthenticator(t *testing.T, role string) *Authenticator {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewAuthenticator(&Config{
+		JWTSecret: testSecret,
+		Users:     []User{{Username: "alice", PasswordHash: string(hash), Role: role}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestNewAuthenticatorRejectsWeakSecrets(t *testing.T) {
+	for _, secret := range []string{"", "change-me", "too-short-secret"} {
+		if _, err := NewAuthenticator(&Config{JWTSecret: secret}); err == nil {
+			t.Errorf("签名密钥 %q 应被拒绝", secret)
+		}
+	}
+	if _, err := NewAuthenticator(&Config{JWTSecret: testSecret}); err != nil {
+		t.Fatalf("32字节的签名密钥应被接受: %v", err)
+	}
+}
+
+func TestNewAuthenticatorRejectsInvalidUsers(t *testing.T) {
+	if _, err := NewAuthenticator(&Config{JWTSecret: testSecret, Users: []User{{Username: "admin"}}}); err == nil {
+		t.Error("未配置密码哈希的用户应被拒绝")
+	}
+	if _, err := NewAuthenticator(&Config{JWTSecret: testSecret, Users: []User{{Username: "admin", PasswordHash: "x", Role: "root"}}}); err == nil {
+		t.Error("无效角色应被拒绝")
+	}
+}
+
+func TestLogin(t *testing.T) {
+	a := newTestAuthenticator(t, "")
+
+	token, expiresAt, err := a.Login("alice", "secret-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(expiresAt) <= 0 || time.Until(expiresAt) > defaultTokenTTL {
+		t.Errorf("过期时间 %v 不在默认有效期内", expiresAt)
+	}
+	claims, err := a.ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Username != "alice" || claims.Role != RoleAdmin {
+		t.Errorf("声明 username=%q role=%q，期望 alice/admin", claims.Username, claims.Role)
+	}
+
+	for _, c := range []struct{ username, password string }{
+		{"alice", "wrong-password"},
+		{"bob", "secret-password"},
+		{"alice", ""},
+	} {
+		if _, _, err := a.Login(c.username, c.password); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Login(%q, %q) 错误为 %v，期望 ErrInvalidCredentials", c.username, c.password, err)
+		}
+	}
+}
+
+func TestParseTokenExpired(t *testing.T) {
+	a := newTestAuthenticator(t, RoleAdmin)
+	a.ttl = -time.Minute
+	token, _, err := a.IssueToken("alice", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ParseToken(token); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("过期令牌的错误为 %v，期望 ErrTokenExpired", err)
+	}
+}
+
+func TestParseTokenBadSignature(t *testing.T) {
+	a := newTestAuthenticator(t, RoleAdmin)
+
+	// 用其他密钥签发的令牌
+	other, err := NewAuthenticator(&Config{JWTSecret: strings.Repeat("x", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, _, err := other.IssueToken("alice", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ParseToken(forged); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("其他密钥签发的令牌错误为 %v，期望 ErrInvalidToken", err)
+	}
+
+	// 篡改声明后签名不再匹配
+	token, _, _ := a.IssueToken("alice", RoleViewer)
+	parts := strings.Split(token, ".")
+	tampered, _, _ := a.IssueToken("alice", RoleAdmin)
+	parts[1] = strings.Split(tampered, ".")[1]
+	if _, err := a.ParseToken(strings.Join(parts, ".")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("篡改后的令牌错误为 %v，期望 ErrInvalidToken", err)
+	}
+
+	// 不签名的令牌
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, &Claims{Username: "alice", Role: RoleAdmin}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.ParseToken(unsigned); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("alg=none 的令牌错误为 %v，期望 ErrInvalidToken", err)
+	}
+
+	if _, err := a.ParseToken("not-a-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("格式错误的令牌错误为 %v，期望 ErrInvalidToken", err)
+	}
+}
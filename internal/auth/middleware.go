@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyIdentity 认证通过后写入 gin.Context 的身份键
+const ContextKeyIdentity = "identity"
+
+// API Key 的权限范围，分别对应 viewer、operator、admin 角色
+const (
+	ScopeRead    = "read"    // 只读：只允许查询任务、日志、统计等 GET/HEAD 请求
+	ScopeOperate = "operate" // 只读 + 执行、启用、禁用任务
+	ScopeFull    = "full"    // 完全访问
+)
+
+// ErrInvalidAPIKey 无效或已吊销的 API Key
+var ErrInvalidAPIKey = errors.New("无效的API Key")
+
+// Identity 请求方身份
+type Identity struct {
+	Subject string // 用户名或 API Key 名称
+	Role    string // 角色
+	APIKey  bool   // 是否通过 API Key 认证
+}
+
+// APIKeyValidator 校验 API Key 并返回对应身份
+type APIKeyValidator interface {
+	ValidateAPIKey(key string) (*Identity, error)
+}
+
+// UseAPIKeys 启用 X-API-Key 请求头认证，作为 JWT 之外的认证方式
+func (a *Authenticator) UseAPIKeys(validator APIKeyValidator) {
+	a.apiKeys = validator
+}
+
+// Middleware 校验 X-API-Key 或 Authorization: Bearer <token> 请求头，未通过时返回401，
+// viewer 角色访问非只读接口时返回403
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, status, err := a.authenticate(c)
+		if err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !identity.HasRole(RoleOperator) && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "只读权限不允许执行该操作"})
+			return
+		}
+
+		c.Set(ContextKeyIdentity, identity)
+		c.Next()
+	}
+}
+
+// authenticate 解析请求方身份，优先使用 API Key
+func (a *Authenticator) authenticate(c *gin.Context) (*Identity, int, error) {
+	if key := c.GetHeader("X-API-Key"); key != "" && a.apiKeys != nil {
+		identity, err := a.apiKeys.ValidateAPIKey(key)
+		if err != nil {
+			if errors.Is(err, ErrInvalidAPIKey) {
+				return nil, http.StatusUnauthorized, err
+			}
+			return nil, http.StatusInternalServerError, err
+		}
+		identity.APIKey = true
+		return identity, 0, nil
+	}
+
+	header := c.GetHeader("Authorization")
+	tokenString := strings.TrimPrefix(header, "Bearer ")
+	if header == "" || tokenString == header {
+		return nil, http.StatusUnauthorized, errors.New("缺少认证令牌")
+	}
+
+	claims, err := a.ParseToken(tokenString)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+	return &Identity{Subject: claims.Username, Role: claims.Role}, 0, nil
+}
+
+// GetIdentity 获取认证中间件写入的身份
+func GetIdentity(c *gin.Context) *Identity {
+	if v, ok := c.Get(ContextKeyIdentity); ok {
+		if identity, ok := v.(*Identity); ok {
+			return identity
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter 创建经过认证中间件的路由，GET 和 DELETE /tasks 都返回200
+func newTestRouter(a *Authenticator) *gin.Engine {
+	r := gin.New()
+	api := r.Group("/", a.Middleware())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, GetIdentity(c).Subject) }
+	api.GET("/tasks", ok)
+	api.DELETE("/tasks", RequireRole(RoleAdmin), ok)
+	return r
+}
+
+// doRequest 发送请求，authorization 为 Authorization 请求头的值，为空时不设置，headers 为成对的其他请求头
+func doRequest(r http.Handler, method, authorization string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/tasks", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareTokens(t *testing.T) {
+	a := newTestAuthenticator(t, RoleAdmin)
+	r := newTestRouter(a)
+
+	valid, _, err := a.IssueToken("alice", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiredAuth := *a
+	expiredAuth.ttl = -time.Minute
+	expired, _, err := expiredAuth.IssueToken("alice", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"缺少令牌", "", http.StatusUnauthorized},
+		{"不是 Bearer", valid, http.StatusUnauthorized},
+		{"过期令牌", "Bearer " + expired, http.StatusUnauthorized},
+		{"无效令牌", "Bearer abc.def.ghi", http.StatusUnauthorized},
+		{"有效令牌", "Bearer " + valid, http.StatusOK},
+	}
+	for _, c := range cases {
+		if w := doRequest(r, http.MethodGet, c.authorization); w.Code != c.want {
+			t.Errorf("%s: 状态码 %d，期望 %d", c.name, w.Code, c.want)
+		}
+	}
+
+	if w := doRequest(r, http.MethodGet, "Bearer "+valid); w.Body.String() != "alice" {
+		t.Errorf("认证后的身份为 %q，期望 alice", w.Body.String())
+	}
+}
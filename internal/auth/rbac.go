@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 角色，权限依次递增
+const (
+	RoleViewer   = "viewer"   // 只读
+	RoleOperator = "operator" // 只读 + 执行、启用、禁用任务
+	RoleAdmin    = "admin"    // 全部权限，包括创建、修改、删除任务和管理密钥
+)
+
+var roleLevels = map[string]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ValidRole 判断角色是否有效
+func ValidRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// HasRole 判断身份是否具有不低于 required 的角色
+func (i *Identity) HasRole(required string) bool {
+	return i != nil && roleLevels[i.Role] >= roleLevels[required]
+}
+
+// RoleForScope API Key 权限范围对应的角色
+func RoleForScope(scope string) string {
+	switch scope {
+	case ScopeFull:
+		return RoleAdmin
+	case ScopeOperate:
+		return RoleOperator
+	default:
+		return RoleViewer
+	}
+}
+
+// RequireRole 要求已认证身份具有不低于 role 的角色，否则返回403，需在认证中间件之后使用
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !GetIdentity(c).HasRole(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "权限不足，需要 " + role + " 角色"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -2,15 +2,36 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
+	"happx1/internal/auth"
 	"happx1/internal/database"
+	"happx1/internal/logger"
+	"happx1/internal/notifications"
+	"happx1/internal/ratelimit"
+	"happx1/internal/scheduler"
+	"happx1/internal/secrets"
+	"happx1/internal/service"
+	"happx1/internal/telemetry"
 )
 
 type Config struct {
-	MySQL database.MySQLConfig
-	Redis database.RedisConfig
-	Server struct {
+	MySQL     database.MySQLConfig
+	Redis     database.RedisConfig
+	Scheduler scheduler.Config
+	Auth      auth.Config
+	Task      service.Config
+	Telemetry telemetry.Config
+	Log       logger.Config
+	Notify    notifications.Config
+	Secrets   secrets.Config
+	RateLimit ratelimit.Config `mapstructure:"rate_limit"`
+	Server    struct {
 		Port int
 		Mode string
 	}
@@ -18,11 +39,20 @@ type Config struct {
 
 var GlobalConfig Config
 
+// envPrefix 环境变量前缀，HAPPX1_MYSQL_PASSWORD 覆盖 mysql.password
+const envPrefix = "HAPPX1"
+
+// Init 读取 config/config.yaml，环境变量优先于配置文件
 func Init() error {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath("./config")
 
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+	bindEnvs(reflect.TypeOf(Config{}))
+
 	if err := viper.ReadInConfig(); err != nil {
 		return fmt.Errorf("读取配置文件失败: %v", err)
 	}
@@ -31,5 +61,67 @@ func Init() error {
 		return fmt.Errorf("解析配置文件失败: %v", err)
 	}
 
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		reload()
+	})
+	viper.WatchConfig()
+
 	return nil
-} 
\ No newline at end of file
+}
+
+// bindEnvs 为每个配置项绑定环境变量，配置文件中没有的项也能通过环境变量设置
+// 列表以逗号分隔，如 HAPPX1_SCHEDULER_SHELL_ALLOWLIST；map 和对象列表（如 auth.users）只能在配置文件中设置
+func bindEnvs(t reflect.Type, path ...string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := append(path[:len(path):len(path)], name)
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			bindEnvs(field.Type, key...)
+		case reflect.Map:
+		case reflect.Slice:
+			if field.Type.Elem().Kind() != reflect.Struct {
+				viper.BindEnv(strings.Join(key, "."))
+			}
+		default:
+			viper.BindEnv(strings.Join(key, "."))
+		}
+	}
+}
+
+var (
+	watchersMu sync.Mutex
+	watchers   []func(*Config)
+)
+
+// OnChange 注册配置文件变更回调，参数为重新加载的完整配置
+// GlobalConfig 不会被整体替换，各子系统在回调中自行应用可以热更新的设置，其余设置需重启后生效
+func OnChange(fn func(*Config)) {
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+	watchers = append(watchers, fn)
+}
+
+// reload 重新解析配置文件并通知各子系统，解析失败时保留当前配置
+func reload() {
+	var next Config
+	if err := viper.Unmarshal(&next); err != nil {
+		slog.Error("重新加载配置文件失败，保留当前配置", "error", err)
+		return
+	}
+	slog.Info("配置文件已变更，重新加载", "file", viper.ConfigFileUsed())
+
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+	for _, fn := range watchers {
+		fn(&next)
+	}
+}
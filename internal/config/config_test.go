@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// initTestConfig 在临时目录中写入 config/config.yaml 并加载，返回配置文件路径
+// 测试结束时恢复工作目录和全局配置
+func initTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		viper.Reset()
+		GlobalConfig = Config{}
+		watchersMu.Lock()
+		watchers = nil
+		watchersMu.Unlock()
+	})
+
+	viper.Reset()
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReloadOnFileChange(t *testing.T) {
+	path := initTestConfig(t, "log:\n  level: info\nscheduler:\n  worker_count: 2\n")
+	if GlobalConfig.Log.Level != "info" || GlobalConfig.Scheduler.WorkerCount != 2 {
+		t.Fatalf("加载的配置 log.level=%s worker_count=%d", GlobalConfig.Log.Level, GlobalConfig.Scheduler.WorkerCount)
+	}
+
+	reloaded := make(chan *Config, 10)
+	OnChange(func(c *Config) { reloaded <- c })
+	if err := os.WriteFile(path, []byte("log:\n  level: debug\nscheduler:\n  worker_count: 8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 一次写入可能触发多个文件事件，等待读到新内容的回调
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case c := <-reloaded:
+			if c.Log.Level == "debug" && c.Scheduler.WorkerCount == 8 {
+				return
+			}
+		case <-timeout:
+			t.Fatal("修改配置文件后未收到新配置")
+		}
+	}
+}
+
+func TestEnvOverridesFile(t *testing.T) {
+	t.Setenv("HAPPX1_MYSQL_PASSWORD", "from-env")
+	t.Setenv("HAPPX1_SCHEDULER_WORKER_COUNT", "16")
+	t.Setenv("HAPPX1_SCHEDULER_SHELL_ALLOWLIST", "^echo ,^date")
+	t.Setenv("HAPPX1_SERVER_PORT", "9090")
+	initTestConfig(t, "mysql:\n  password: from-file\n  username: root\nscheduler:\n  worker_count: 2\nserver:\n  port: 8080\n")
+
+	cases := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"mysql.password", GlobalConfig.MySQL.Password, "from-env"},
+		{"scheduler.worker_count", GlobalConfig.Scheduler.WorkerCount, 16},
+		{"server.port", GlobalConfig.Server.Port, 9090},
+		{"mysql.username", GlobalConfig.MySQL.Username, "root"},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s 为 %v，期望 %v", c.name, c.got, c.want)
+		}
+	}
+	// 配置文件中没有的项也能通过环境变量设置，列表以逗号分隔
+	if allow := GlobalConfig.Scheduler.ShellAllowlist; len(allow) != 2 || allow[0] != "^echo " || allow[1] != "^date" {
+		t.Errorf("shell_allowlist 为 %q，期望 [^echo  ^date]", allow)
+	}
+}
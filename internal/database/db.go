@@ -0,0 +1,135 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var DB *gorm.DB
+
+// 数据库类型
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// sqliteMemory SQLite 内存数据库的 Database 配置值
+const sqliteMemory = ":memory:"
+
+// MySQLConfig 数据库配置，Driver 为 postgres 时连接 PostgreSQL，为 sqlite 时 Database 为数据库文件路径或 :memory:
+type MySQLConfig struct {
+	Driver          string `mapstructure:"driver"` // 数据库类型：mysql（默认）、postgres 或 sqlite
+	Host            string `mapstructure:"host"`
+	Port            int    `mapstructure:"port"`
+	Username        string `mapstructure:"username"`
+	Password        string `mapstructure:"password"`
+	Database        string `mapstructure:"database"`
+	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
+	MaxOpenConns    int    `mapstructure:"max_open_conns"`
+	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`
+	DeadlockRetries int    `mapstructure:"deadlock_retries"` // 死锁最大重试次数
+	DeadlockBackoff int    `mapstructure:"deadlock_backoff"` // 死锁重试初始退避（毫秒）
+
+	SSLMode string `mapstructure:"ssl_mode"` // postgres：sslmode 参数，默认 disable
+}
+
+// DSN 按数据库类型生成连接串
+func DSN(config *MySQLConfig) (string, error) {
+	switch config.Driver {
+	case DriverMySQL, "":
+		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			config.Username,
+			config.Password,
+			config.Host,
+			config.Port,
+			config.Database,
+		), nil
+	case DriverPostgres:
+		sslMode := config.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(config.Username, config.Password),
+			Host:     net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+			Path:     "/" + config.Database,
+			RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+		}
+		return dsn.String(), nil
+	case DriverSQLite:
+		// 等待其他连接释放写锁，而不是立即返回 database is locked
+		pragmas := "_pragma=busy_timeout(5000)"
+		if config.Database == sqliteMemory {
+			// 共享缓存使连接池中的所有连接访问同一个内存数据库
+			return "file:happx1?mode=memory&cache=shared&" + pragmas, nil
+		}
+		return "file:" + config.Database + "?_pragma=journal_mode(WAL)&" + pragmas, nil
+	default:
+		return "", fmt.Errorf("不支持的数据库类型: %s", config.Driver)
+	}
+}
+
+// dialector 按数据库类型创建 gorm 驱动
+func dialector(config *MySQLConfig) (gorm.Dialector, error) {
+	dsn, err := DSN(config)
+	if err != nil {
+		return nil, err
+	}
+	switch config.Driver {
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		return sqlite.Open(dsn), nil
+	default:
+		return mysql.Open(dsn), nil
+	}
+}
+
+// InitDB 按配置的数据库类型连接数据库
+func InitDB(config *MySQLConfig) error {
+	dialect, err := dialector(config)
+	if err != nil {
+		return err
+	}
+
+	DB, err = gorm.Open(dialect, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+	if err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return fmt.Errorf("获取数据库连接池失败: %v", err)
+	}
+
+	// 设置连接池参数
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(config.ConnMaxLifetime) * time.Second)
+	if config.Driver == DriverSQLite && config.Database == sqliteMemory {
+		// 最后一个连接关闭时内存数据库随之销毁，保持至少一个连接
+		sqlDB.SetMaxIdleConns(max(config.MaxIdleConns, 1))
+		sqlDB.SetConnMaxLifetime(0)
+	}
+
+	// 设置死锁重试参数
+	deadlockRetries = config.DeadlockRetries
+	if config.DeadlockBackoff > 0 {
+		deadlockBackoff = time.Duration(config.DeadlockBackoff) * time.Millisecond
+	}
+
+	return nil
+}
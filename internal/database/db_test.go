@@ -0,0 +1,103 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"happx1/internal/model"
+)
+
+func TestDSN(t *testing.T) {
+	cases := []struct {
+		name   string
+		config MySQLConfig
+		want   string
+	}{
+		{
+			"默认使用 MySQL",
+			MySQLConfig{Host: "db", Port: 3306, Username: "root", Password: "secret", Database: "happx1"},
+			"root:secret@tcp(db:3306)/happx1?charset=utf8mb4&parseTime=True&loc=Local",
+		},
+		{
+			"MySQL",
+			MySQLConfig{Driver: DriverMySQL, Host: "db", Port: 3307, Username: "app", Password: "p", Database: "jobs"},
+			"app:p@tcp(db:3307)/jobs?charset=utf8mb4&parseTime=True&loc=Local",
+		},
+		{
+			"PostgreSQL 默认关闭 SSL",
+			MySQLConfig{Driver: DriverPostgres, Host: "pg", Port: 5432, Username: "postgres", Password: "secret", Database: "happx1"},
+			"postgres://postgres:secret@pg:5432/happx1?sslmode=disable",
+		},
+		{
+			"PostgreSQL 密码中的特殊字符被转义",
+			MySQLConfig{Driver: DriverPostgres, Host: "pg", Port: 5433, Username: "app", Password: "p@ss/word", Database: "jobs", SSLMode: "require"},
+			"postgres://app:p%40ss%2Fword@pg:5433/jobs?sslmode=require",
+		},
+		{
+			"SQLite 文件",
+			MySQLConfig{Driver: DriverSQLite, Database: "/data/happx1.db"},
+			"file:/data/happx1.db?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)",
+		},
+		{
+			"SQLite 内存数据库",
+			MySQLConfig{Driver: DriverSQLite, Database: ":memory:"},
+			"file:happx1?mode=memory&cache=shared&_pragma=busy_timeout(5000)",
+		},
+	}
+	for _, c := range cases {
+		got, err := DSN(&c.config)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: DSN 为 %q，期望 %q", c.name, got, c.want)
+		}
+	}
+
+	if _, err := DSN(&MySQLConfig{Driver: "oracle"}); err == nil {
+		t.Error("不支持的数据库类型应返回错误")
+	}
+}
+
+// TestPostgresMigrations 需要可用的 PostgreSQL，设置 HAPPX1_TEST_POSTGRES_HOST 时运行
+// 其他连接参数可通过 HAPPX1_TEST_POSTGRES_USER、HAPPX1_TEST_POSTGRES_PASSWORD 和 HAPPX1_TEST_POSTGRES_DATABASE 设置
+func TestPostgresMigrations(t *testing.T) {
+	host := os.Getenv("HAPPX1_TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("未设置 HAPPX1_TEST_POSTGRES_HOST")
+	}
+	config := &MySQLConfig{
+		Driver:   DriverPostgres,
+		Host:     host,
+		Port:     5432,
+		Username: envOr("HAPPX1_TEST_POSTGRES_USER", "postgres"),
+		Password: os.Getenv("HAPPX1_TEST_POSTGRES_PASSWORD"),
+		Database: envOr("HAPPX1_TEST_POSTGRES_DATABASE", "postgres"),
+	}
+	previous := DB
+	t.Cleanup(func() { DB = previous })
+	if err := InitDB(config); err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := DB.DB(); err == nil {
+		t.Cleanup(func() { sqlDB.Close() })
+	}
+
+	if err := model.AutoMigrate(DB); err != nil {
+		t.Fatalf("PostgreSQL 迁移失败: %v", err)
+	}
+	for _, table := range []interface{}{&model.Task{}, &model.TaskLog{}} {
+		if !DB.Migrator().HasTable(table) {
+			t.Errorf("迁移后缺少数据表 %T", table)
+		}
+	}
+}
+
+// envOr 返回环境变量 key 的值，未设置时返回 fallback
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
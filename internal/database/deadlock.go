@@ -0,0 +1,57 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// 死锁重试参数，由 InitDB 根据配置设置
+var (
+	deadlockRetries = 3
+	deadlockBackoff = 50 * time.Millisecond
+)
+
+// sqliteError 为 SQLite 驱动错误实现的接口，Code 返回 SQLite 结果码
+type sqliteError interface {
+	Code() int
+}
+
+// SQLite 主结果码，扩展结果码的低8位为主结果码
+const (
+	sqliteBusy   = 5 // SQLITE_BUSY: 数据库文件被其他连接锁定
+	sqliteLocked = 6 // SQLITE_LOCKED: 同一连接内的表锁冲突
+)
+
+// IsDeadlock 判断是否为可重试的死锁或锁等待超时错误，支持 MySQL、PostgreSQL 和 SQLite
+func IsDeadlock(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// 1213: Deadlock found, 1205: Lock wait timeout exceeded
+		return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 40P01: deadlock_detected, 55P03: lock_not_available
+		return pgErr.Code == "40P01" || pgErr.Code == "55P03"
+	}
+	var liteErr sqliteError
+	if errors.As(err, &liteErr) {
+		code := liteErr.Code() & 0xff
+		return code == sqliteBusy || code == sqliteLocked
+	}
+	return false
+}
+
+// WithDeadlockRetry 执行数据库写操作，遇到死锁时按指数退避重试
+func WithDeadlockRetry(fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !IsDeadlock(err) || attempt >= deadlockRetries {
+			return err
+		}
+		time.Sleep(deadlockBackoff << attempt)
+	}
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// noBackoff 测试期间取消重试等待
+func noBackoff(t *testing.T) {
+	t.Helper()
+	backoff := deadlockBackoff
+	deadlockBackoff = 0
+	t.Cleanup(func() { deadlockBackoff = backoff })
+}
+
+// fakeSQLiteError 模拟 SQLite 驱动错误，驱动的错误类型字段未导出无法直接构造
+type fakeSQLiteError int
+
+func (e fakeSQLiteError) Error() string { return fmt.Sprintf("sqlite error %d", int(e)) }
+func (e fakeSQLiteError) Code() int     { return int(e) }
+
+func TestIsDeadlock(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&mysql.MySQLError{Number: 1213}, true},
+		{&mysql.MySQLError{Number: 1205}, true},
+		{&mysql.MySQLError{Number: 1062}, false},
+		{&pgconn.PgError{Code: "40P01"}, true},
+		{&pgconn.PgError{Code: "55P03"}, true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{fakeSQLiteError(5), true},
+		{fakeSQLiteError(6), true},
+		{fakeSQLiteError(517), true}, // SQLITE_BUSY_SNAPSHOT
+		{fmt.Errorf("update: %w", fakeSQLiteError(5)), true},
+		{fakeSQLiteError(19), false}, // SQLITE_CONSTRAINT
+		{errors.New("deadlock"), false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := IsDeadlock(c.err); got != c.want {
+			t.Errorf("IsDeadlock(%v) = %v，期望 %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestWithDeadlockRetrySucceedsAfterDeadlock(t *testing.T) {
+	noBackoff(t)
+	calls := 0
+	err := WithDeadlockRetry(func() error {
+		calls++
+		if calls == 1 {
+			return &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("重试后应成功: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("执行了 %d 次，期望2次", calls)
+	}
+}
+
+func TestWithDeadlockRetryGivesUp(t *testing.T) {
+	noBackoff(t)
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	calls := 0
+	err := WithDeadlockRetry(func() error {
+		calls++
+		return deadlock
+	})
+	if !errors.Is(err, deadlock) {
+		t.Fatalf("放弃重试后的错误为 %v，期望最后一次的死锁错误", err)
+	}
+	if calls != deadlockRetries+1 {
+		t.Fatalf("执行了 %d 次，期望 %d 次", calls, deadlockRetries+1)
+	}
+}
+
+func TestWithDeadlockRetryDoesNotRetryOtherErrors(t *testing.T) {
+	noBackoff(t)
+	failure := errors.New("唯一键冲突")
+	calls := 0
+	if err := WithDeadlockRetry(func() error {
+		calls++
+		return failure
+	}); !errors.Is(err, failure) || calls != 1 {
+		t.Fatalf("错误为 %v，执行了 %d 次，期望原错误且只执行1次", err, calls)
+	}
+}
+
+func TestWithDeadlockRetryBacksOff(t *testing.T) {
+	backoff := deadlockBackoff
+	deadlockBackoff = 5 * time.Millisecond
+	t.Cleanup(func() { deadlockBackoff = backoff })
+
+	start := time.Now()
+	WithDeadlockRetry(func() error { return &mysql.MySQLError{Number: 1213} })
+	// 3次重试依次等待 5、10、20 毫秒
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Fatalf("重试共等待 %v，期望按指数退避至少 35ms", elapsed)
+	}
+}
@@ -0,0 +1,69 @@
+package eventbus
+
+import "sync"
+
+// Handler 事件处理函数
+type Handler func(e Event)
+
+// Bus 进程内的发布订阅事件总线
+// 每个订阅者有独立的缓冲队列和 goroutine，按发布顺序依次处理；
+// 发布不会阻塞，订阅者处理不及时、缓冲区已满时丢弃该订阅者的事件
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	ch   chan Event
+	done chan struct{}
+}
+
+// New 创建事件总线
+func New() *Bus {
+	return &Bus{subscribers: make(map[*subscriber]struct{})}
+}
+
+// Subscribe 注册订阅者，buffer 为缓冲的事件数，返回取消订阅函数
+// 取消订阅会等待正在处理的事件结束，不能在 handler 中调用
+func (b *Bus) Subscribe(handler Handler, buffer int) (unsubscribe func()) {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	sub := &subscriber{
+		ch:   make(chan Event, buffer),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(sub.done)
+		for e := range sub.ch {
+			handler(e)
+		}
+	}()
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, sub)
+			b.mu.Unlock()
+			close(sub.ch)
+			<-sub.done
+		})
+	}
+}
+
+// Publish 向所有订阅者发布事件
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- e:
+		default:
+		}
+	}
+}
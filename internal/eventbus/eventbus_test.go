@@ -0,0 +1,79 @@
+package eventbus
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventsDeliveredInOrder(t *testing.T) {
+	b := New()
+	var (
+		mu     sync.Mutex
+		events []Event
+	)
+	unsubscribe := b.Subscribe(func(e Event) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}, 10)
+
+	run := TaskEvent{TaskID: 1, RunID: "run-1"}
+	published := []Event{
+		TaskStarted{TaskEvent: run},
+		TaskRetried{TaskEvent: run, Attempt: 1, Error: "exit status 1"},
+		TaskFailed{TaskEvent: run, Attempts: 2, Error: "exit status 1"},
+		TaskSucceeded{TaskEvent: run, Attempts: 1},
+	}
+	for _, e := range published {
+		b.Publish(e)
+	}
+	// 取消订阅等待已缓冲的事件处理完成
+	unsubscribe()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{TypeTaskStarted, TypeTaskRetried, TypeTaskFailed, TypeTaskSucceeded}
+	if len(events) != len(want) {
+		t.Fatalf("收到 %d 个事件，期望 %d 个", len(events), len(want))
+	}
+	for i, e := range events {
+		if e.Type() != want[i] {
+			t.Fatalf("第%d个事件为 %s，期望 %s", i+1, e.Type(), want[i])
+		}
+	}
+
+	// 取消订阅后不再收到事件
+	b.Publish(TaskStarted{TaskEvent: run})
+	if len(events) != len(want) {
+		t.Fatal("取消订阅后仍收到事件")
+	}
+}
+
+func TestSlowSubscriberDoesNotBlockPublish(t *testing.T) {
+	b := New()
+	release := make(chan struct{})
+	slow := b.Subscribe(func(e Event) { <-release }, 1)
+
+	fast := make(chan Event, 100)
+	defer b.Subscribe(func(e Event) { fast <- e }, 100)()
+
+	start := time.Now()
+	for i := 0; i < 50; i++ {
+		b.Publish(TaskStarted{TaskEvent: TaskEvent{TaskID: uint(i)}})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("发布耗时 %v，被处理缓慢的订阅者阻塞", elapsed)
+	}
+
+	// 其他订阅者不受影响，收到全部事件
+	for i := 0; i < 50; i++ {
+		select {
+		case <-fast:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("其他订阅者只收到 %d 个事件", i)
+		}
+	}
+	close(release)
+	slow()
+}
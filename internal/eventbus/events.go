@@ -0,0 +1,56 @@
+package eventbus
+
+import "time"
+
+// 事件类型
+const (
+	TypeTaskStarted   = "started"
+	TypeTaskSucceeded = "succeeded"
+	TypeTaskFailed    = "failed"
+	TypeTaskRetried   = "retried"
+)
+
+// Event 执行生命周期事件
+type Event interface {
+	Type() string
+}
+
+// TaskEvent 执行事件的公共字段
+type TaskEvent struct {
+	TaskID   uint      `json:"task_id"`   // 任务ID
+	TaskName string    `json:"task_name"` // 任务名称
+	RunID    string    `json:"run_id"`    // 执行ID
+	Time     time.Time `json:"time"`      // 事件时间
+}
+
+// TaskStarted 任务开始执行
+type TaskStarted struct {
+	TaskEvent
+}
+
+// TaskSucceeded 任务执行成功
+type TaskSucceeded struct {
+	TaskEvent
+	Duration int `json:"duration"` // 执行时长（秒）
+	Attempts int `json:"attempts"` // 尝试次数
+}
+
+// TaskFailed 任务执行失败（已用完重试次数）
+type TaskFailed struct {
+	TaskEvent
+	Duration int    `json:"duration"` // 执行时长（秒）
+	Attempts int    `json:"attempts"` // 尝试次数
+	Error    string `json:"error"`    // 错误信息
+}
+
+// TaskRetried 一次尝试失败，即将重试
+type TaskRetried struct {
+	TaskEvent
+	Attempt int    `json:"attempt"` // 失败的是第几次尝试
+	Error   string `json:"error"`   // 错误信息
+}
+
+func (TaskStarted) Type() string   { return TypeTaskStarted }
+func (TaskSucceeded) Type() string { return TypeTaskSucceeded }
+func (TaskFailed) Type() string    { return TypeTaskFailed }
+func (TaskRetried) Type() string   { return TypeTaskRetried }
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Config 日志配置
+type Config struct {
+	Format string `mapstructure:"format"` // 输出格式：text（默认）或 json
+	Level  string `mapstructure:"level"`  // 日志级别：debug、info（默认）、warn、error
+}
+
+// level 全局日志级别，可在运行时修改
+var level slog.LevelVar
+
+// parseLevel 解析日志级别
+func parseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("不支持的日志级别: %s", s)
+	}
+}
+
+// SetLevel 修改全局日志级别，立即生效
+func SetLevel(s string) error {
+	l, err := parseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// Init 按配置设置全局结构化日志，标准库 log 包的输出也会转为 info 级别的结构化日志
+func Init(config *Config) error {
+	if err := SetLevel(config.Level); err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: &level}
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("不支持的日志格式: %s", config.Format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
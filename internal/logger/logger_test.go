@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// captureStderr 将标准错误重定向到临时文件后按配置初始化日志，返回读取已输出日志行的函数
+func captureStderr(t *testing.T, config *Config) func() []map[string]interface{} {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, previous := os.Stderr, slog.Default()
+	os.Stderr = f
+	t.Cleanup(func() {
+		os.Stderr = stderr
+		slog.SetDefault(previous)
+		SetLevel("info")
+		f.Close()
+	})
+	if err := Init(config); err != nil {
+		t.Fatal(err)
+	}
+
+	return func() []map[string]interface{} {
+		t.Helper()
+		data, err := os.Open(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer data.Close()
+		var lines []map[string]interface{}
+		scanner := bufio.NewScanner(data)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("日志行不是 JSON: %s", scanner.Text())
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	read := captureStderr(t, &Config{Format: "json", Level: "info"})
+	slog.Debug("调试信息")
+	slog.Info("任务执行完成", "task_id", 7, "run_id", "run-1", "status", 1, "duration", 3)
+
+	lines := read()
+	if len(lines) != 1 {
+		t.Fatalf("输出了 %d 行日志，期望 debug 级别被过滤后只有1行", len(lines))
+	}
+	line := lines[0]
+	want := map[string]interface{}{"level": "INFO", "msg": "任务执行完成", "task_id": 7.0, "run_id": "run-1", "status": 1.0, "duration": 3.0}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("字段 %s 为 %v，期望 %v", k, line[k], v)
+		}
+	}
+	if _, ok := line["time"]; !ok {
+		t.Error("日志缺少 time 字段")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	read := captureStderr(t, &Config{Format: "json", Level: "warn"})
+	slog.Info("被过滤")
+	if err := SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	slog.Debug("修改级别后输出")
+	if lines := read(); len(lines) != 1 || lines[0]["msg"] != "修改级别后输出" {
+		t.Fatalf("输出的日志 %v，期望只有修改级别后的 debug 日志", lines)
+	}
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("不支持的日志级别应报错")
+	}
+}
+
+func TestInitRejectsInvalidConfig(t *testing.T) {
+	for _, config := range []*Config{{Format: "xml"}, {Level: "trace"}} {
+		if err := Init(config); err == nil {
+			t.Errorf("配置 %+v 应报错", *config)
+		}
+	}
+}
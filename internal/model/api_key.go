@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey 供自动化客户端使用的静态访问密钥，数据库中只保存哈希值
+type APIKey struct {
+	gorm.Model
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`      // 密钥名称
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"`     // 密钥前缀，便于识别
+	KeyHash    string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"` // 密钥的 SHA-256 哈希
+	Scope      string     `gorm:"type:varchar(20);not null" json:"scope"`      // 权限范围：read-只读，operate-可执行任务，full-完全访问
+	LastUsedAt *time.Time `json:"last_used_at"`                                // 最近使用时间
+}
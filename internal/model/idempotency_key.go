@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// IdempotencyKey 创建任务请求的幂等键，保留期内重复提交相同键的请求返回首次创建的任务
+type IdempotencyKey struct {
+	Key         string    `gorm:"type:varchar(100);primaryKey" json:"key"`       // 客户端提供的 Idempotency-Key
+	RequestHash string    `gorm:"type:varchar(64);not null" json:"request_hash"` // 请求体的 SHA-256，相同键的请求体必须一致
+	TaskID      uint      `gorm:"not null" json:"task_id"`                       // 首次请求创建的任务ID
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`                       // 首次请求时间，超过保留期后键可以重新使用
+}
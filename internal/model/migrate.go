@@ -0,0 +1,37 @@
+package model
+
+import "gorm.io/gorm"
+
+// AutoMigrate 自动迁移所有数据表
+func AutoMigrate(db *gorm.DB) error {
+	if err := dropLegacyTaskNameIndex(db); err != nil {
+		return err
+	}
+	return db.AutoMigrate(
+		&Task{},
+		&TaskLog{},
+		&APIKey{},
+		&TaskStats{},
+		&TaskAudit{},
+		&IdempotencyKey{},
+		&Setting{},
+	)
+}
+
+// dropLegacyTaskNameIndex 删除旧版本在 tasks.name 上单独创建的唯一索引
+// 该索引不包含 deleted_at，软删除的任务仍占用名称，导致无法用相同名称重新创建；AutoMigrate 不会删除已有索引
+func dropLegacyTaskNameIndex(db *gorm.DB) error {
+	m := db.Migrator()
+	switch db.Dialector.Name() {
+	case "mysql":
+		if m.HasIndex(&Task{}, "name") {
+			return m.DropIndex(&Task{}, "name")
+		}
+	case "postgres":
+		if m.HasConstraint(&Task{}, "tasks_name_key") {
+			return m.DropConstraint(&Task{}, "tasks_name_key")
+		}
+	}
+	// SQLite 的唯一约束在 AutoMigrate 重建表时去掉
+	return nil
+}
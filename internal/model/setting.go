@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Setting 需要持久化的运行时设置，按键保存，重启后恢复
+type Setting struct {
+	Key       string    `gorm:"type:varchar(100);primaryKey" json:"key"` // 设置项
+	Value     string    `gorm:"type:text" json:"value"`                  // 设置值
+	UpdatedAt time.Time `json:"updated_at"`                              // 最后修改时间
+}
+
+// 设置项
+const (
+	SettingSchedulerPaused = "scheduler_paused" // 调度器是否处于暂停（维护）模式，值为 true/false
+)
@@ -1,35 +1,201 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 // Task 定时任务模型
+// 名称唯一索引包含 deleted_at，软删除的任务不占用名称，可以用相同名称重新创建
+// 数据库不比较 NULL，未删除任务之间的名称唯一由创建和恢复时的检查保证
 type Task struct {
-	gorm.Model
-	Name        string    `gorm:"type:varchar(100);not null;unique" json:"name"`  // 任务名称
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index;uniqueIndex:idx_tasks_name_deleted_at,priority:2"`
+
+	Name string `gorm:"type:varchar(100);not null;uniqueIndex:idx_tasks_name_deleted_at,priority:1" json:"name"` // 任务名称
+
 	Spec        string    `gorm:"type:varchar(100);not null" json:"spec"`         // cron 表达式
 	Command     string    `gorm:"type:text;not null" json:"command"`              // 执行的命令
-	Status      int       `gorm:"type:tinyint;not null;default:1" json:"status"`  // 状态：1-启用，0-禁用
+	Status      int       `gorm:"type:smallint;not null;default:1" json:"status"` // 状态：1-启用，0-禁用
 	LastRunTime time.Time `json:"last_run_time"`                                  // 上次运行时间
 	NextRunTime time.Time `json:"next_run_time"`                                  // 下次运行时间
 	Timeout     int       `gorm:"type:int;not null;default:60" json:"timeout"`    // 超时时间（秒）
-	RetryTimes  int       `gorm:"type:int;not null;default:3" json:"retry_times"` // 重试次数
+	RetryTimes  *int      `gorm:"type:int;not null;default:3" json:"retry_times"` // 失败重试次数，0表示不重试，未设置时为3
 	RetryDelay  int       `gorm:"type:int;not null;default:5" json:"retry_delay"` // 重试延迟（秒）
 	Description string    `gorm:"type:varchar(500)" json:"description"`           // 任务描述
+	Tags        Tags      `gorm:"type:varchar(1000)" json:"tags"`                 // 任务标签
+	DryRun      bool      `gorm:"not null" json:"dry_run"`                        // 演练模式：只记录将要执行的命令，不实际执行
+	Priority    int       `gorm:"type:int;not null;default:0" json:"priority"`    // 优先级，数值越大越先执行
+
+	DependsOn        *uint `gorm:"index" json:"depends_on"`                              // 依赖的任务ID，该任务最近一次执行成功后才会触发
+	DependencyWindow int   `gorm:"type:int;not null;default:0" json:"dependency_window"` // 依赖任务成功结果的有效期（秒），0表示不限制
+
+	OnSuccessTaskID *uint `gorm:"index" json:"on_success_task_id"` // 执行成功后触发的后续任务ID
+	OnFailureTaskID *uint `gorm:"index" json:"on_failure_task_id"` // 执行失败（重试耗尽）后触发的后续任务ID
+
+	PreCheckURL    string `gorm:"type:varchar(500)" json:"pre_check_url"`    // 前置检查地址，定时触发时先 GET 该地址，条件不满足时跳过本次执行，为空时不检查
+	PreCheckExpect string `gorm:"type:varchar(255)" json:"pre_check_expect"` // 前置检查的响应体必须匹配的正则表达式，如 ^true$；为空时只要求返回 2xx 状态
+
+	Type        string `gorm:"type:varchar(20);not null;default:cron" json:"type"` // 触发类型：cron-定时，once-一次性（Spec为RFC3339时间），after-在其他任务完成后触发
+	AfterTaskID *uint  `gorm:"index" json:"after_task_id"`                         // after 类型：触发源任务ID
+	AfterOffset int    `gorm:"type:int;not null;default:0" json:"after_offset"`    // after 类型：触发源任务完成后延迟执行的时间（秒）
+
+	CallbackURL       string `gorm:"type:varchar(500)" json:"callback_url"`        // 执行完成后的回调地址
+	CallbackFormat    string `gorm:"type:varchar(10)" json:"callback_format"`      // 回调数据格式：json（默认）或 form
+	CallbackHealthURL string `gorm:"type:varchar(500)" json:"callback_health_url"` // 回调端健康检查地址，设置后发送前先探测，不可用时缓存回调待恢复后补发
+
+	ExecType string  `gorm:"type:varchar(20);not null;default:shell" json:"exec_type"` // 执行类型：shell-执行 Command 命令，grpc-调用 Target 上名为 Command 的方法，sql-在 Target 连接上执行 Command 语句，redis-执行 Command 中的 Redis 命令，mq-将 Body 发布到 Command 指定的主题（kafka://broker:port/topic），http-请求 Command 地址
+	Target   string  `gorm:"type:varchar(255)" json:"target"`                          // grpc 类型：服务地址（host:port），服务需开启反射；sql 类型：配置中的数据库连接名
+	Body     string  `gorm:"type:text" json:"body"`                                    // grpc 类型：JSON 格式的请求消息；mq 类型：消息内容；http 类型：请求体
+	Headers  Headers `gorm:"type:text" json:"headers"`                                 // mq 类型：消息头；http 类型：请求头
+
+	Method                string `gorm:"type:varchar(10)" json:"method"`                             // http 类型：请求方法（GET、POST、PUT、DELETE、PATCH、HEAD、OPTIONS），默认 GET
+	InsecureSkipVerify    bool   `gorm:"not null" json:"insecure_skip_verify"`                       // http 类型：不校验服务端证书，仅用于内部自签名证书
+	CACert                string `gorm:"type:text" json:"ca_cert"`                                   // http 类型：PEM 格式的 CA 证书，设置后只信任该 CA 签发的服务端证书
+	ClientCert            string `gorm:"type:text" json:"client_cert"`                               // http 类型：PEM 格式的客户端证书，用于 mTLS
+	ClientKey             string `gorm:"type:text" json:"client_key"`                                // http 类型：PEM 格式的客户端私钥，接口返回时脱敏
+	FollowRedirects       *bool  `gorm:"not null;default:true" json:"follow_redirects"`              // http 类型：是否跟随重定向，未设置时为 true
+	MaxRedirects          int    `gorm:"type:int;not null;default:0" json:"max_redirects"`           // http 类型：最多跟随的重定向次数，0表示使用默认值10
+	ConnectTimeout        int    `gorm:"type:int;not null;default:0" json:"connect_timeout"`         // http 类型：建立连接（含 DNS 解析）超时时间（秒），0表示只受 Timeout 限制
+	ResponseHeaderTimeout int    `gorm:"type:int;not null;default:0" json:"response_header_timeout"` // http 类型：发送请求后等待响应头的超时时间（秒），0表示只受 Timeout 限制
+
+	ExpectBodyRegex string `gorm:"type:varchar(255)" json:"expect_body_regex"` // http 类型：响应体必须匹配的正则表达式，不匹配时执行失败，按完整响应体判断，为空时不检查
+
+	OutputJSONPath string `gorm:"type:varchar(255)" json:"output_json_path"` // http 类型：从 JSON 响应体中提取结果值的路径，如 $.data.count，结果保存到执行日志的 ResultValue，为空时不提取
+
+	MemoryLimitMB int `gorm:"type:int;not null;default:0" json:"memory_limit_mb"` // shell 类型：内存上限（MB），超出时进程被终止，0表示不限制
+	CPUQuota      int `gorm:"type:int;not null;default:0" json:"cpu_quota"`       // shell 类型：CPU 配额，单核的百分比（50 为半核，200 为两核），需要 cgroup v2，0表示不限制
+
+	HeartbeatTimeout int    `gorm:"type:int;not null;default:0" json:"heartbeat_timeout"` // shell 类型：心跳超时（秒），超过该时间没有输出心跳行时判定任务卡住并提前终止，0表示不检测
+	HeartbeatPattern string `gorm:"type:varchar(255)" json:"heartbeat_pattern"`           // shell 类型：心跳行的正则表达式，为空时任意一行输出都算心跳
+
+	ValidFrom  *time.Time `json:"valid_from"`  // 生效时间，之前的触发被跳过，未设置时不限制
+	ValidUntil *time.Time `json:"valid_until"` // 失效时间，之后的触发被跳过并自动禁用任务，未设置时不限制
+
+	ActiveHours string `gorm:"type:varchar(20)" json:"active_hours"` // cron 类型：活动时间段，如 "09:00-18:00"（开始晚于结束表示跨0点），按调度时区计算，之外的触发被跳过，为空表示全天
+	ActiveDays  string `gorm:"type:varchar(50)" json:"active_days"`  // cron 类型：活动的星期，如 "mon-fri" 或 "mon,wed,fri"，为空表示每天
+
+	Deadline *time.Time `json:"deadline"` // 执行的绝对截止时间，每次执行最晚在 Timeout 与该时间中较早者被终止，未设置时只受 Timeout 限制
+
+	MissedPolicy string `gorm:"type:varchar(10);not null;default:skip" json:"missed_policy"` // cron 类型：服务停止期间错过的触发在启动时如何处理：skip-跳过（默认），run_once-补执行一次，run_all-每次错过的触发都补执行
+
+	MaxJitter int `gorm:"type:int;not null;default:0" json:"max_jitter"` // 定时触发后随机延迟 0~MaxJitter 秒再执行，避免大量任务同时触发，0表示不延迟，只适用于 cron 类型任务
+
+	MaxRuns int `gorm:"type:int;not null;default:0" json:"max_runs"` // 最多执行次数（按执行统计计数，重置统计后重新计数），达到后自动禁用，0表示不限制
+
+	SuccessRateThreshold float64 `gorm:"not null;default:0" json:"success_rate_threshold"` // 最近执行的成功率（0~1）低于该值时发送告警，0表示不告警
+
+	AlertOnFailure bool `gorm:"not null" json:"alert_on_failure"` // 执行失败（重试耗尽）时发送告警，之后首次执行成功时发送恢复通知；连续失败只告警一次
+
+	MaxConcurrent     int    `gorm:"type:int;not null;default:0" json:"max_concurrent"`                // 同一任务最多同时进行的执行数，0表示不限制
+	ConcurrencyPolicy string `gorm:"type:varchar(10);not null;default:skip" json:"concurrency_policy"` // 达到 MaxConcurrent 时的处理方式：skip-跳过新的执行（默认），queue-排队等待正在进行的执行结束
+
+	Version int `gorm:"type:int;not null;default:0" json:"version"` // 版本号，每次修改配置时加1；更新（PUT）时必须回传读取时的版本，与数据库中的版本不一致说明任务已被他人修改
+
+	QuietSuccess bool              `gorm:"not null" json:"quiet_success"` // 执行成功时不保存输出，失败时仍保存完整输出
+	DedupeParams bool              `gorm:"not null" json:"dedupe_params"` // 相同参数的执行不允许并发，不同参数可以并行
+	RunParams    map[string]string `gorm:"-" json:"-"`                    // 本次执行的参数，以 HAPPX1_PARAM_<KEY> 环境变量传给命令，不持久化
+	RunID        string            `gorm:"-" json:"-"`                    // 本次执行的ID，提交执行时生成，贯穿日志、回调和链路
+	CatchUp      bool              `gorm:"-" json:"-"`                    // 本次执行是否为启动时对错过触发的补执行
+	Manual       bool              `gorm:"-" json:"-"`                    // 本次执行是否为通过接口发起的手动执行
+	ChainDepth   int               `gorm:"-" json:"-"`                    // 本次执行在成功/失败后续任务链中的深度，直接触发时为0
+	ScheduledAt  time.Time         `gorm:"-" json:"-"`                    // 本次定时触发的计划时间（cron 或一次性任务到期），其他方式发起的执行为零值
+}
+
+// 任务触发类型
+const (
+	TaskTypeCron  = "cron"
+	TaskTypeOnce  = "once"
+	TaskTypeAfter = "after"
+)
+
+// 错过触发的处理策略
+const (
+	MissedPolicySkip    = "skip"
+	MissedPolicyRunOnce = "run_once"
+	MissedPolicyRunAll  = "run_all"
+)
+
+// 达到并发上限时的处理方式
+const (
+	ConcurrencyPolicySkip  = "skip"
+	ConcurrencyPolicyQueue = "queue"
+)
+
+// 任务执行类型
+const (
+	ExecTypeShell = "shell"
+	ExecTypeGRPC  = "grpc"
+	ExecTypeSQL   = "sql"
+	ExecTypeRedis = "redis"
+	ExecTypeMQ    = "mq"
+	ExecTypeHTTP  = "http"
+)
+
+// 回调数据格式
+const (
+	CallbackFormatJSON = "json"
+	CallbackFormatForm = "form"
+)
+
+// OutputEncodingBase64 日志输出为 base64 编码的原始字节
+const OutputEncodingBase64 = "base64"
+
+// RedactedSecret 接口返回时替代敏感字段的占位符
+const RedactedSecret = "******"
+
+// MarshalJSON 序列化时对客户端私钥脱敏
+func (t Task) MarshalJSON() ([]byte, error) {
+	type task Task
+	out := task(t)
+	if out.ClientKey != "" {
+		out.ClientKey = RedactedSecret
+	}
+	return json.Marshal(out)
+}
+
+// DefaultRetryTimes 未设置重试次数时的默认值
+const DefaultRetryTimes = 3
+
+// ShouldFollowRedirects 返回 http 类型任务是否跟随重定向，未设置时为 true
+func (t *Task) ShouldFollowRedirects() bool {
+	return t.FollowRedirects == nil || *t.FollowRedirects
+}
+
+// GetRetryTimes 返回失败重试次数，未设置时返回默认值
+func (t *Task) GetRetryTimes() int {
+	if t.RetryTimes == nil {
+		return DefaultRetryTimes
+	}
+	return *t.RetryTimes
 }
 
 // TaskLog 任务执行日志
 type TaskLog struct {
 	gorm.Model
 	TaskID     uint      `gorm:"not null" json:"task_id"`                        // 任务ID
-	Status     int       `gorm:"type:tinyint;not null" json:"status"`            // 状态：1-成功，0-失败
+	Status     int       `gorm:"type:smallint;not null" json:"status"`           // 状态：1-成功，0-失败
 	StartTime  time.Time `gorm:"not null" json:"start_time"`                     // 开始时间
 	EndTime    time.Time `json:"end_time"`                                       // 结束时间
 	Duration   int       `gorm:"type:int;not null" json:"duration"`              // 执行时长（秒）
 	Output     string    `gorm:"type:text" json:"output"`                        // 输出结果
 	Error      string    `gorm:"type:text" json:"error"`                         // 错误信息
 	RetryCount int       `gorm:"type:int;not null;default:0" json:"retry_count"` // 重试次数
+	DryRun     bool      `gorm:"not null" json:"dry_run"`                        // 是否为演练执行
+
+	RunID   string `gorm:"type:varchar(36);index" json:"run_id"`       // 执行ID，同一次执行的所有尝试相同
+	Attempt int    `gorm:"type:int;not null;default:1" json:"attempt"` // 第几次尝试，从1开始
+	Retried bool   `gorm:"not null;index" json:"retried"`              // 本次尝试失败后进行了重试；为 false 时是该次执行的最终结果
+
+	CatchUp bool `gorm:"not null" json:"catch_up"` // 是否为服务启动时对停止期间错过的触发的补执行
+
+	OutputEncoding string `gorm:"type:varchar(10)" json:"output_encoding"` // 输出的编码：空表示原样保存的 UTF-8 文本，base64 表示输出不是有效的 UTF-8，保存的是 base64 编码后的原始字节
+
+	ExitCode int `gorm:"type:int;not null;default:0" json:"exit_code"` // shell 命令的退出码，成功时为0，非 shell 类型或未能取得退出码的失败（如超时被终止、启动失败）为-1
+
+	ResultValue string `gorm:"type:varchar(255)" json:"result_value"` // http 类型：按任务的 OutputJSONPath 从响应体中提取的结果值，字符串原样保存，其他值保存为 JSON 文本，未提取时为空
 }
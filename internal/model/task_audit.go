@@ -0,0 +1,26 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TaskAudit 任务配置变更审计记录
+type TaskAudit struct {
+	ID        uint            `gorm:"primarykey" json:"id"`
+	TaskID    uint            `gorm:"not null;index" json:"task_id"`           // 任务ID
+	Action    string          `gorm:"type:varchar(20);not null" json:"action"` // 操作类型：create、update、delete、restore、enable、disable
+	Actor     string          `gorm:"type:varchar(100)" json:"actor"`          // 操作人，来自认证身份，未认证时为空
+	Diff      json.RawMessage `gorm:"type:text" json:"diff"`                   // 变化的字段：{"字段": {"before": 旧值, "after": 新值}}
+	CreatedAt time.Time       `json:"created_at"`                              // 操作时间
+}
+
+// 审计操作类型
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionEnable  = "enable"
+	AuditActionDisable = "disable"
+)
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// TaskStats 任务执行统计，每个任务一行，每次执行结束后累加
+type TaskStats struct {
+	ID            uint       `gorm:"primarykey" json:"-"`
+	TaskID        uint       `gorm:"not null;uniqueIndex" json:"task_id"`      // 任务ID
+	TotalRuns     int64      `gorm:"not null;default:0" json:"total_runs"`     // 执行次数
+	SuccessCount  int64      `gorm:"not null;default:0" json:"success_count"`  // 成功次数
+	FailureCount  int64      `gorm:"not null;default:0" json:"failure_count"`  // 失败次数
+	TotalDuration int64      `gorm:"not null;default:0" json:"total_duration"` // 累计执行时长（秒）
+	LastError     string     `gorm:"type:text" json:"last_error"`              // 最近一次失败的错误信息
+	LastFailure   *time.Time `json:"last_failure"`                             // 最近一次失败时间
+	LastSuccess   *time.Time `json:"last_success"`                             // 最近一次成功时间
+	ResetAt       *time.Time `json:"reset_at"`                                 // 最近一次重置统计的时间
+	UpdatedAt     time.Time  `json:"updated_at"`                               // 更新时间
+	AvgDuration   float64    `gorm:"-" json:"avg_duration"`                    // 平均执行时长（秒），读取时计算
+
+	ConsecutiveFailures int64      `gorm:"not null;default:0" json:"consecutive_failures"` // 连续失败次数，成功后清零
+	BreakerTrips        int64      `gorm:"not null;default:0" json:"breaker_trips"`        // 本轮连续失败中熔断的次数，决定下次熔断的暂停时长，成功后清零
+	SuspendedUntil      *time.Time `json:"suspended_until"`                                // 熔断暂停到该时间，之前的定时触发被跳过
+
+	SuccessRateAlertedAt *time.Time `json:"success_rate_alerted_at"` // 成功率过低告警的发送时间，成功率恢复后清空，清空前不重复告警
+	FailureAlertedAt     *time.Time `json:"failure_alerted_at"`      // 执行失败告警的发送时间，执行成功并发送恢复通知后清空，清空前不重复告警
+
+	SuccessRate float64 `gorm:"-" json:"success_rate"` // 成功率（0~1），读取时计算
+	P95Duration float64 `gorm:"-" json:"p95_duration"` // 最近执行的 P95 执行时长（秒），读取时计算
+	P99Duration float64 `gorm:"-" json:"p99_duration"` // 最近执行的 P99 执行时长（秒），读取时计算
+}
@@ -0,0 +1,68 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Notifier 告警通知
+type Notifier interface {
+	Notify(ctx context.Context, title, content string) error
+}
+
+// Config 告警通知配置
+type Config struct {
+	WebhookURL string `mapstructure:"webhook_url"` // 告警 webhook 地址，为空时不发送告警
+}
+
+// New 按配置创建告警通知，未配置时返回 nil
+func New(config *Config) Notifier {
+	if config.WebhookURL == "" {
+		return nil
+	}
+	return &WebhookNotifier{
+		URL:    config.WebhookURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WebhookNotifier 以 JSON 形式 POST 告警到 webhook 地址
+type WebhookNotifier struct {
+	URL    string
+	client *http.Client
+}
+
+// Notify 发送告警，请求体为 {"title": ..., "content": ...}
+func (n *WebhookNotifier) Notify(ctx context.Context, title, content string) error {
+	body, err := json.Marshal(map[string]string{"title": title, "content": content})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("告警 webhook 返回错误状态: %d", resp.StatusCode)
+	}
+	return nil
+}
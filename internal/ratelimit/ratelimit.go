@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"happx1/internal/auth"
+)
+
+// Config 接口限流配置
+type Config struct {
+	Enabled           bool    `mapstructure:"enabled"`             // 是否启用限流
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"` // 每个客户端每秒补充的请求数，默认10
+	Burst             int     `mapstructure:"burst"`               // 每个客户端最多可以连续发起的请求数，默认20
+}
+
+const (
+	defaultRequestsPerSecond = 10
+	defaultBurst             = 20
+	keyPrefix                = "happx1:ratelimit:"
+)
+
+// tokenBucket 令牌桶：按经过的时间补充令牌，有令牌时消耗一个并放行，否则返回需要等待的毫秒数
+// 状态保存在 Redis 中，多个实例共享同一客户端的令牌桶；时间由调用方传入，使用毫秒
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, wait}
+`)
+
+// Limiter 基于 Redis 令牌桶的按客户端限流
+type Limiter struct {
+	redis *redis.Client
+	rate  float64
+	burst int
+}
+
+// New 按配置创建限流器，未启用时返回 nil
+func New(redis *redis.Client, config *Config) *Limiter {
+	if !config.Enabled {
+		return nil
+	}
+	l := &Limiter{redis: redis, rate: config.RequestsPerSecond, burst: config.Burst}
+	if l.rate <= 0 {
+		l.rate = defaultRequestsPerSecond
+	}
+	if l.burst <= 0 {
+		l.burst = defaultBurst
+	}
+	return l
+}
+
+// Allow 为客户端 key 消耗一个令牌，不允许时返回需要等待的时间
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	result, err := tokenBucket.Run(ctx, l.redis, []string{keyPrefix + key},
+		l.rate, l.burst, time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
+
+// Middleware 按客户端限流，超出时返回 429 和 Retry-After 头（秒）
+// 放在认证中间件之后时按用户名或 API Key 名称限流，未认证的请求（如登录）按客户端IP限流
+// Redis 不可用时放行请求，避免限流故障导致接口不可用；l 为 nil 时不限流
+func (l *Limiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil {
+			c.Next()
+			return
+		}
+
+		allowed, wait, err := l.Allow(c.Request.Context(), clientKey(c))
+		if err != nil {
+			slog.Error("限流检查失败，放行请求", "error", err)
+			c.Next()
+			return
+		}
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "请求过于频繁，请稍后重试"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientKey 返回限流的客户端标识，API Key、用户和IP使用不同前缀，同名的 API Key 和用户不共用令牌桶
+func clientKey(c *gin.Context) string {
+	identity := auth.GetIdentity(c)
+	switch {
+	case identity == nil:
+		return "ip:" + c.ClientIP()
+	case identity.APIKey:
+		return "key:" + identity.Subject
+	default:
+		return "user:" + identity.Subject
+	}
+}
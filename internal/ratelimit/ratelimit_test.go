@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"happx1/internal/auth"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter 创建经过限流中间件的路由，返回内存 Redis 以便测试中关闭
+func newTestRouter(t *testing.T, config *Config) (*gin.Engine, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	r := gin.New()
+	r.GET("/tasks", New(rdb, config).Middleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r, mr
+}
+
+// doRequest 以 ip 为客户端地址发送请求
+func doRequest(r http.Handler, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.RemoteAddr = ip + ":12345"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareRejectsBeyondBurst(t *testing.T) {
+	r, _ := newTestRouter(t, &Config{Enabled: true, RequestsPerSecond: 1, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if w := doRequest(r, "192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("第%d个请求返回 %d，期望在突发上限内放行", i+1, w.Code)
+		}
+	}
+	w := doRequest(r, "192.0.2.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("超出上限的请求返回 %d，期望429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Fatalf("Retry-After 为 %q，期望1", got)
+	}
+
+	// 每个客户端使用独立的令牌桶
+	if w := doRequest(r, "192.0.2.2"); w.Code != http.StatusOK {
+		t.Fatalf("其他客户端的请求返回 %d，期望放行", w.Code)
+	}
+}
+
+func TestMiddlewareSeparatesAPIKeysFromUsers(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	// 由 X-Kind 头模拟认证结果，API Key 与用户同名
+	r := gin.New()
+	r.GET("/tasks", func(c *gin.Context) {
+		c.Set(auth.ContextKeyIdentity, &auth.Identity{Subject: "ops", APIKey: c.GetHeader("X-Kind") == "key"})
+	}, New(rdb, &Config{Enabled: true, RequestsPerSecond: 1, Burst: 1}).Middleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// do 以指定认证方式发送请求
+	do := func(kind string) int {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		req.Header.Set("X-Kind", kind)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do("user"); code != http.StatusOK {
+		t.Fatalf("用户的第一个请求返回 %d", code)
+	}
+	if code := do("user"); code != http.StatusTooManyRequests {
+		t.Fatalf("用户令牌用完后的请求返回 %d，期望429", code)
+	}
+	if code := do("key"); code != http.StatusOK {
+		t.Fatalf("同名 API Key 的请求返回 %d，期望使用独立的令牌桶", code)
+	}
+}
+
+func TestMiddlewareRefillsOverTime(t *testing.T) {
+	r, _ := newTestRouter(t, &Config{Enabled: true, RequestsPerSecond: 20, Burst: 1})
+
+	if w := doRequest(r, "192.0.2.1"); w.Code != http.StatusOK {
+		t.Fatalf("第一个请求返回 %d", w.Code)
+	}
+	if w := doRequest(r, "192.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("令牌用完后的请求返回 %d，期望429", w.Code)
+	}
+	// 每秒补充20个令牌，等待一个令牌的时间后再次放行
+	time.Sleep(100 * time.Millisecond)
+	if w := doRequest(r, "192.0.2.1"); w.Code != http.StatusOK {
+		t.Fatalf("补充令牌后的请求返回 %d，期望放行", w.Code)
+	}
+}
+
+func TestMiddlewareAllowsWhenRedisDown(t *testing.T) {
+	r, mr := newTestRouter(t, &Config{Enabled: true, Burst: 1})
+	mr.Close()
+
+	for i := 0; i < 3; i++ {
+		if w := doRequest(r, "192.0.2.1"); w.Code != http.StatusOK {
+			t.Fatalf("Redis 不可用时第%d个请求返回 %d，期望放行", i+1, w.Code)
+		}
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	if l := New(nil, &Config{}); l != nil {
+		t.Fatal("未启用时应返回 nil")
+	}
+	r := gin.New()
+	r.GET("/tasks", New(nil, &Config{}).Middleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	if w := doRequest(r, "192.0.2.1"); w.Code != http.StatusOK {
+		t.Fatalf("未启用限流时请求返回 %d", w.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// weekdayNames 活动日期使用的星期缩写
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ActiveWindow 任务的活动时间：每天的时间段和星期，只在其中的定时触发才执行
+type ActiveWindow struct {
+	start, end int     // 每天的开始和结束时间（距0点的分钟数），开始晚于结束时时间段跨过0点
+	days       [7]bool // 允许的星期，跨0点的时间段按开始那天计算
+	allDay     bool    // 未设置时间段，允许的星期全天都是活动时间
+}
+
+// ParseActiveHours 解析活动时间，hours 为 "09:00-18:00" 形式的时间段（开始晚于结束表示跨0点，如 "22:00-06:00"），
+// days 为逗号分隔的星期缩写或范围（如 "mon-fri,sun"），为空表示每天；两者都为空时返回nil，表示不限制
+func ParseActiveHours(hours, days string) (*ActiveWindow, error) {
+	hours, days = strings.TrimSpace(hours), strings.TrimSpace(days)
+	if hours == "" && days == "" {
+		return nil, nil
+	}
+
+	w := &ActiveWindow{allDay: hours == ""}
+	if hours != "" {
+		startText, endText, ok := strings.Cut(hours, "-")
+		if !ok {
+			return nil, fmt.Errorf("无效的活动时间段 %q，应为 HH:MM-HH:MM", hours)
+		}
+		var err error
+		if w.start, err = parseClock(startText); err != nil {
+			return nil, err
+		}
+		if w.end, err = parseClock(endText); err != nil {
+			return nil, err
+		}
+		if w.start == w.end {
+			return nil, fmt.Errorf("活动时间段的开始和结束时间不能相同: %s", hours)
+		}
+	}
+
+	if days == "" {
+		for i := range w.days {
+			w.days[i] = true
+		}
+		return w, nil
+	}
+	for _, part := range strings.Split(strings.ToLower(days), ",") {
+		part = strings.TrimSpace(part)
+		fromText, toText, isRange := strings.Cut(part, "-")
+		from, ok := weekdayNames[strings.TrimSpace(fromText)]
+		if !ok {
+			return nil, fmt.Errorf("无效的星期 %q，应为 mon、tue、wed、thu、fri、sat、sun", part)
+		}
+		to := from
+		if isRange {
+			if to, ok = weekdayNames[strings.TrimSpace(toText)]; !ok {
+				return nil, fmt.Errorf("无效的星期 %q，应为 mon、tue、wed、thu、fri、sat、sun", part)
+			}
+		}
+		// 范围可以跨周末，如 fri-mon
+		for d := from; ; d = (d + 1) % 7 {
+			w.days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return w, nil
+}
+
+// parseClock 解析 HH:MM 形式的时间，返回距0点的分钟数
+func parseClock(text string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间 %q，应为 HH:MM", text)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains 判断 t 是否在活动时间内，按 t 所在时区的本地时间计算，时间段包含开始时间、不包含结束时间
+func (w *ActiveWindow) Contains(t time.Time) bool {
+	if w.allDay {
+		return w.days[t.Weekday()]
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return w.days[t.Weekday()] && minute >= w.start && minute < w.end
+	}
+	// 跨0点：开始时间之后属于当天的时间段，结束时间之前属于前一天的时间段
+	if minute >= w.start {
+		return w.days[t.Weekday()]
+	}
+	return minute < w.end && w.days[(t.Weekday()+6)%7]
+}
+
+// inActiveHours 判断 at 是否在任务的活动时间内，按调度时区计算，未设置活动时间时总是返回 true
+func (s *Scheduler) inActiveHours(task *model.Task, at time.Time) bool {
+	window, err := ParseActiveHours(task.ActiveHours, task.ActiveDays)
+	if err != nil {
+		// 创建时已校验，无效时不限制，避免任务因配置问题永远不执行
+		slog.Warn("活动时间无效，不限制执行时间", "task_id", task.ID, "task_name", task.Name, "error", err)
+		return true
+	}
+	return window == nil || window.Contains(at.In(s.location))
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestActiveWindowContains(t *testing.T) {
+	// 2030-01-07 是星期一
+	at := func(day, hour, minute int) time.Time { return time.Date(2030, 1, day, hour, minute, 0, 0, time.UTC) }
+	cases := []struct {
+		hours, days string
+		at          time.Time
+		want        bool
+	}{
+		{"09:00-18:00", "", at(7, 10, 0), true},
+		{"09:00-18:00", "", at(7, 3, 0), false},
+		{"09:00-18:00", "", at(7, 9, 0), true},
+		{"09:00-18:00", "", at(7, 18, 0), false},
+		{"09:00-18:00", "mon-fri", at(12, 10, 0), false},
+		{"09:00-18:00", "fri-mon", at(13, 10, 0), true},
+		{"22:00-06:00", "", at(7, 23, 0), true},
+		{"22:00-06:00", "", at(7, 5, 59), true},
+		{"22:00-06:00", "", at(7, 12, 0), false},
+		// 跨0点的时间段按开始那天计算：周五22点开始的时间段包括周六凌晨
+		{"22:00-06:00", "fri", at(12, 3, 0), true},
+		{"22:00-06:00", "fri", at(11, 3, 0), false},
+		{"", "sat,sun", at(13, 12, 0), true},
+		{"", "sat,sun", at(7, 12, 0), false},
+	}
+	for _, c := range cases {
+		window, err := ParseActiveHours(c.hours, c.days)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := window.Contains(c.at); got != c.want {
+			t.Errorf("%q %q 在 %s: %v，期望 %v", c.hours, c.days, c.at.Format("Mon 15:04"), got, c.want)
+		}
+	}
+
+	for _, c := range []struct{ hours, days string }{
+		{"9-18", ""},
+		{"09:00-09:00", ""},
+		{"09:00-25:00", ""},
+		{"", "weekday"},
+	} {
+		if _, err := ParseActiveHours(c.hours, c.days); err == nil {
+			t.Errorf("%q %q 应被拒绝", c.hours, c.days)
+		}
+	}
+}
+
+func TestTriggerOutsideActiveHoursSkipped(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("缺少时区数据")
+	}
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{Timezone: "Asia/Tokyo"})
+	task := createTestTask(t, db, &model.Task{Command: "echo", ActiveHours: "09:00-18:00"})
+
+	// 活动时间按调度时区计算
+	if s.admit(task, time.Date(2030, 1, 7, 3, 0, 0, 0, tokyo)) {
+		t.Error("03:00 的触发应被跳过")
+	}
+	if !s.admit(task, time.Date(2030, 1, 7, 10, 0, 0, 0, tokyo)) {
+		t.Error("10:00 的触发应执行")
+	}
+	// UTC 01:00 为东京时间 10:00
+	if !s.admit(task, time.Date(2030, 1, 7, 1, 0, 0, 0, time.UTC)) {
+		t.Error("东京时间 10:00 的触发应执行")
+	}
+}
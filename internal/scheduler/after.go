@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"log/slog"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// scheduleAfterTasks 源任务完成后，按各自的偏移量安排以其为触发源的 after 任务
+// 待触发的 after 任务只保存在内存中，调度器停止时取消，重启后不会恢复，需等源任务下次完成后重新安排
+func (s *Scheduler) scheduleAfterTasks(source *model.Task, finishedAt time.Time) {
+	var tasks []model.Task
+	if err := s.db.Where("type = ? AND after_task_id = ? AND status = ?", model.TaskTypeAfter, source.ID, 1).
+		Find(&tasks).Error; err != nil {
+		slog.Error("加载后续任务失败", "task_id", source.ID, "task_name", source.Name, "error", err)
+		return
+	}
+
+	for i := range tasks {
+		task := tasks[i]
+		delay := time.Until(finishedAt.Add(time.Duration(task.AfterOffset) * time.Second))
+
+		s.mu.Lock()
+		// 调度器已停止时不再安排，与 stopAfterTimers 使用同一把锁，停止后不会留下定时器
+		select {
+		case <-s.stopCh:
+			s.mu.Unlock()
+			return
+		default:
+		}
+		// 源任务再次完成时以最新的完成时间为准
+		if timer, ok := s.afterTimers[task.ID]; ok {
+			timer.Stop()
+		}
+		var timer *time.Timer
+		timer = time.AfterFunc(delay, func() {
+			s.mu.Lock()
+			// 等待期间被源任务的再次完成替换或被取消时不删除新的定时器
+			if s.afterTimers[task.ID] == timer {
+				delete(s.afterTimers, task.ID)
+			}
+			s.mu.Unlock()
+			s.trigger(&task)
+		})
+		s.afterTimers[task.ID] = timer
+		s.mu.Unlock()
+	}
+}
+
+// stopAfterTimers 取消所有待触发的 after 任务，调度器停止时调用
+func (s *Scheduler) stopAfterTimers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, timer := range s.afterTimers {
+		timer.Stop()
+		delete(s.afterTimers, id)
+	}
+}
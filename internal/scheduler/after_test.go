@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestAfterTaskFiresOffsetAfterSource(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	source := createTestTask(t, db, &model.Task{Name: "report", Command: "echo report"})
+	cleanup := createTestTask(t, db, &model.Task{Name: "cleanup", Type: model.TaskTypeAfter, AfterTaskID: &source.ID, AfterOffset: 1, Command: "echo cleanup"})
+
+	sourceLog := s.ExecuteTask(source)
+	waitFor(t, 5*time.Second, "后续任务在偏移量之后执行", func() bool { return countLogs(t, db, cleanup.ID) == 1 })
+
+	var cleanupLog model.TaskLog
+	db.Where("task_id = ?", cleanup.ID).First(&cleanupLog)
+	offset := cleanupLog.StartTime.Sub(sourceLog.EndTime)
+	if offset < time.Second || offset > 2*time.Second {
+		t.Fatalf("后续任务在触发源完成 %v 后执行，期望约1秒", offset)
+	}
+}
+
+func TestAfterTaskUsesLatestCompletion(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	source := createTestTask(t, db, &model.Task{Name: "report", Command: "echo report"})
+	cleanup := createTestTask(t, db, &model.Task{Name: "cleanup", Type: model.TaskTypeAfter, AfterTaskID: &source.ID, AfterOffset: 1, Command: "echo cleanup"})
+
+	// 偏移时间内触发源再次完成，只按最新的完成时间执行一次
+	s.ExecuteTask(source)
+	time.Sleep(500 * time.Millisecond)
+	latest := s.ExecuteTask(source)
+	waitFor(t, 5*time.Second, "后续任务执行", func() bool { return countLogs(t, db, cleanup.ID) == 1 })
+
+	var cleanupLog model.TaskLog
+	db.Where("task_id = ?", cleanup.ID).First(&cleanupLog)
+	if cleanupLog.StartTime.Before(latest.EndTime.Add(time.Second)) {
+		t.Fatalf("后续任务在 %v 执行，早于最新完成时间加偏移量 %v", cleanupLog.StartTime, latest.EndTime.Add(time.Second))
+	}
+	time.Sleep(700 * time.Millisecond)
+	if n := countLogs(t, db, cleanup.ID); n != 1 {
+		t.Fatalf("后续任务执行了 %d 次，期望1次", n)
+	}
+}
+
+func TestRemoveTaskCancelsAfterTimer(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	source := createTestTask(t, db, &model.Task{Name: "report", Command: "echo report"})
+	cleanup := createTestTask(t, db, &model.Task{Name: "cleanup", Type: model.TaskTypeAfter, AfterTaskID: &source.ID, AfterOffset: 1, Command: "echo cleanup"})
+
+	s.ExecuteTask(source)
+	s.RemoveTask(cleanup.ID)
+	time.Sleep(1500 * time.Millisecond)
+	if n := countLogs(t, db, cleanup.ID); n != 0 {
+		t.Fatalf("移除后后续任务仍执行了 %d 次", n)
+	}
+}
+
+func TestAfterTimerRemovedAfterFiring(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	source := createTestTask(t, db, &model.Task{Name: "report", Command: "echo report"})
+	cleanup := createTestTask(t, db, &model.Task{Name: "cleanup", Type: model.TaskTypeAfter, AfterTaskID: &source.ID, AfterOffset: 1, Command: "echo cleanup"})
+
+	s.ExecuteTask(source)
+	waitFor(t, 5*time.Second, "后续任务执行", func() bool { return countLogs(t, db, cleanup.ID) == 1 })
+	s.mu.Lock()
+	pending := len(s.afterTimers)
+	s.mu.Unlock()
+	if pending != 0 {
+		t.Fatalf("触发后仍保留 %d 个定时器", pending)
+	}
+}
+
+func TestStopCancelsAfterTimers(t *testing.T) {
+	db := newTestDB(t)
+	s := NewScheduler(db, nil, &Config{})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	source := createTestTask(t, db, &model.Task{Name: "report", Command: "echo report"})
+	cleanup := createTestTask(t, db, &model.Task{Name: "cleanup", Type: model.TaskTypeAfter, AfterTaskID: &source.ID, AfterOffset: 1, Command: "echo cleanup"})
+
+	// 停止后待触发的 after 任务被取消，不会在停止后触发
+	s.ExecuteTask(source)
+	s.Stop()
+	s.mu.Lock()
+	pending := len(s.afterTimers)
+	s.mu.Unlock()
+	if pending != 0 {
+		t.Fatalf("停止后仍有 %d 个待触发的定时器", pending)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if n := countLogs(t, db, cleanup.ID); n != 0 {
+		t.Fatalf("停止后后续任务仍执行了 %d 次", n)
+	}
+}
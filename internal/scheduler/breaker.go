@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"happx1/internal/database"
+	"happx1/internal/model"
+)
+
+// 熔断暂停时长的默认值
+const (
+	defaultBreakerCooldown    = time.Minute
+	defaultBreakerMaxCooldown = time.Hour
+)
+
+// suspendedUntil 返回任务熔断暂停的截止时间，未熔断时返回零值，查询失败时视为未熔断
+func (s *Scheduler) suspendedUntil(task *model.Task) time.Time {
+	if s.config.BreakerThreshold <= 0 {
+		return time.Time{}
+	}
+
+	var stats model.TaskStats
+	if err := s.db.Where("task_id = ?", task.ID).Limit(1).Find(&stats).Error; err != nil {
+		slog.Error("查询任务熔断状态失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+		return time.Time{}
+	}
+	if stats.SuspendedUntil == nil {
+		return time.Time{}
+	}
+	return *stats.SuspendedUntil
+}
+
+// breakerCooldown 返回第 trips 次熔断的暂停时长：首次为 BreakerCooldown，之后每次翻倍，不超过 BreakerMaxCooldown
+func (s *Scheduler) breakerCooldown(trips int64) time.Duration {
+	cooldown, maxCooldown := defaultBreakerCooldown, defaultBreakerMaxCooldown
+	if s.config.BreakerCooldown > 0 {
+		cooldown = time.Duration(s.config.BreakerCooldown) * time.Second
+	}
+	if s.config.BreakerMaxCooldown > 0 {
+		maxCooldown = time.Duration(s.config.BreakerMaxCooldown) * time.Second
+	}
+	for i := int64(1); i < trips && cooldown < maxCooldown; i++ {
+		cooldown *= 2
+	}
+	if cooldown > maxCooldown {
+		cooldown = maxCooldown
+	}
+	return cooldown
+}
+
+// checkBreaker 在执行失败后检查连续失败次数，达到 BreakerThreshold 时熔断：暂停定时触发一段时间，到期后自动恢复
+// 恢复后的执行仍失败时立即再次熔断，暂停时长翻倍；执行成功后熔断关闭（见 recordStats）
+func (s *Scheduler) checkBreaker(task *model.Task, taskLog *model.TaskLog) {
+	if s.config.BreakerThreshold <= 0 || taskLog.Status == 1 || taskLog.DryRun {
+		return
+	}
+
+	var stats model.TaskStats
+	if err := s.db.Where("task_id = ?", task.ID).Limit(1).Find(&stats).Error; err != nil {
+		slog.Error("查询任务连续失败次数失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+		return
+	}
+	if stats.ConsecutiveFailures < int64(s.config.BreakerThreshold) {
+		return
+	}
+
+	trips := stats.BreakerTrips + 1
+	cooldown := s.breakerCooldown(trips)
+	until := time.Now().Add(cooldown)
+	if err := database.WithDeadlockRetry(func() error {
+		return s.db.Model(&model.TaskStats{}).Where("task_id = ?", task.ID).Updates(map[string]interface{}{
+			"breaker_trips":   gorm.Expr("breaker_trips + 1"),
+			"suspended_until": until,
+		}).Error
+	}); err != nil {
+		slog.Error("任务熔断失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+		return
+	}
+	slog.Warn("任务连续失败，已熔断",
+		"task_id", task.ID,
+		"task_name", task.Name,
+		"consecutive_failures", stats.ConsecutiveFailures,
+		"trips", trips,
+		"cooldown", cooldown,
+		"suspended_until", until,
+	)
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestBreakerCooldown(t *testing.T) {
+	s := NewScheduler(nil, nil, &Config{BreakerCooldown: 60, BreakerMaxCooldown: 300})
+	cases := []struct {
+		trips int64
+		want  time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 5 * time.Minute},
+		{10, 5 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := s.breakerCooldown(c.trips); got != c.want {
+			t.Errorf("第%d次熔断暂停 %v，期望 %v", c.trips, got, c.want)
+		}
+	}
+}
+
+func TestBreakerSuspendsAndResumes(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{BreakerThreshold: 5, BreakerCooldown: 1})
+	task := createTestTask(t, db, &model.Task{Command: "exit 1"})
+
+	// stats 返回任务的执行统计
+	stats := func() model.TaskStats {
+		var stats model.TaskStats
+		if err := db.Where("task_id = ?", task.ID).First(&stats).Error; err != nil {
+			t.Fatal(err)
+		}
+		return stats
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := s.SubmitAndWait(context.Background(), newRun(task)); err != nil {
+			t.Fatal(err)
+		}
+		if i < 4 && stats().SuspendedUntil != nil {
+			t.Fatalf("连续失败 %d 次就熔断了", i+1)
+		}
+	}
+	if st := stats(); st.SuspendedUntil == nil || st.BreakerTrips != 1 || st.ConsecutiveFailures != 5 {
+		t.Fatalf("连续失败5次后 suspended_until=%v trips=%d failures=%d，期望熔断", st.SuspendedUntil, st.BreakerTrips, st.ConsecutiveFailures)
+	}
+
+	// 熔断期间定时触发被跳过
+	s.trigger(newRun(task))
+	time.Sleep(200 * time.Millisecond)
+	if n := countLogs(t, db, task.ID); n != 5 {
+		t.Fatalf("熔断期间执行了 %d 次", n-5)
+	}
+
+	// 暂停到期后恢复触发，成功后熔断关闭
+	waitFor(t, 3*time.Second, "熔断到期", func() bool { return time.Now().After(*stats().SuspendedUntil) })
+	recovered := newRun(task)
+	recovered.Command = "echo recovered"
+	s.trigger(recovered)
+	waitFor(t, 3*time.Second, "恢复后的执行完成", func() bool { return countLogs(t, db, task.ID) == 6 })
+	if st := stats(); st.SuspendedUntil != nil || st.BreakerTrips != 0 || st.ConsecutiveFailures != 0 {
+		t.Fatalf("成功后 suspended_until=%v trips=%d failures=%d，期望熔断关闭", st.SuspendedUntil, st.BreakerTrips, st.ConsecutiveFailures)
+	}
+}
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// callbackTimeout 发送回调和探测回调端健康状态的超时时间
+const callbackTimeout = 10 * time.Second
+
+// callbackData 构造回调数据
+func callbackData(task *model.Task, taskLog *model.TaskLog) map[string]interface{} {
+	return map[string]interface{}{
+		"task_id":         task.ID,
+		"task_name":       task.Name,
+		"run_id":          taskLog.RunID,
+		"status":          taskLog.Status,
+		"start_time":      taskLog.StartTime,
+		"end_time":        taskLog.EndTime,
+		"duration":        taskLog.Duration,
+		"output":          taskLog.Output,
+		"output_encoding": taskLog.OutputEncoding,
+		"result_value":    taskLog.ResultValue,
+		"error":           taskLog.Error,
+		"exit_code":       taskLog.ExitCode,
+		"retry_count":     taskLog.RetryCount,
+		"dry_run":         taskLog.DryRun,
+	}
+}
+
+// EncodeCallback 按回调格式编码回调数据，返回请求体和 Content-Type
+func EncodeCallback(format string, data map[string]interface{}) ([]byte, string, error) {
+	switch format {
+	case model.CallbackFormatJSON, "":
+		body, err := json.Marshal(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return body, "application/json", nil
+	case model.CallbackFormatForm:
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		values := url.Values{}
+		for _, k := range keys {
+			switch v := data[k].(type) {
+			case time.Time:
+				values.Set(k, v.Format(time.RFC3339))
+			default:
+				values.Set(k, fmt.Sprint(v))
+			}
+		}
+		return []byte(values.Encode()), "application/x-www-form-urlencoded", nil
+	default:
+		return nil, "", fmt.Errorf("不支持的回调格式: %s", format)
+	}
+}
+
+// callbackRequest 一次待发送的回调
+type callbackRequest struct {
+	TaskID      uint      `json:"task_id"`
+	TaskName    string    `json:"task_name"`
+	RunID       string    `json:"run_id"`
+	URL         string    `json:"url"`
+	HealthURL   string    `json:"health_url,omitempty"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// sendCallback 将执行结果发送到任务配置的回调地址
+// 配置了健康检查地址时先探测，回调端不可用或发送失败则缓存到 Redis，待恢复后补发
+func (s *Scheduler) sendCallback(task *model.Task, taskLog *model.TaskLog) {
+	if task.CallbackURL == "" {
+		return
+	}
+
+	body, contentType, err := EncodeCallback(task.CallbackFormat, callbackData(task, taskLog))
+	if err != nil {
+		slog.Error("编码回调数据失败", "task_id", task.ID, "task_name", task.Name, "run_id", taskLog.RunID, "error", err)
+		return
+	}
+
+	req := &callbackRequest{
+		TaskID:      task.ID,
+		TaskName:    task.Name,
+		RunID:       taskLog.RunID,
+		URL:         task.CallbackURL,
+		HealthURL:   task.CallbackHealthURL,
+		ContentType: contentType,
+		Body:        body,
+		CreatedAt:   time.Now(),
+	}
+
+	if req.HealthURL == "" {
+		if err := s.deliverCallback(req); err != nil {
+			slog.Error("发送回调失败", "task_id", task.ID, "task_name", task.Name, "run_id", req.RunID, "error", err)
+		}
+		return
+	}
+
+	if err := s.probeCallback(req.HealthURL); err != nil {
+		slog.Warn("回调端不可用，缓存回调", "task_id", task.ID, "task_name", task.Name, "run_id", req.RunID, "error", err)
+		s.bufferCallback(req)
+		return
+	}
+	if err := s.deliverCallback(req); err != nil {
+		slog.Warn("发送回调失败，缓存回调", "task_id", task.ID, "task_name", task.Name, "run_id", req.RunID, "error", err)
+		s.bufferCallback(req)
+	}
+}
+
+// callbackClient 发送回调的客户端，与 http 类型任务一样不允许连接禁止访问的网段
+func (s *Scheduler) callbackClient() *http.Client {
+	return &http.Client{Transport: s.callbackTransport, Timeout: callbackTimeout}
+}
+
+// deliverCallback 发送回调，返回错误状态码时视为失败
+func (s *Scheduler) deliverCallback(req *callbackRequest) error {
+	resp, err := s.callbackClient().Post(req.URL, req.ContentType, bytes.NewReader(req.Body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("回调返回错误状态: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// probeCallback 探测回调端健康状态，2xx 视为健康
+func (s *Scheduler) probeCallback(healthURL string) error {
+	resp, err := s.callbackClient().Get(healthURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("健康检查返回状态: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+const (
+	callbackBufferKey              = "happx1:callback:buffer"
+	defaultCallbackFlushInterval   = 30 * time.Second
+	defaultCallbackBufferMaxLength = 10000
+)
+
+// bufferCallback 将回调缓存到 Redis，超出上限时丢弃最早的回调
+func (s *Scheduler) bufferCallback(req *callbackRequest) {
+	if s.redis == nil {
+		slog.Warn("未配置Redis，丢弃回调", "task_id", req.TaskID, "task_name", req.TaskName, "run_id", req.RunID)
+		return
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		slog.Error("序列化回调失败", "task_id", req.TaskID, "task_name", req.TaskName, "run_id", req.RunID, "error", err)
+		return
+	}
+
+	maxLen := int64(defaultCallbackBufferMaxLength)
+	if s.config.CallbackBufferMaxLength > 0 {
+		maxLen = int64(s.config.CallbackBufferMaxLength)
+	}
+
+	ctx := context.Background()
+	pipe := s.redis.TxPipeline()
+	pipe.RPush(ctx, callbackBufferKey, data)
+	pipe.LTrim(ctx, callbackBufferKey, -maxLen, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Error("缓存回调失败", "task_id", req.TaskID, "task_name", req.TaskName, "run_id", req.RunID, "error", err)
+	}
+}
+
+// runCallbackFlusher 定期补发缓存的回调，直到调度器停止
+func (s *Scheduler) runCallbackFlusher() {
+	defer s.wg.Done()
+
+	interval := defaultCallbackFlushInterval
+	if s.config.CallbackFlushInterval > 0 {
+		interval = time.Duration(s.config.CallbackFlushInterval) * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flushCallbacks()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// flushCallbacks 补发一轮缓存的回调：回调端恢复健康的发送，仍不可用或发送失败的放回队尾
+func (s *Scheduler) flushCallbacks() {
+	ctx := context.Background()
+	n, err := s.redis.LLen(ctx, callbackBufferKey).Result()
+	if err != nil {
+		slog.Error("读取回调缓存失败", "error", err)
+		return
+	}
+
+	// 同一轮中每个健康检查地址只探测一次
+	healthy := make(map[string]bool)
+	for i := int64(0); i < n; i++ {
+		data, err := s.redis.LPop(ctx, callbackBufferKey).Bytes()
+		if err != nil {
+			return
+		}
+
+		var req callbackRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			slog.Warn("丢弃无法解析的缓存回调", "error", err)
+			continue
+		}
+
+		ok, probed := healthy[req.HealthURL]
+		if !probed {
+			ok = req.HealthURL == "" || s.probeCallback(req.HealthURL) == nil
+			healthy[req.HealthURL] = ok
+		}
+		if ok {
+			if err := s.deliverCallback(&req); err == nil {
+				slog.Info("已补发缓存的回调", "task_id", req.TaskID, "task_name", req.TaskName, "run_id", req.RunID)
+				continue
+			}
+			healthy[req.HealthURL] = false
+		}
+
+		if err := s.redis.RPush(ctx, callbackBufferKey, data).Err(); err != nil {
+			slog.Error("回调放回缓存失败", "task_id", req.TaskID, "task_name", req.TaskName, "run_id", req.RunID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"happx1/internal/model"
+)
+
+func TestCallbackBufferedUntilRecovery(t *testing.T) {
+	var (
+		up       atomic.Bool
+		mu       sync.Mutex
+		received []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/health" {
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	_, rdb := newTestRedis(t)
+	s := newGuardedScheduler(t, allowLoopback)
+	s.redis = rdb
+	task := &model.Task{ID: 7, Name: "cb", CallbackURL: srv.URL + "/callback", CallbackHealthURL: srv.URL + "/health"}
+
+	// 回调端不可用时缓存回调，不发送
+	for _, runID := range []string{"run-1", "run-2"} {
+		s.sendCallback(task, &model.TaskLog{TaskID: 7, RunID: runID, Status: 1})
+	}
+	ctx := context.Background()
+	if n := rdb.LLen(ctx, callbackBufferKey).Val(); n != 2 {
+		t.Fatalf("缓存了 %d 个回调，期望2个", n)
+	}
+
+	// 仍不可用时补发失败的回调留在缓存中
+	s.flushCallbacks()
+	if n := rdb.LLen(ctx, callbackBufferKey).Val(); n != 2 {
+		t.Fatalf("回调端不可用时补发后缓存剩余 %d 个，期望2个", n)
+	}
+
+	// 恢复后补发全部缓存的回调
+	up.Store(true)
+	s.flushCallbacks()
+	if n := rdb.LLen(ctx, callbackBufferKey).Val(); n != 0 {
+		t.Fatalf("恢复后补发，缓存剩余 %d 个", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("回调端收到 %d 个回调，期望2个", len(received))
+	}
+}
+
+func TestCallbackBufferTrimmed(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	s := NewScheduler(nil, rdb, &Config{CallbackBufferMaxLength: 2})
+	for _, runID := range []string{"run-1", "run-2", "run-3"} {
+		s.bufferCallback(&callbackRequest{RunID: runID})
+	}
+
+	// 超出上限时丢弃最早的回调
+	ctx := context.Background()
+	if n := rdb.LLen(ctx, callbackBufferKey).Val(); n != 2 {
+		t.Fatalf("缓存了 %d 个回调，期望2个", n)
+	}
+	if first := rdb.LIndex(ctx, callbackBufferKey, 0).Val(); !strings.Contains(first, `"run-2"`) {
+		t.Fatalf("最早的缓存回调为 %s，期望 run-2", first)
+	}
+}
@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// allowLoopback 测试中用于替换默认网段的禁止访问网段，使本机的测试服务器可以访问
+const allowLoopback = "203.0.113.0/24"
+
+func TestCallbackBlockedAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("禁止访问的回调地址收到了请求")
+	}))
+	defer srv.Close()
+
+	s := newGuardedScheduler(t)
+	req := &callbackRequest{URL: srv.URL, ContentType: "application/json", Body: []byte("{}")}
+	if err := s.deliverCallback(req); err == nil || !strings.Contains(err.Error(), "禁止访问的地址") {
+		t.Fatalf("发送回调的错误为 %v，期望连接被拦截", err)
+	}
+	if err := s.probeCallback(srv.URL); err == nil || !strings.Contains(err.Error(), "禁止访问的地址") {
+		t.Fatalf("健康检查的错误为 %v，期望连接被拦截", err)
+	}
+}
+
+func TestCallbackDelivered(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var data map[string]interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			t.Errorf("回调数据不是 JSON: %s", body)
+		}
+		received <- data
+	}))
+	defer srv.Close()
+
+	s := newGuardedScheduler(t, allowLoopback)
+	task := &model.Task{ID: 7, Name: "cb", CallbackURL: srv.URL}
+	s.sendCallback(task, &model.TaskLog{TaskID: 7, RunID: "run-1", Status: 1, Output: "ok"})
+
+	data := <-received
+	if data["run_id"] != "run-1" || data["task_name"] != "cb" || data["output"] != "ok" {
+		t.Fatalf("回调数据 %v 不正确", data)
+	}
+}
+
+func TestEncodeCallback(t *testing.T) {
+	start := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	data := map[string]interface{}{"task_id": uint(7), "status": 1, "output": "a&b=c", "start_time": start}
+
+	body, contentType, err := EncodeCallback(model.CallbackFormatJSON, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/json" {
+		t.Fatalf("JSON 格式的 Content-Type 为 %s", contentType)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("JSON 回调数据无法解析: %s", body)
+	}
+	if decoded["output"] != "a&b=c" || decoded["start_time"] != "2024-03-10T08:00:00Z" || decoded["status"] != 1.0 || decoded["task_id"] != 7.0 {
+		t.Fatalf("JSON 回调数据为 %s", body)
+	}
+	if _, _, err := EncodeCallback("", data); err != nil {
+		t.Fatalf("未设置格式时应使用 JSON: %v", err)
+	}
+
+	body, contentType, err = EncodeCallback(model.CallbackFormatForm, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/x-www-form-urlencoded" {
+		t.Fatalf("表单格式的 Content-Type 为 %s", contentType)
+	}
+	if string(body) != "output=a%26b%3Dc&start_time=2024-03-10T08%3A00%3A00Z&status=1&task_id=7" {
+		t.Fatalf("表单回调数据为 %s", body)
+	}
+
+	if _, _, err := EncodeCallback("xml", data); err == nil {
+		t.Fatal("不支持的格式应报错")
+	}
+}
+
+func TestCallbackDeliveredAsForm(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		received <- r
+	}))
+	defer srv.Close()
+
+	s := newGuardedScheduler(t, allowLoopback)
+	task := &model.Task{ID: 7, Name: "cb", CallbackURL: srv.URL, CallbackFormat: model.CallbackFormatForm}
+	s.sendCallback(task, &model.TaskLog{TaskID: 7, RunID: "run-1", Status: 1, Output: "ok"})
+
+	r := <-received
+	if ct := r.Header.Get("Content-Type"); ct != "application/x-www-form-urlencoded" {
+		t.Fatalf("回调的 Content-Type 为 %s", ct)
+	}
+	if r.PostForm.Get("run_id") != "run-1" || r.PostForm.Get("task_name") != "cb" || r.PostForm.Get("status") != "1" {
+		t.Fatalf("回调表单 %v 不正确", r.PostForm)
+	}
+}
+
+func TestRunIDInLogAndCallback(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&data)
+		received <- data
+	}))
+	defer srv.Close()
+
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{HTTPBlockedCIDRs: []string{allowLoopback}})
+	task := createTestTask(t, db, &model.Task{Command: "echo traced", CallbackURL: srv.URL})
+	taskLog := s.ExecuteTask(task)
+
+	var saved model.TaskLog
+	if err := db.Where("task_id = ?", task.ID).First(&saved).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.RunID == "" || saved.RunID != taskLog.RunID {
+		t.Fatalf("保存的执行ID %q 与返回的 %q 不一致", saved.RunID, taskLog.RunID)
+	}
+	select {
+	case data := <-received:
+		if data["run_id"] != saved.RunID {
+			t.Fatalf("回调中的执行ID %v，期望 %s", data["run_id"], saved.RunID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到回调")
+	}
+
+	// 每次执行生成新的执行ID
+	if next := s.ExecuteTask(newRun(task)); next.RunID == saved.RunID {
+		t.Fatal("两次执行使用了相同的执行ID")
+	}
+	<-received
+}
@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"log/slog"
+
+	"happx1/internal/model"
+)
+
+// maxChainDepth 成功/失败后续任务链的最大深度，超过时不再触发，避免循环链无限执行
+const maxChainDepth = 10
+
+// triggerFollowUp 按执行结果触发任务的后续任务：成功时触发 OnSuccessTaskID，失败时触发 OnFailureTaskID
+// 后续任务按定时触发的规则执行（生效时间、依赖、前置检查等），未启用时不触发
+func (s *Scheduler) triggerFollowUp(task *model.Task, taskLog *model.TaskLog) {
+	nextID := task.OnFailureTaskID
+	if taskLog.Status == 1 {
+		nextID = task.OnSuccessTaskID
+	}
+	if nextID == nil {
+		return
+	}
+	if task.ChainDepth >= maxChainDepth {
+		slog.Warn("后续任务链超过最大深度，不再触发", "task_id", task.ID, "task_name", task.Name, "run_id", task.RunID, "next_task_id", *nextID, "max_depth", maxChainDepth)
+		return
+	}
+
+	var next model.Task
+	if err := s.db.Where("id = ? AND status = ?", *nextID, 1).Limit(1).Find(&next).Error; err != nil {
+		slog.Error("加载后续任务失败", "task_id", task.ID, "task_name", task.Name, "next_task_id", *nextID, "error", err)
+		return
+	}
+	if next.ID == 0 {
+		slog.Info("后续任务不存在或未启用，不触发", "task_id", task.ID, "task_name", task.Name, "next_task_id", *nextID)
+		return
+	}
+	next.ChainDepth = task.ChainDepth + 1
+	slog.Info("触发后续任务", "task_id", task.ID, "task_name", task.Name, "run_id", task.RunID, "status", taskLog.Status, "next_task_id", next.ID, "next_task_name", next.Name, "depth", next.ChainDepth)
+	// 触发前可能等待随机延迟或请求前置检查，不占用当前 worker
+	go s.trigger(&next)
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestFollowUpByOutcome(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	onSuccess := createTestTask(t, db, &model.Task{Name: "on-success", Command: "echo success"})
+	onFailure := createTestTask(t, db, &model.Task{Name: "on-failure", Command: "echo failure"})
+
+	cases := []struct {
+		name     string
+		command  string
+		fired    *model.Task
+		notFired *model.Task
+	}{
+		{"成功", "true", onSuccess, onFailure},
+		{"失败", "false", onFailure, onSuccess},
+	}
+	for _, c := range cases {
+		before := countLogs(t, db, c.fired.ID)
+		skipped := countLogs(t, db, c.notFired.ID)
+		task := createTestTask(t, db, &model.Task{Name: "source-" + c.command, Command: c.command, OnSuccessTaskID: &onSuccess.ID, OnFailureTaskID: &onFailure.ID})
+		s.ExecuteTask(task)
+
+		waitFor(t, 5*time.Second, c.name+"后触发后续任务", func() bool { return countLogs(t, db, c.fired.ID) == before+1 })
+		time.Sleep(200 * time.Millisecond)
+		if n := countLogs(t, db, c.notFired.ID); n != skipped {
+			t.Fatalf("%s: 另一个后续任务 %s 也被触发", c.name, c.notFired.Name)
+		}
+	}
+}
+
+func TestFollowUpChainDepthLimited(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	// 绕过校验保存指向自身的后续任务，模拟循环链
+	task := createTestTask(t, db, &model.Task{Command: "true"})
+	if err := db.Model(task).Update("on_success_task_id", task.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s.ExecuteTask(task)
+	waitFor(t, 10*time.Second, "后续任务链执行到最大深度", func() bool { return countLogs(t, db, task.ID) == maxChainDepth+1 })
+	time.Sleep(300 * time.Millisecond)
+	if n := countLogs(t, db, task.ID); n != maxChainDepth+1 {
+		t.Fatalf("循环链执行了 %d 次，期望在深度 %d 停止", n, maxChainDepth)
+	}
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"log/slog"
+	"time"
+
+	"happx1/internal/model"
+)
+
+const (
+	logCleanupBatchSize       = 1000
+	defaultLogCleanupInterval = time.Hour
+)
+
+// runLogCleanup 定期清理过期和超出数量上限的任务日志，直到调度器停止
+func (s *Scheduler) runLogCleanup() {
+	defer s.wg.Done()
+
+	interval := defaultLogCleanupInterval
+	if s.config.CleanupInterval > 0 {
+		interval = time.Duration(s.config.CleanupInterval) * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.cleanupLogs()
+		select {
+		case <-ticker.C:
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// cleanupLogs 执行一次日志清理
+func (s *Scheduler) cleanupLogs() {
+	// 保留策略可热更新，读取当前值
+	s.mu.Lock()
+	retentionDays, maxLogs := s.config.LogRetentionDays, s.config.MaxLogsPerTask
+	s.mu.Unlock()
+
+	if retentionDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -retentionDays)
+		if deleted, err := s.deleteLogsBefore(cutoff); err != nil {
+			slog.Error("清理过期任务日志失败", "error", err)
+		} else if deleted > 0 {
+			slog.Info("已清理过期任务日志", "deleted", deleted, "retention_days", retentionDays)
+		}
+	}
+
+	if maxLogs > 0 {
+		if deleted, err := s.pruneExcessLogs(maxLogs); err != nil {
+			slog.Error("清理超出数量上限的任务日志失败", "error", err)
+		} else if deleted > 0 {
+			slog.Info("已清理超出数量上限的任务日志", "deleted", deleted, "max_logs_per_task", maxLogs)
+		}
+	}
+}
+
+// deleteLogsBefore 分批物理删除开始时间早于 cutoff 的日志
+func (s *Scheduler) deleteLogsBefore(cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		var ids []uint
+		if err := s.db.Unscoped().Model(&model.TaskLog{}).
+			Where("start_time < ?", cutoff).
+			Limit(logCleanupBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			return total, nil
+		}
+
+		result := s.db.Unscoped().Delete(&model.TaskLog{}, ids)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if len(ids) < logCleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// pruneExcessLogs 每个任务只保留最新的 keep 条日志，其余分批物理删除
+func (s *Scheduler) pruneExcessLogs(keep int) (int64, error) {
+	var taskIDs []uint
+	if err := s.db.Unscoped().Model(&model.TaskLog{}).
+		Group("task_id").
+		Having("COUNT(*) > ?", keep).
+		Pluck("task_id", &taskIDs).Error; err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, taskID := range taskIDs {
+		for {
+			var ids []uint
+			if err := s.db.Unscoped().Model(&model.TaskLog{}).
+				Where("task_id = ?", taskID).
+				Order("start_time desc, id desc").
+				Offset(keep).
+				Limit(logCleanupBatchSize).
+				Pluck("id", &ids).Error; err != nil {
+				return total, err
+			}
+			if len(ids) == 0 {
+				break
+			}
+
+			result := s.db.Unscoped().Delete(&model.TaskLog{}, ids)
+			if result.Error != nil {
+				return total, result.Error
+			}
+			total += result.RowsAffected
+			if len(ids) < logCleanupBatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"happx1/internal/model"
+)
+
+// insertLogs 为任务保存 n 条开始时间为 startTime 的日志
+func insertLogs(t *testing.T, db *gorm.DB, taskID uint, n int, startTime time.Time) {
+	t.Helper()
+	logs := make([]model.TaskLog, n)
+	for i := range logs {
+		logs[i] = model.TaskLog{TaskID: taskID, Status: 1, StartTime: startTime.Add(time.Duration(i) * time.Second), Attempt: 1}
+	}
+	if err := db.CreateInBatches(logs, 500).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCleanupRemovesExpiredLogs(t *testing.T) {
+	db := newTestDB(t)
+	s := NewScheduler(db, nil, &Config{LogRetentionDays: 7})
+	// 超过一批的过期日志分多批删除
+	insertLogs(t, db, 1, logCleanupBatchSize+200, time.Now().AddDate(0, 0, -10))
+	insertLogs(t, db, 1, 5, time.Now().AddDate(0, 0, -1))
+	insertLogs(t, db, 2, 3, time.Now())
+
+	s.cleanupLogs()
+
+	var remaining, expired int64
+	db.Unscoped().Model(&model.TaskLog{}).Count(&remaining)
+	db.Unscoped().Model(&model.TaskLog{}).Where("start_time < ?", time.Now().AddDate(0, 0, -7)).Count(&expired)
+	if remaining != 8 || expired != 0 {
+		t.Fatalf("清理后剩余 %d 条日志（其中过期 %d 条），期望只剩未过期的8条", remaining, expired)
+	}
+}
+
+func TestCleanupPrunesExcessLogsPerTask(t *testing.T) {
+	db := newTestDB(t)
+	s := NewScheduler(db, nil, &Config{MaxLogsPerTask: 3})
+	start := time.Now().Add(-time.Hour)
+	insertLogs(t, db, 1, 5, start)
+	insertLogs(t, db, 2, 2, start)
+
+	s.cleanupLogs()
+
+	var logs []model.TaskLog
+	db.Where("task_id = ?", 1).Order("start_time").Find(&logs)
+	if len(logs) != 3 || !logs[0].StartTime.Equal(start.Add(2*time.Second)) {
+		t.Fatalf("任务1剩余 %d 条日志，期望保留最新的3条", len(logs))
+	}
+	var other int64
+	db.Model(&model.TaskLog{}).Where("task_id = ?", 2).Count(&other)
+	if other != 2 {
+		t.Fatalf("未超出上限的任务剩余 %d 条日志，期望2条", other)
+	}
+}
+
+func TestCleanupDisabledByDefault(t *testing.T) {
+	db := newTestDB(t)
+	s := NewScheduler(db, nil, &Config{})
+	insertLogs(t, db, 1, 5, time.Now().AddDate(-1, 0, 0))
+
+	s.cleanupLogs()
+
+	var remaining int64
+	db.Model(&model.TaskLog{}).Count(&remaining)
+	if remaining != 5 {
+		t.Fatalf("未配置保留策略时删除了日志，剩余 %d 条", remaining)
+	}
+}
@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"errors"
+	"log/slog"
+
+	"happx1/internal/model"
+)
+
+// ErrConcurrencyLimit 任务正在进行的执行数已达 MaxConcurrent
+var ErrConcurrencyLimit = errors.New("该任务正在进行的执行数已达并发上限")
+
+// skipsOverLimit 任务设置了并发上限且超出时跳过新的执行，提交时按排队和执行中的数量检查
+func skipsOverLimit(task *model.Task) bool {
+	return task.MaxConcurrent > 0 && task.ConcurrencyPolicy != model.ConcurrencyPolicyQueue
+}
+
+// queuesOverLimit 任务设置了并发上限且超出时排队等待，按已提交到 worker 池的数量检查
+func queuesOverLimit(task *model.Task) bool {
+	return task.MaxConcurrent > 0 && task.ConcurrencyPolicy == model.ConcurrencyPolicyQueue
+}
+
+// dispatch 提交执行到 worker 池，done 同 workerPool.submit
+// queue 策略的任务已提交的执行数达到上限时，执行暂存在任务的等待列表中（parked 为 true），不占用 worker，有执行结束时再提交（见 releaseSlot）
+// 调度器已停止时关闭 done 并返回 ok 为 false，由调用方释放提交时的登记
+func (s *Scheduler) dispatch(task *model.Task, done chan *model.TaskLog) (parked, ok bool) {
+	if !queuesOverLimit(task) {
+		return false, s.pool.submit(task, done)
+	}
+
+	s.mu.Lock()
+	select {
+	case <-s.stopCh:
+		s.mu.Unlock()
+		if done != nil {
+			close(done)
+		}
+		return false, false
+	default:
+	}
+	if s.queuedRuns[task.ID] >= task.MaxConcurrent {
+		s.pendingRuns[task.ID] = append(s.pendingRuns[task.ID], &job{task: task, done: done})
+		s.mu.Unlock()
+		slog.Info("等待并发名额", "task_id", task.ID, "task_name", task.Name, "run_id", task.RunID, "max_concurrent", task.MaxConcurrent)
+		return true, true
+	}
+	s.queuedRuns[task.ID]++
+	s.mu.Unlock()
+
+	if !s.pool.submit(task, done) {
+		s.releaseSlot(task)
+		return false, false
+	}
+	return false, true
+}
+
+// releaseSlot queue 策略的执行结束（或被 worker 池丢弃）后释放名额，并按新的名额把等待列表中的执行提交到 worker 池
+// 并发上限修改后按等待中的执行携带的上限计算
+func (s *Scheduler) releaseSlot(task *model.Task) {
+	if !queuesOverLimit(task) {
+		return
+	}
+
+	s.mu.Lock()
+	s.queuedRuns[task.ID]--
+	var ready []*job
+	waiting := s.pendingRuns[task.ID]
+	for len(waiting) > 0 && s.queuedRuns[task.ID] < waiting[0].task.MaxConcurrent {
+		ready = append(ready, waiting[0])
+		waiting[0] = nil
+		waiting = waiting[1:]
+		s.queuedRuns[task.ID]++
+	}
+	if len(waiting) == 0 {
+		delete(s.pendingRuns, task.ID)
+	} else {
+		s.pendingRuns[task.ID] = waiting
+	}
+	if s.queuedRuns[task.ID] <= 0 {
+		delete(s.queuedRuns, task.ID)
+	}
+	s.mu.Unlock()
+
+	for _, j := range ready {
+		// 调度器正在停止时 worker 池拒绝提交并关闭 done
+		if !s.pool.submit(j.task, j.done) {
+			s.releaseRun(j.task)
+			s.releaseSlot(j.task)
+		}
+	}
+}
+
+// dropPending 调度器停止时丢弃等待并发名额的执行，关闭其 done，返回被丢弃的任务，由调用方释放提交时的登记
+func (s *Scheduler) dropPending() []*model.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var dropped []*model.Task
+	for taskID, waiting := range s.pendingRuns {
+		for _, j := range waiting {
+			if j.done != nil {
+				close(j.done)
+			}
+			dropped = append(dropped, j.task)
+		}
+		delete(s.pendingRuns, taskID)
+	}
+	return dropped
+}
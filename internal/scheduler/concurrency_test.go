@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// newRun 复制任务作为一次新的执行，每次提交使用独立的执行ID
+func newRun(task *model.Task) *model.Task {
+	run := *task
+	run.RunID = ""
+	return &run
+}
+
+// peakConcurrency 按执行日志的开始和结束时间计算任务同时进行的最大执行数
+func peakConcurrency(t *testing.T, s *Scheduler, taskID uint) int {
+	t.Helper()
+	var logs []model.TaskLog
+	if err := s.db.Where("task_id = ?", taskID).Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	peak := 0
+	for _, l := range logs {
+		n := 0
+		for _, other := range logs {
+			if !other.StartTime.After(l.StartTime) && other.EndTime.After(l.StartTime) {
+				n++
+			}
+		}
+		peak = max(peak, n)
+	}
+	return peak
+}
+
+func TestQueuePolicyLimitsConcurrency(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{WorkerCount: 4})
+	task := createTestTask(t, db, &model.Task{Command: "sleep 0.2", MaxConcurrent: 2, ConcurrencyPolicy: model.ConcurrencyPolicyQueue})
+
+	for i := 0; i < 5; i++ {
+		if err := s.Submit(newRun(task)); err != nil {
+			t.Fatalf("第%d次提交: %v", i+1, err)
+		}
+	}
+	waitFor(t, 5*time.Second, "排队的执行全部完成", func() bool { return countLogs(t, db, task.ID) == 5 })
+
+	if peak := peakConcurrency(t, s, task.ID); peak != 2 {
+		t.Fatalf("同时进行的执行数最多为 %d，期望2", peak)
+	}
+	waitFor(t, time.Second, "名额全部释放", func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.queuedRuns) == 0 && len(s.pendingRuns) == 0
+	})
+}
+
+func TestQueuePolicyDoesNotBlockWorkers(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{WorkerCount: 2})
+	slow := createTestTask(t, db, &model.Task{Name: "slow", Command: "sleep 0.5", MaxConcurrent: 1, ConcurrencyPolicy: model.ConcurrencyPolicyQueue})
+	other := createTestTask(t, db, &model.Task{Name: "other", Command: "echo other"})
+
+	// 超出上限的执行等待名额时不占用 worker，其他任务仍可立即执行
+	for i := 0; i < 3; i++ {
+		if err := s.Submit(newRun(slow)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now()
+	if err := s.Submit(other); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 5*time.Second, "其他任务执行完成", func() bool { return countLogs(t, db, other.ID) == 1 })
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("其他任务等待了 %v，worker 被排队的执行占用", elapsed)
+	}
+	waitFor(t, 5*time.Second, "排队的执行全部完成", func() bool { return countLogs(t, db, slow.ID) == 3 })
+}
+
+func TestSkipPolicyRejectsOverLimit(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{WorkerCount: 2})
+	task := createTestTask(t, db, &model.Task{Command: "sleep 0.3", MaxConcurrent: 1, ConcurrencyPolicy: model.ConcurrencyPolicySkip})
+
+	if err := s.Submit(newRun(task)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Submit(newRun(task)); !errors.Is(err, ErrConcurrencyLimit) {
+		t.Fatalf("超出上限的错误为 %v，期望 ErrConcurrencyLimit", err)
+	}
+	waitFor(t, 5*time.Second, "执行完成", func() bool { return countLogs(t, db, task.ID) == 1 })
+	waitFor(t, time.Second, "名额释放", func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.activeRuns[task.ID] == 0
+	})
+	if err := s.Submit(newRun(task)); err != nil {
+		t.Fatalf("执行结束后再次提交: %v", err)
+	}
+}
+
+func TestStopDropsPendingRuns(t *testing.T) {
+	db := newTestDB(t)
+	s := NewScheduler(db, nil, &Config{WorkerCount: 2})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	task := createTestTask(t, db, &model.Task{Command: "sleep 0.3", MaxConcurrent: 1, ConcurrencyPolicy: model.ConcurrencyPolicyQueue})
+	if err := s.Submit(newRun(task)); err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := s.SubmitAndWait(context.Background(), newRun(task))
+		result <- err
+	}()
+	waitFor(t, time.Second, "执行进入等待列表", func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.pendingRuns[task.ID]) == 1
+	})
+	s.Stop()
+
+	if err := <-result; !errors.Is(err, ErrSchedulerStopped) {
+		t.Fatalf("等待名额的执行错误为 %v，期望 ErrSchedulerStopped", err)
+	}
+	if n := countLogs(t, db, task.ID); n != 1 {
+		t.Fatalf("执行了 %d 次，期望只执行停止前开始的1次", n)
+	}
+	if len(s.queuedRuns) != 0 || len(s.pendingRuns) != 0 {
+		t.Fatalf("停止后仍有名额登记 queued=%v pending=%d", s.queuedRuns, len(s.pendingRuns))
+	}
+}
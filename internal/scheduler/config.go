@@ -0,0 +1,51 @@
+package scheduler
+
+// Config 调度器配置
+type Config struct {
+	WorkerCount int    `mapstructure:"worker_count"` // 同时执行任务的最大 worker 数量
+	Timezone    string `mapstructure:"timezone"`     // 调度时区（IANA 名称），为空时使用操作系统时区
+
+	ExecutionMode   string `mapstructure:"execution_mode"`    // 执行模式：inline-触发后直接在本实例执行（默认），queue-触发后写入 Redis 执行队列，由各实例的 worker 取出执行（至少执行一次）
+	QueueConsumerID string `mapstructure:"queue_consumer_id"` // 队列模式下本实例的消费者ID，用于重启后恢复未执行完的执行，需各实例唯一且重启后不变，默认主机名
+
+	EnableSecondsField bool `mapstructure:"enable_seconds_field"` // cron 表达式是否包含秒字段：开启时为 6 位表达式，关闭时为标准 5 位表达式
+
+	LogRetentionDays int `mapstructure:"log_retention_days"` // 任务日志保留天数，0表示不按时间清理
+	MaxLogsPerTask   int `mapstructure:"max_logs_per_task"`  // 每个任务最多保留的日志条数，0表示不限制
+	CleanupInterval  int `mapstructure:"cleanup_interval"`   // 日志清理间隔（秒），默认1小时
+
+	MaxManualRuns int `mapstructure:"max_manual_runs"` // 每个任务同时排队或执行的手动执行数上限，默认1，负数表示不限制
+
+	ExportTaskEnv bool `mapstructure:"export_task_env"` // 是否以 HAPPX1_TASK_ID 等环境变量向 shell 命令暴露任务信息
+	QuietSuccess  bool `mapstructure:"quiet_success"`   // 所有任务执行成功时都不保存输出，失败时仍保存完整输出
+
+	BreakerThreshold   int `mapstructure:"breaker_threshold"`    // 连续失败多少次后熔断（暂停定时触发），0表示不熔断
+	BreakerCooldown    int `mapstructure:"breaker_cooldown"`     // 首次熔断的暂停时长（秒），之后仍失败时每次熔断翻倍，默认60秒
+	BreakerMaxCooldown int `mapstructure:"breaker_max_cooldown"` // 熔断暂停时长的上限（秒），默认1小时
+
+	SuccessRateWindow   int `mapstructure:"success_rate_window"`   // 按最近多少次执行计算成功率告警（success_rate_threshold）的成功率，默认20
+	SuccessRateInterval int `mapstructure:"success_rate_interval"` // 检查成功率的间隔（秒），默认5分钟
+
+	OutputEncoding string `mapstructure:"output_encoding"` // 输出不是有效的 UTF-8（如二进制数据）时的处理方式：base64-编码后保存并在日志中标记（默认），replace-将无效字节替换为 U+FFFD
+
+	CallbackFlushInterval   int `mapstructure:"callback_flush_interval"`    // 缓存回调的补发间隔（秒），默认30秒
+	CallbackBufferMaxLength int `mapstructure:"callback_buffer_max_length"` // Redis 中最多缓存的回调数，默认10000
+
+	SQLConnections     map[string]string `mapstructure:"sql_connections"`      // sql 类型任务可用的数据库连接，连接名到 MySQL DSN 的映射
+	SQLAllowedPrefixes []string          `mapstructure:"sql_allowed_prefixes"` // sql 类型任务允许的语句前缀（不区分大小写），为空时不允许执行任何语句
+
+	RedisAllowedCommands []string `mapstructure:"redis_allowed_commands"` // redis 类型任务允许的命令（不区分大小写），为空时不允许执行任何命令；参数不能引用调度器使用的 happx1: 前缀的键
+
+	HTTPBlockedCIDRs []string `mapstructure:"http_blocked_cidrs"` // http、grpc 类型任务、前置检查和回调禁止访问的网段，为空时使用默认的回环、私有和链路本地网段
+	HTTPUserAgent    string   `mapstructure:"http_user_agent"`    // http 类型任务请求默认的 User-Agent，为空时为 happx1-scheduler/<版本>，任务 Headers 中设置时以任务为准
+
+	HTTPMaxResponseBytes int `mapstructure:"http_max_response_bytes"` // http 类型任务日志中保存的响应体字节数上限，超出部分截断（expect_body_regex 仍按完整响应体判断），0表示使用默认值64KB
+
+	ShellAllowlist    []string `mapstructure:"shell_allowlist"`     // shell 类型任务允许的命令（正则表达式，需匹配整条命令，前缀匹配可写为 prefix.*），命令不能包含 ; | & 等 shell 元字符，为空时不限制
+	DisableShellTasks bool     `mapstructure:"disable_shell_tasks"` // 禁用 shell 类型任务，创建和执行时都会拒绝
+	CgroupParent      string   `mapstructure:"cgroup_parent"`       // 限制 shell 任务资源使用的 cgroup v2 目录，默认 /sys/fs/cgroup/happx1
+
+	JitterSeed int64 `mapstructure:"jitter_seed"` // 任务随机延迟（max_jitter）的种子，非0时同一任务在同一分钟内的延迟固定，便于复现；0表示完全随机
+}
+
+const defaultWorkerCount = 10
@@ -0,0 +1,22 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// executionContext 返回一次执行的 context：截止时间为 now+Timeout 与任务的 Deadline 中较早者
+func executionContext(task *model.Task) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(time.Duration(task.Timeout) * time.Second)
+	if task.Deadline != nil && task.Deadline.Before(deadline) {
+		deadline = *task.Deadline
+	}
+	return context.WithDeadline(context.Background(), deadline)
+}
+
+// deadlinePassed 任务设置了截止时间且已经过了该时间
+func deadlinePassed(task *model.Task) bool {
+	return task.Deadline != nil && !time.Now().Before(*task.Deadline)
+}
@@ -0,0 +1,37 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestDeadlineCancelsBeforeTimeout(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	deadline := time.Now().Add(time.Second)
+	retries := 2
+	task := createTestTask(t, db, &model.Task{Command: "sleep 10", Timeout: 30, Deadline: &deadline, RetryTimes: &retries})
+	task.RetryDelay = 0
+
+	taskLog := s.ExecuteTask(task)
+	if taskLog.Status != 0 {
+		t.Fatal("超过截止时间的执行应失败")
+	}
+	// 到截止时间时终止（之后最多等待 shellWaitDelay 让子进程释放输出管道），过了截止时间不再重试
+	if taskLog.EndTime.Sub(deadline) > shellWaitDelay+time.Second {
+		t.Fatalf("执行在 %v 结束，期望在截止时间 %v 终止", taskLog.EndTime, deadline)
+	}
+	if n := countLogs(t, db, task.ID); n != 1 || taskLog.RetryCount != 0 {
+		t.Fatalf("保存了 %d 条日志、重试 %d 次，期望过了截止时间不重试", n, taskLog.RetryCount)
+	}
+
+	// 截止时间晚于超时时间时按超时终止
+	later := time.Now().Add(time.Hour)
+	ctx, cancel := executionContext(&model.Task{Timeout: 5, Deadline: &later})
+	defer cancel()
+	if d, _ := ctx.Deadline(); time.Until(d) > 5*time.Second {
+		t.Fatalf("context 截止时间为 %v，期望为5秒后", d)
+	}
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"happx1/internal/model"
+)
+
+// ErrDuplicateRun 相同参数的执行已在进行中
+var ErrDuplicateRun = errors.New("相同参数的任务执行正在进行中")
+
+// ErrTooManyManualRuns 任务正在排队或执行的手动执行数已达上限
+var ErrTooManyManualRuns = errors.New("该任务正在进行的手动执行数已达上限")
+
+// defaultMaxManualRuns 每个任务默认同时进行的手动执行数
+const defaultMaxManualRuns = 1
+
+// paramsHash 计算执行参数的哈希，与参数顺序无关
+func paramsHash(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%q=%q;", k, params[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// runKey 去重键：任务ID + 参数哈希
+func runKey(task *model.Task) string {
+	return fmt.Sprintf("%d:%s", task.ID, paramsHash(task.RunParams))
+}
+
+// acquireRun 登记执行：开启参数去重的任务相同参数的执行已在排队或运行时返回 ErrDuplicateRun，
+// 手动执行数已达 MaxManualRuns 时返回 ErrTooManyManualRuns，skip 策略下执行数已达 MaxConcurrent 时返回 ErrConcurrencyLimit
+func (s *Scheduler) acquireRun(task *model.Task) error {
+	if !task.DedupeParams && !task.Manual && !skipsOverLimit(task) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if skipsOverLimit(task) && s.activeRuns[task.ID] >= task.MaxConcurrent {
+		return ErrConcurrencyLimit
+	}
+	if task.Manual {
+		limit := s.config.MaxManualRuns
+		if limit == 0 {
+			limit = defaultMaxManualRuns
+		}
+		if limit > 0 && s.manualRuns[task.ID] >= limit {
+			return ErrTooManyManualRuns
+		}
+	}
+	if task.DedupeParams {
+		key := runKey(task)
+		if s.inflight[key] {
+			return ErrDuplicateRun
+		}
+		s.inflight[key] = true
+	}
+	if task.Manual {
+		s.manualRuns[task.ID]++
+	}
+	if skipsOverLimit(task) {
+		s.activeRuns[task.ID]++
+	}
+	return nil
+}
+
+// releaseRun 执行结束后释放登记
+func (s *Scheduler) releaseRun(task *model.Task) {
+	if !task.DedupeParams && !task.Manual && !skipsOverLimit(task) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if task.DedupeParams {
+		delete(s.inflight, runKey(task))
+	}
+	if task.Manual {
+		if s.manualRuns[task.ID]--; s.manualRuns[task.ID] <= 0 {
+			delete(s.manualRuns, task.ID)
+		}
+	}
+	if skipsOverLimit(task) {
+		if s.activeRuns[task.ID]--; s.activeRuns[task.ID] <= 0 {
+			delete(s.activeRuns, task.ID)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestParamsHash(t *testing.T) {
+	a := paramsHash(map[string]string{"region": "eu", "date": "2024-03-10"})
+	b := paramsHash(map[string]string{"date": "2024-03-10", "region": "eu"})
+	if a != b {
+		t.Fatal("参数哈希应与参数顺序无关")
+	}
+	for _, other := range []map[string]string{
+		{"region": "us", "date": "2024-03-10"},
+		{"region": "eu"},
+		{"region": "eu;date=2024-03-10"},
+		nil,
+	} {
+		if paramsHash(other) == a {
+			t.Errorf("不同参数 %v 的哈希相同", other)
+		}
+	}
+}
+
+func TestDedupeParamsRuns(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{WorkerCount: 4})
+	task := createTestTask(t, db, &model.Task{Command: "sleep 0.3", DedupeParams: true})
+	withParams := func(params map[string]string) *model.Task {
+		run := newRun(task)
+		run.RunParams = params
+		return run
+	}
+
+	if err := s.Submit(withParams(map[string]string{"region": "eu", "date": "d1"})); err != nil {
+		t.Fatal(err)
+	}
+	// 相同参数的执行正在进行时被拒绝
+	if err := s.Submit(withParams(map[string]string{"date": "d1", "region": "eu"})); !errors.Is(err, ErrDuplicateRun) {
+		t.Fatalf("相同参数的执行错误为 %v，期望 ErrDuplicateRun", err)
+	}
+	// 不同参数的执行同时进行
+	if err := s.Submit(withParams(map[string]string{"region": "us", "date": "d1"})); err != nil {
+		t.Fatalf("不同参数的执行被拒绝: %v", err)
+	}
+	waitFor(t, 5*time.Second, "两次执行完成", func() bool { return countLogs(t, db, task.ID) == 2 })
+	if peak := peakConcurrency(t, s, task.ID); peak != 2 {
+		t.Fatalf("不同参数的执行同时进行的数量为 %d，期望2", peak)
+	}
+
+	// 执行结束后释放，相同参数可以再次执行
+	waitFor(t, time.Second, "去重登记释放", func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.inflight) == 0
+	})
+	if err := s.Submit(withParams(map[string]string{"region": "eu", "date": "d1"})); err != nil {
+		t.Fatalf("执行结束后相同参数的执行被拒绝: %v", err)
+	}
+}
+
+func TestDedupeDisabledAllowsIdenticalParams(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{WorkerCount: 2})
+	task := createTestTask(t, db, &model.Task{Command: "sleep 0.2"})
+
+	for i := 0; i < 2; i++ {
+		run := newRun(task)
+		run.RunParams = map[string]string{"region": "eu"}
+		if err := s.Submit(run); err != nil {
+			t.Fatalf("未开启参数去重时第%d次执行被拒绝: %v", i+1, err)
+		}
+	}
+	waitFor(t, 5*time.Second, "两次执行完成", func() bool { return countLogs(t, db, task.ID) == 2 })
+}
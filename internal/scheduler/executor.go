@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// shellWaitDelay 超时或心跳超时终止命令后等待输出管道关闭的时间，避免后台子进程持有管道导致一直等待
+const shellWaitDelay = time.Second
+
+// execute 解析密钥占位符、替换执行上下文变量后执行一次任务，返回输出和提取的结果值，输出、结果值和错误中的密钥值会被脱敏，attempt 为第几次尝试（从1开始）
+func (s *Scheduler) execute(task *model.Task, attempt int) ([]byte, string, error) {
+	resolved, resolver, secretEnv, err := s.resolveSecrets(task)
+	if err != nil {
+		return nil, "", err
+	}
+	// 先解析密钥，变量值（如上一次的输出）中的占位符不会被当作密钥解析
+	resolved = s.resolveVariables(resolved)
+	output, value, err := s.executeByType(resolved, attempt, secretEnv)
+	return []byte(resolver.Redact(string(output))), resolver.Redact(value), redactError(resolver, err)
+}
+
+// executeByType 按执行类型执行一次任务，只有 http 类型会按 OutputJSONPath 提取结果值，secretEnv 为 shell 命令中密钥对应的环境变量
+func (s *Scheduler) executeByType(task *model.Task, attempt int, secretEnv []string) (output []byte, value string, err error) {
+	switch task.ExecType {
+	case model.ExecTypeGRPC:
+		output, err = s.executeGRPC(task)
+	case model.ExecTypeSQL:
+		output, err = s.executeSQL(task)
+	case model.ExecTypeRedis:
+		output, err = s.executeRedis(task)
+	case model.ExecTypeMQ:
+		output, err = s.executeMQ(task)
+	case model.ExecTypeHTTP:
+		output, value, err = s.executeHTTP(task)
+	default:
+		output, err = s.executeShell(task, attempt, secretEnv)
+	}
+	return output, value, err
+}
+
+// describeExecution 描述将要执行的操作，用于演练模式
+func describeExecution(task *model.Task) string {
+	switch task.ExecType {
+	case model.ExecTypeGRPC:
+		return fmt.Sprintf("grpc %s %s %s", task.Target, task.Command, task.Body)
+	case model.ExecTypeSQL:
+		return fmt.Sprintf("sql [%s] %s", task.Target, task.Command)
+	case model.ExecTypeRedis:
+		return fmt.Sprintf("redis %s", task.Command)
+	case model.ExecTypeMQ:
+		return fmt.Sprintf("mq %s %s", task.Command, task.Body)
+	case model.ExecTypeHTTP:
+		return fmt.Sprintf("http %s %s", task.Method, task.Command)
+	default:
+		return fmt.Sprintf("sh -c %q", task.Command)
+	}
+}
+
+// execTypeOf 返回任务的执行类型，未设置时为 shell
+func execTypeOf(task *model.Task) string {
+	if task.ExecType == "" {
+		return model.ExecTypeShell
+	}
+	return task.ExecType
+}
+
+// executeShell 执行 shell 命令，返回合并后的标准输出和错误输出，attempt 为第几次尝试（从1开始），secretEnv 为命令中密钥对应的环境变量
+func (s *Scheduler) executeShell(task *model.Task, attempt int, secretEnv []string) ([]byte, error) {
+	// 配置可能在任务创建后收紧，执行前再次检查
+	if err := s.CheckShell(task.Command); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := executionContext(task)
+	defer cancel()
+	ctx, stall := context.WithCancelCause(ctx)
+	defer stall(nil)
+
+	command, vars := s.shellVariables(task)
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = s.taskEnv(task, attempt, append(vars, secretEnv...))
+	cmd.WaitDelay = shellWaitDelay
+	release, err := s.applyResourceLimits(cmd, task)
+	if err != nil {
+		return nil, err
+	}
+	output, err := runWithHeartbeat(cmd, task, stall)
+	if limitErr := release(); limitErr != nil {
+		return output, limitErr
+	}
+	return output, err
+}
+
+// exitCode 返回命令的退出码，成功时为0，不是因命令退出而失败时为-1
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// taskEnv 构造命令的环境变量：任务元数据、执行参数和命令使用的执行上下文变量、密钥，都没有时返回nil以继承当前进程环境
+func (s *Scheduler) taskEnv(task *model.Task, attempt int, vars []string) []string {
+	if !s.config.ExportTaskEnv && len(task.RunParams) == 0 && len(vars) == 0 {
+		return nil
+	}
+
+	env := os.Environ()
+	if s.config.ExportTaskEnv {
+		env = append(env,
+			"HAPPX1_TASK_ID="+strconv.FormatUint(uint64(task.ID), 10),
+			"HAPPX1_TASK_NAME="+task.Name,
+			"HAPPX1_ATTEMPT="+strconv.Itoa(attempt),
+			"HAPPX1_RUN_ID="+task.RunID,
+		)
+	}
+	for k, v := range task.RunParams {
+		env = append(env, "HAPPX1_PARAM_"+strings.ToUpper(k)+"="+v)
+	}
+	return append(env, vars...)
+}
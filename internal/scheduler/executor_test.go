@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestShellTaskEnv(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{ExportTaskEnv: true})
+	task := createTestTask(t, db, &model.Task{Name: "self-identify", Command: `echo "$HAPPX1_TASK_ID|$HAPPX1_TASK_NAME|$HAPPX1_ATTEMPT|$HAPPX1_RUN_ID"`})
+
+	taskLog := s.ExecuteTask(task)
+	want := fmt.Sprintf("%d|self-identify|1|%s", task.ID, taskLog.RunID)
+	if got := strings.TrimSpace(taskLog.Output); got != want {
+		t.Fatalf("脚本读取到的任务信息为 %q，期望 %q", got, want)
+	}
+
+	// 每次尝试的序号不同
+	retries := 1
+	retried := createTestTask(t, db, &model.Task{Name: "attempts", Command: `echo "attempt $HAPPX1_ATTEMPT"; exit 1`, RetryTimes: &retries})
+	retried.RetryDelay = 0
+	if taskLog := s.ExecuteTask(retried); strings.TrimSpace(taskLog.Output) != "attempt 2" {
+		t.Fatalf("第2次尝试的输出为 %q", taskLog.Output)
+	}
+}
+
+func TestShellTaskEnvDisabled(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	task := createTestTask(t, db, &model.Task{Command: `echo "[$HAPPX1_TASK_ID]"`})
+
+	if taskLog := s.ExecuteTask(task); strings.TrimSpace(taskLog.Output) != "[]" {
+		t.Fatalf("未开启时脚本读取到任务ID %q", taskLog.Output)
+	}
+
+	// 执行参数不受开关影响
+	run := newRun(task)
+	run.Command = `echo "$HAPPX1_PARAM_REGION"`
+	run.RunParams = map[string]string{"region": "eu"}
+	if taskLog := s.ExecuteTask(run); strings.TrimSpace(taskLog.Output) != "eu" {
+		t.Fatalf("执行参数的输出为 %q，期望 eu", taskLog.Output)
+	}
+}
+
+func TestExitCodeRecorded(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	cases := []struct {
+		name    string
+		command string
+		want    int
+	}{
+		{"成功", "true", 0},
+		{"退出码3", "exit 3", 3},
+		{"命令不存在", "no-such-command-happx1", 127},
+	}
+	for _, c := range cases {
+		task := createTestTask(t, db, &model.Task{Name: c.name, Command: c.command})
+		s.ExecuteTask(task)
+		var saved model.TaskLog
+		if err := db.Where("task_id = ?", task.ID).First(&saved).Error; err != nil {
+			t.Fatal(err)
+		}
+		if saved.ExitCode != c.want {
+			t.Errorf("%s: 保存的退出码为 %d，期望 %d", c.name, saved.ExitCode, c.want)
+		}
+	}
+
+	// 不是进程退出导致的失败记录为 -1
+	if code := exitCode(errors.New("连接失败")); code != -1 {
+		t.Errorf("非进程退出错误的退出码为 %d，期望 -1", code)
+	}
+}
+
+func TestHeartbeatTimeout(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	cases := []struct {
+		name    string
+		command string
+		pattern string
+		stalled bool
+	}{
+		{"停止输出", "echo progress; sleep 0.3; echo progress; sleep 30", "", true},
+		{"只有不匹配的输出", "for i in 1 2 3 4 5 6 7 8; do echo noise; sleep 0.3; done; sleep 30", "^progress", true},
+		{"持续输出心跳", "for i in 1 2 3 4 5 6; do echo progress $i; sleep 0.3; done", "^progress", false},
+	}
+	for _, c := range cases {
+		task := createTestTask(t, db, &model.Task{Name: c.name, Command: c.command, Timeout: 20, HeartbeatTimeout: 1, HeartbeatPattern: c.pattern})
+		start := time.Now()
+		taskLog := s.ExecuteTask(task)
+		elapsed := time.Since(start)
+
+		if !c.stalled {
+			if taskLog.Status != 1 {
+				t.Errorf("%s: 执行失败 %s", c.name, taskLog.Error)
+			}
+			continue
+		}
+		if taskLog.Status != 0 || !strings.Contains(taskLog.Error, "stalled") {
+			t.Errorf("%s: status=%d error=%q，期望判定为卡住", c.name, taskLog.Status, taskLog.Error)
+		}
+		if elapsed > 5*time.Second {
+			t.Errorf("%s: 执行了 %v，期望在心跳超时后提前终止而不是等到超时", c.name, elapsed)
+		}
+		if !strings.Contains(taskLog.Output, "progress") && !strings.Contains(taskLog.Output, "noise") {
+			t.Errorf("%s: 终止前的输出 %q 丢失", c.name, taskLog.Output)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"happx1/internal/database"
+	"happx1/internal/model"
+)
+
+// checkFailureAlert 按执行的最终结果发送失败告警或恢复通知，未配置告警通知或演练执行时不处理
+// 失败告警的发送状态记录在任务统计中：已告警时连续失败不再告警，之后首次成功时发送恢复通知并清空状态
+func (s *Scheduler) checkFailureAlert(task *model.Task, taskLog *model.TaskLog) {
+	if s.notifier == nil || taskLog.DryRun {
+		return
+	}
+
+	if taskLog.Status == 1 {
+		// 关闭失败告警后仍为之前发出的告警发送恢复通知
+		claimed, err := s.claimFailureAlert(task.ID, nil)
+		if err != nil {
+			slog.Error("更新失败告警状态失败", "task_id", task.ID, "task_name", task.Name, "run_id", taskLog.RunID, "error", err)
+			return
+		}
+		if !claimed {
+			return
+		}
+		title := fmt.Sprintf("[已恢复] %s", task.Name)
+		content := fmt.Sprintf("任务 %s（ID %d）已恢复，执行 %s 成功", task.Name, task.ID, taskLog.RunID)
+		slog.Info("任务已恢复", "task_id", task.ID, "task_name", task.Name, "run_id", taskLog.RunID)
+		if err := s.notifier.Notify(context.Background(), title, content); err != nil {
+			slog.Error("发送恢复通知失败", "task_id", task.ID, "task_name", task.Name, "run_id", taskLog.RunID, "error", err)
+		}
+		return
+	}
+
+	if !task.AlertOnFailure {
+		return
+	}
+	now := time.Now()
+	claimed, err := s.claimFailureAlert(task.ID, &now)
+	if err != nil {
+		slog.Error("更新失败告警状态失败", "task_id", task.ID, "task_name", task.Name, "run_id", taskLog.RunID, "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	title := fmt.Sprintf("[执行失败] %s", task.Name)
+	content := fmt.Sprintf("任务 %s（ID %d）执行 %s 失败，共尝试 %d 次: %s", task.Name, task.ID, taskLog.RunID, taskLog.Attempt, taskLog.Error)
+	if err := s.notifier.Notify(context.Background(), title, content); err != nil {
+		slog.Error("发送失败告警失败", "task_id", task.ID, "task_name", task.Name, "run_id", taskLog.RunID, "error", err)
+		// 告警没有发出，清空状态，下次失败时重新告警
+		if _, err := s.claimFailureAlert(task.ID, nil); err != nil {
+			slog.Error("更新失败告警状态失败", "task_id", task.ID, "task_name", task.Name, "run_id", taskLog.RunID, "error", err)
+		}
+	}
+}
+
+// claimFailureAlert 更新任务统计中的失败告警状态：at 不为 nil 时从未告警改为已告警，为 nil 时从已告警改为未告警
+// 返回状态是否由本次调用改变，同一任务的并发执行只有一个会发送告警或恢复通知
+func (s *Scheduler) claimFailureAlert(taskID uint, at *time.Time) (bool, error) {
+	var claimed bool
+	err := database.WithDeadlockRetry(func() error {
+		query := s.db.Model(&model.TaskStats{}).Where("task_id = ?", taskID)
+		if at != nil {
+			query = query.Where("failure_alerted_at IS NULL")
+		} else {
+			query = query.Where("failure_alerted_at IS NOT NULL")
+		}
+		result := query.Update("failure_alerted_at", at)
+		claimed = result.RowsAffected > 0
+		return result.Error
+	})
+	return claimed, err
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"happx1/internal/model"
+)
+
+func TestFailureAlertAndRecovery(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	notifier := &recordingNotifier{}
+	s.UseNotifier(notifier)
+	task := createTestTask(t, db, &model.Task{Name: "flaky", Command: "true", AlertOnFailure: true})
+
+	// run 按给定命令执行一次任务
+	run := func(command string) {
+		next := newRun(task)
+		next.Command = command
+		s.ExecuteTask(next)
+	}
+	// titles 返回已发送的通知标题
+	titles := func() []string {
+		notifier.mu.Lock()
+		defer notifier.mu.Unlock()
+		return append([]string(nil), notifier.titles...)
+	}
+
+	// 从未失败时成功不发送恢复通知
+	run("true")
+	// 连续失败只告警一次，之后首次成功发送一次恢复通知
+	run("false")
+	run("false")
+	run("true")
+	run("true")
+	got := titles()
+	if len(got) != 2 || !strings.HasPrefix(got[0], "[执行失败] flaky") || !strings.HasPrefix(got[1], "[已恢复] flaky") {
+		t.Fatalf("发送的通知为 %q，期望一次失败告警和一次恢复通知", got)
+	}
+
+	// 恢复后再次失败时重新告警
+	run("false")
+	if got := titles(); len(got) != 3 || !strings.HasPrefix(got[2], "[执行失败]") {
+		t.Fatalf("恢复后再次失败发送的通知为 %q", got)
+	}
+
+	// 未开启失败告警的任务不发送告警，也不发送恢复通知
+	quiet := createTestTask(t, db, &model.Task{Name: "quiet", Command: "false"})
+	s.ExecuteTask(quiet)
+	recovered := newRun(quiet)
+	recovered.Command = "true"
+	s.ExecuteTask(recovered)
+	if n := notifier.count(); n != 3 {
+		t.Fatalf("未开启失败告警的任务发送了 %d 条通知", n-3)
+	}
+}
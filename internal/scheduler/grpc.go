@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"happx1/internal/model"
+)
+
+// ParseGRPCMethod 解析 gRPC 方法名，支持 pkg.Service/Method 和 /pkg.Service/Method
+func ParseGRPCMethod(name string) (service, method string, err error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	idx := strings.LastIndex(name, "/")
+	if idx <= 0 || idx == len(name)-1 {
+		return "", "", fmt.Errorf("无效的 gRPC 方法名，应为 pkg.Service/Method: %s", name)
+	}
+	return name[:idx], name[idx+1:], nil
+}
+
+// executeGRPC 通过服务端反射解析方法描述，以 Body 中的 JSON 作为请求调用 Target 上的方法，返回 JSON 格式的响应
+func (s *Scheduler) executeGRPC(task *model.Task) ([]byte, error) {
+	ctx, cancel := executionContext(task)
+	defer cancel()
+
+	service, method, err := ParseGRPCMethod(task.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	// 与 http 类型任务一样，每次建立连接前检查目标地址，禁止访问的网段不能连接
+	dialer := &net.Dialer{Control: s.httpDialControl}
+	conn, err := grpc.DialContext(ctx, task.Target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", addr)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("连接 gRPC 服务失败: %v", err)
+	}
+	defer conn.Close()
+
+	desc, err := resolveGRPCMethod(ctx, conn, service, method)
+	if err != nil {
+		return nil, err
+	}
+
+	req := dynamicpb.NewMessage(desc.Input())
+	if strings.TrimSpace(task.Body) != "" {
+		if err := protojson.Unmarshal([]byte(task.Body), req); err != nil {
+			return nil, fmt.Errorf("解析请求消息失败: %v", err)
+		}
+	}
+	resp := dynamicpb.NewMessage(desc.Output())
+	if err := conn.Invoke(ctx, "/"+service+"/"+method, req, resp); err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(resp)
+}
+
+// resolveGRPCMethod 通过服务端反射获取方法描述
+func resolveGRPCMethod(ctx context.Context, conn *grpc.ClientConn, service, method string) (protoreflect.MethodDescriptor, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("调用 gRPC 反射服务失败: %v", err)
+	}
+	defer stream.CloseSend()
+
+	r := &reflectionResolver{stream: stream, protos: make(map[string]*descriptorpb.FileDescriptorProto)}
+	if err := r.request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	}); err != nil {
+		return nil, err
+	}
+
+	files := new(protoregistry.Files)
+	for name := range r.protos {
+		if err := r.register(files, name); err != nil {
+			return nil, err
+		}
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("gRPC 服务不存在: %s", service)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s 不是 gRPC 服务", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("gRPC 方法不存在: %s/%s", service, method)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("不支持流式 gRPC 方法: %s/%s", service, method)
+	}
+	return md, nil
+}
+
+// reflectionResolver 通过反射流获取文件描述及其依赖
+type reflectionResolver struct {
+	stream rpb.ServerReflection_ServerReflectionInfoClient
+	protos map[string]*descriptorpb.FileDescriptorProto
+}
+
+// request 发送反射请求并保存返回的文件描述
+func (r *reflectionResolver) request(req *rpb.ServerReflectionRequest) error {
+	if err := r.stream.Send(req); err != nil {
+		return fmt.Errorf("发送反射请求失败: %v", err)
+	}
+	resp, err := r.stream.Recv()
+	if err != nil {
+		return fmt.Errorf("接收反射响应失败: %v", err)
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return fmt.Errorf("反射请求失败: %s", errResp.GetErrorMessage())
+	}
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := new(descriptorpb.FileDescriptorProto)
+		if err := proto.Unmarshal(raw, fd); err != nil {
+			return fmt.Errorf("解析文件描述失败: %v", err)
+		}
+		r.protos[fd.GetName()] = fd
+	}
+	return nil
+}
+
+// register 按依赖顺序注册文件描述，缺少的依赖通过反射补充获取
+func (r *reflectionResolver) register(files *protoregistry.Files, name string) error {
+	if _, err := files.FindFileByPath(name); err == nil {
+		return nil
+	}
+
+	fd, ok := r.protos[name]
+	if !ok {
+		if err := r.request(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+		}); err != nil {
+			return err
+		}
+		if fd, ok = r.protos[name]; !ok {
+			return fmt.Errorf("反射服务未返回文件描述: %s", name)
+		}
+	}
+	for _, dep := range fd.GetDependency() {
+		if err := r.register(files, dep); err != nil {
+			return err
+		}
+	}
+
+	file, err := protodesc.NewFile(fd, files)
+	if err != nil {
+		return fmt.Errorf("构建文件描述失败 [%s]: %v", name, err)
+	}
+	return files.RegisterFile(file)
+}
@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"happx1/internal/model"
+)
+
+// startGRPCServer 启动开启了反射的进程内 gRPC 服务，提供健康检查服务，返回监听地址
+func startGRPCServer(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus("jobs", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(srv, healthSrv)
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestExecuteGRPC(t *testing.T) {
+	target := startGRPCServer(t)
+	s := NewScheduler(nil, nil, &Config{})
+
+	cases := []struct {
+		name    string
+		command string
+		body    string
+		want    string
+		wantErr string
+	}{
+		{"默认请求", "grpc.health.v1.Health/Check", "", `"SERVING"`, ""},
+		{"JSON 请求", "/grpc.health.v1.Health/Check", `{"service":"jobs"}`, `"NOT_SERVING"`, ""},
+		{"方法不存在", "grpc.health.v1.Health/Missing", "", "", "gRPC 方法不存在"},
+		{"服务不存在", "pkg.Missing/Check", "", "", "反射请求失败"},
+		{"流式方法", "grpc.health.v1.Health/Watch", "", "", "不支持流式 gRPC 方法"},
+		{"无效的请求", "grpc.health.v1.Health/Check", `{"unknown":1}`, "", "解析请求消息失败"},
+	}
+	for _, c := range cases {
+		output, err := s.executeGRPC(&model.Task{ExecType: model.ExecTypeGRPC, Target: target, Command: c.command, Body: c.body, Timeout: 5})
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%s: 错误为 %v，期望包含 %q", c.name, err, c.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !strings.Contains(string(output), c.want) {
+			t.Errorf("%s: 响应 %s 不包含 %s", c.name, output, c.want)
+		}
+	}
+}
+
+func TestParseGRPCMethod(t *testing.T) {
+	for _, name := range []string{"pkg.Service/Method", "/pkg.Service/Method"} {
+		service, method, err := ParseGRPCMethod(name)
+		if err != nil || service != "pkg.Service" || method != "Method" {
+			t.Errorf("ParseGRPCMethod(%q) = %q, %q, %v", name, service, method, err)
+		}
+	}
+	for _, name := range []string{"", "Method", "pkg.Service/", "/Method"} {
+		if _, _, err := ParseGRPCMethod(name); err == nil {
+			t.Errorf("ParseGRPCMethod(%q) 应报错", name)
+		}
+	}
+}
+
+func TestExecuteGRPCBlockedAddress(t *testing.T) {
+	target := startGRPCServer(t)
+	s := newGuardedScheduler(t)
+
+	// 与 http 类型任务使用相同的禁止访问网段，创建时和连接时都检查
+	if err := s.CheckGRPCTarget(target); err == nil || !strings.Contains(err.Error(), "禁止访问的地址") {
+		t.Fatalf("回环地址的校验错误为 %v，期望被拒绝", err)
+	}
+	_, err := s.executeGRPC(&model.Task{ExecType: model.ExecTypeGRPC, Target: target, Command: "grpc.health.v1.Health/Check", Timeout: 5})
+	if err == nil || !strings.Contains(err.Error(), "禁止访问的地址") {
+		t.Fatalf("连接回环地址的错误为 %v，期望连接被拦截", err)
+	}
+
+	allowed := newGuardedScheduler(t, allowLoopback)
+	if err := allowed.CheckGRPCTarget(target); err != nil {
+		t.Fatalf("未禁止的地址校验失败: %v", err)
+	}
+	if err := allowed.CheckGRPCTarget("localhost"); err == nil {
+		t.Fatal("缺少端口的地址应被拒绝")
+	}
+}
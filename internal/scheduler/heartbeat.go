@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// ErrTaskStalled 任务在心跳超时内没有输出心跳行，判定为卡住
+var ErrTaskStalled = errors.New("任务卡住（stalled）")
+
+// maxHeartbeatLine 检查心跳时单行保留的最大字节数，超长的行只检查前面部分
+const maxHeartbeatLine = 64 * 1024
+
+// heartbeatWriter 收集命令输出，每出现一行匹配心跳模式的输出时调用 beat
+type heartbeatWriter struct {
+	mu      sync.Mutex
+	output  bytes.Buffer
+	line    []byte
+	pattern *regexp.Regexp // 为nil时任意一行都算心跳
+	beat    func()
+}
+
+// Write 实现 io.Writer
+func (w *heartbeatWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(p)
+	w.output.Write(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.appendLine(p)
+			break
+		}
+		w.appendLine(p[:i])
+		if w.pattern == nil || w.pattern.Match(w.line) {
+			w.beat()
+		}
+		w.line = w.line[:0]
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// appendLine 追加到当前行，超出 maxHeartbeatLine 的部分丢弃
+func (w *heartbeatWriter) appendLine(p []byte) {
+	if room := maxHeartbeatLine - len(w.line); room < len(p) {
+		p = p[:room]
+	}
+	w.line = append(w.line, p...)
+}
+
+// runWithHeartbeat 执行命令并返回合并后的标准输出和错误输出
+// 任务设置了心跳超时时，超过该时间没有输出心跳行则调用 stall 终止命令，返回 ErrTaskStalled
+func runWithHeartbeat(cmd *exec.Cmd, task *model.Task, stall context.CancelCauseFunc) ([]byte, error) {
+	if task.HeartbeatTimeout <= 0 {
+		return cmd.CombinedOutput()
+	}
+
+	var pattern *regexp.Regexp
+	if task.HeartbeatPattern != "" {
+		var err error
+		if pattern, err = regexp.Compile(task.HeartbeatPattern); err != nil {
+			return nil, fmt.Errorf("无效的心跳模式: %v", err)
+		}
+	}
+
+	timeout := time.Duration(task.HeartbeatTimeout) * time.Second
+	var stalled atomic.Bool
+	timer := time.AfterFunc(timeout, func() {
+		stalled.Store(true)
+		stall(ErrTaskStalled)
+	})
+	defer timer.Stop()
+
+	w := &heartbeatWriter{pattern: pattern, beat: func() { timer.Reset(timeout) }}
+	// 标准输出和错误输出使用同一个 writer 时共用一个管道，输出顺序与 CombinedOutput 相同
+	cmd.Stdout, cmd.Stderr = w, w
+	err := cmd.Run()
+
+	w.mu.Lock()
+	output := w.output.Bytes()
+	w.mu.Unlock()
+	if stalled.Load() {
+		return output, fmt.Errorf("%w：%d 秒内没有心跳输出，已提前终止", ErrTaskStalled, task.HeartbeatTimeout)
+	}
+	return output, err
+}
@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"happx1/internal/model"
+)
+
+// maxHTTPResponse http 类型任务读取的响应体上限，超出部分不读取
+const maxHTTPResponse = 10 << 20
+
+// defaultHTTPMaxResponseBytes 未配置时 http 类型任务日志中保存的响应体上限
+const defaultHTTPMaxResponseBytes = 64 << 10
+
+// defaultMaxRedirects 未设置最大重定向次数时的默认值，与 net/http 一致
+const defaultMaxRedirects = 10
+
+// Version 调度器版本，用于默认的 User-Agent，构建时可通过 -ldflags "-X happx1/internal/scheduler.Version=x.y.z" 设置
+var Version = "1.0"
+
+// requestIDHeader http 类型任务请求中携带执行ID的请求头
+const requestIDHeader = "X-Request-ID"
+
+// userAgent 返回 http 类型任务请求默认的 User-Agent
+func (s *Scheduler) userAgent() string {
+	if s.config.HTTPUserAgent != "" {
+		return s.config.HTTPUserAgent
+	}
+	return "happx1-scheduler/" + Version
+}
+
+// ParseCACert 解析 PEM 格式的 CA 证书
+func ParseCACert(pem string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(pem)) {
+		return nil, fmt.Errorf("无效的 CA 证书")
+	}
+	return pool, nil
+}
+
+// httpTransport 按任务的连接超时和 TLS 配置创建 Transport，每次建立连接前检查目标地址是否禁止访问
+func (s *Scheduler) httpTransport(task *model.Task) (*http.Transport, error) {
+	transport := s.guardedTransport(time.Duration(task.ConnectTimeout) * time.Second)
+	if task.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = time.Duration(task.ResponseHeaderTimeout) * time.Second
+	}
+	if !task.InsecureSkipVerify && task.CACert == "" && task.ClientCert == "" {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if task.InsecureSkipVerify {
+		slog.Warn("任务已关闭 TLS 证书校验", "task_id", task.ID, "task_name", task.Name, "run_id", task.RunID)
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if task.CACert != "" {
+		pool, err := ParseCACert(task.CACert)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if task.ClientCert != "" {
+		cert, err := tls.X509KeyPair([]byte(task.ClientCert), []byte(task.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("无效的客户端证书: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// checkRedirect 按任务配置决定是否跟随重定向，跨主机重定向时移除 Authorization 请求头
+func checkRedirect(task *model.Task) func(req *http.Request, via []*http.Request) error {
+	maxRedirects := task.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	return func(req *http.Request, via []*http.Request) error {
+		if !task.ShouldFollowRedirects() {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("重定向次数超过上限 %d", maxRedirects)
+		}
+		if req.URL.Host != via[0].URL.Host {
+			req.Header.Del("Authorization")
+		}
+		return nil
+	}
+}
+
+// executeHTTP 请求 Command 地址，输出状态码和响应体，返回错误状态码时视为失败
+// 设置了 OutputJSONPath 时从成功的响应体中提取结果值，提取失败只记录警告，不影响执行结果
+// Timeout 是整个请求（含重定向和读取响应体）的上限，连接和等待响应头可单独设置更短的超时
+func (s *Scheduler) executeHTTP(task *model.Task) ([]byte, string, error) {
+	ctx, cancel := executionContext(task)
+	defer cancel()
+
+	transport, err := s.httpTransport(task)
+	if err != nil {
+		return nil, "", err
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, CheckRedirect: checkRedirect(task)}
+
+	method := task.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if task.Body != "" {
+		body = strings.NewReader(task.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, task.Command, body)
+	if err != nil {
+		return nil, "", err
+	}
+	// 默认请求头，任务 Headers 中设置了同名请求头时覆盖
+	req.Header.Set("User-Agent", s.userAgent())
+	if task.RunID != "" {
+		req.Header.Set(requestIDHeader, task.RunID)
+	}
+	for k, v := range task.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponse))
+	if err != nil {
+		return nil, "", err
+	}
+	output := []byte(fmt.Sprintf("HTTP %d\n%s", resp.StatusCode, s.truncateResponse(data)))
+	if resp.StatusCode >= http.StatusBadRequest {
+		return output, "", fmt.Errorf("HTTP 请求返回错误状态: %d", resp.StatusCode)
+	}
+	if task.ExpectBodyRegex != "" {
+		pattern, err := regexp.Compile(task.ExpectBodyRegex)
+		if err != nil {
+			return output, "", fmt.Errorf("无效的响应体匹配规则: %v", err)
+		}
+		if !pattern.Match(data) {
+			return output, "", fmt.Errorf("响应体不匹配 %q", task.ExpectBodyRegex)
+		}
+	}
+	return output, s.extractResult(task, data), nil
+}
+
+// extractResult 按任务的 OutputJSONPath 从响应体中提取结果值，未设置或提取失败时返回空
+func (s *Scheduler) extractResult(task *model.Task, data []byte) string {
+	if task.OutputJSONPath == "" {
+		return ""
+	}
+	value, err := EvalJSONPath(data, task.OutputJSONPath)
+	if err != nil {
+		slog.Warn("提取结果值失败", "task_id", task.ID, "task_name", task.Name, "run_id", task.RunID, "path", task.OutputJSONPath, "error", err)
+		return ""
+	}
+	return truncateResultValue(value)
+}
+
+// truncateResponse 截断超过保存上限的响应体，在 UTF-8 字符边界处截断并注明原始大小
+func (s *Scheduler) truncateResponse(data []byte) []byte {
+	limit := s.config.HTTPMaxResponseBytes
+	if limit <= 0 {
+		limit = defaultHTTPMaxResponseBytes
+	}
+	if len(data) <= limit {
+		return data
+	}
+	n := limit
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	truncated := append([]byte(nil), data[:n]...)
+	return append(truncated, fmt.Sprintf("\n...[响应体共 %d 字节，已截断]", len(data))...)
+}
@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultHTTPBlockedCIDRs 未配置时 http 类型任务禁止访问的网段：回环、私有、链路本地（含云厂商元数据地址）等
+var defaultHTTPBlockedCIDRs = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+}
+
+// httpResolveTimeout 校验请求地址时解析域名的超时时间
+const httpResolveTimeout = 5 * time.Second
+
+// parseCIDRs 解析网段列表
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("无效的网段 %s: %v", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// loadHTTPBlocked 加载 http 类型任务禁止访问的网段，未配置时使用默认网段
+func (s *Scheduler) loadHTTPBlocked() error {
+	cidrs := s.config.HTTPBlockedCIDRs
+	if len(cidrs) == 0 {
+		cidrs = defaultHTTPBlockedCIDRs
+	}
+	networks, err := parseCIDRs(cidrs)
+	if err != nil {
+		return fmt.Errorf("加载 http_blocked_cidrs 失败: %v", err)
+	}
+	s.httpBlocked = networks
+	return nil
+}
+
+// httpIPBlocked 判断地址是否位于禁止访问的网段
+func (s *Scheduler) httpIPBlocked(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range s.httpBlocked {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckHTTPURL 校验 http 类型任务的请求地址：只允许 http/https，且解析后的地址不能位于禁止访问的网段
+func (s *Scheduler) CheckHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("无效的请求地址，只支持 http/https: %s", raw)
+	}
+
+	return s.checkHost(u.Hostname())
+}
+
+// CheckGRPCTarget 校验 grpc 类型任务的服务地址：应为 host:port，且解析后的地址不能位于禁止访问的网段
+func (s *Scheduler) CheckGRPCTarget(target string) error {
+	host, _, err := net.SplitHostPort(target)
+	if err != nil || host == "" {
+		return fmt.Errorf("无效的 gRPC 服务地址，应为 host:port: %s", target)
+	}
+	return s.checkHost(host)
+}
+
+// checkHost 校验主机名或 IP 解析后的地址不位于禁止访问的网段
+func (s *Scheduler) checkHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if s.httpIPBlocked(ip) {
+			return fmt.Errorf("禁止访问的地址: %s", host)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpResolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("解析请求地址失败: %v", err)
+	}
+	for _, addr := range addrs {
+		if s.httpIPBlocked(addr.IP) {
+			return fmt.Errorf("禁止访问的地址: %s 解析为 %s", host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// httpDialControl 建立连接前检查实际连接的地址，防止域名在校验后被解析到内网地址（DNS rebinding）或重定向到内网地址
+func (s *Scheduler) httpDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("无效的连接地址: %s", address)
+	}
+	if s.httpIPBlocked(ip) {
+		return fmt.Errorf("禁止访问的地址: %s", host)
+	}
+	return nil
+}
+
+// guardedTransport 创建每次建立连接前检查目标地址的 Transport，connectTimeout 为0时建立连接最多等待30秒
+// 不使用 HTTP(S)_PROXY 等环境变量中的代理：经过代理时只能检查代理的地址，禁止访问的网段不再生效
+func (s *Scheduler) guardedTransport(connectTimeout time.Duration) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   s.httpDialControl,
+	}
+	if connectTimeout > 0 {
+		dialer.Timeout = connectTimeout
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
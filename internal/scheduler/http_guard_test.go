@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"happx1/internal/model"
+)
+
+// newGuardedScheduler 创建只加载了禁止访问网段和回调连接的调度器，cidrs 为空时使用默认网段
+func newGuardedScheduler(t *testing.T, cidrs ...string) *Scheduler {
+	t.Helper()
+	s := NewScheduler(nil, nil, &Config{HTTPBlockedCIDRs: cidrs})
+	if err := s.loadHTTPBlocked(); err != nil {
+		t.Fatal(err)
+	}
+	s.callbackTransport = s.guardedTransport(0)
+	return s
+}
+
+func TestCheckHTTPURL(t *testing.T) {
+	s := newGuardedScheduler(t)
+	blocked := []string{
+		"http://169.254.169.254/latest/meta-data/",
+		"http://127.0.0.1:8080/",
+		"http://10.1.2.3/",
+		"http://[::1]/",
+		"ftp://93.184.216.34/",
+		"not a url",
+	}
+	for _, raw := range blocked {
+		if err := s.CheckHTTPURL(raw); err == nil {
+			t.Errorf("%s 应被拒绝", raw)
+		}
+	}
+	if err := s.CheckHTTPURL("https://93.184.216.34/path"); err != nil {
+		t.Errorf("公网地址应被允许: %v", err)
+	}
+}
+
+func TestGuardedTransportIgnoresProxy(t *testing.T) {
+	s := newGuardedScheduler(t)
+	if s.guardedTransport(0).Proxy != nil {
+		t.Fatal("受保护的 Transport 不应使用环境变量中的代理")
+	}
+	transport, err := s.httpTransport(&model.Task{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.Proxy != nil {
+		t.Fatal("http 任务的 Transport 不应使用环境变量中的代理")
+	}
+}
+
+func TestHTTPTaskDialBlocked(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("禁止访问的地址收到了请求")
+	}))
+	defer srv.Close()
+
+	// 创建后才解析到内网的地址（如 DNS rebinding）在连接时被拦截
+	s := newGuardedScheduler(t)
+	_, _, err := s.executeHTTP(&model.Task{ExecType: model.ExecTypeHTTP, Command: srv.URL, Timeout: 5})
+	if err == nil || !strings.Contains(err.Error(), "禁止访问的地址") {
+		t.Fatalf("错误为 %v，期望连接被拦截", err)
+	}
+}
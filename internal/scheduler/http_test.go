@@ -0,0 +1,295 @@
+package scheduler
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"happx1/internal/model"
+)
+
+// certPEM 返回测试服务器证书的 PEM 编码
+func certPEM(srv *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+}
+
+// newTestCert 生成自签名证书，返回 PEM 编码的证书和私钥
+func newTestCert(t *testing.T, name string) (certPEM, keyPEM string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestHTTPTaskTLSVerification(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secure"))
+	}))
+	defer srv.Close()
+	otherCA, _ := newTestCert(t, "other-ca")
+
+	s := newGuardedScheduler(t, allowLoopback)
+	cases := []struct {
+		name     string
+		insecure bool
+		caCert   string
+		wantErr  string
+	}{
+		{"默认校验自签名证书", false, "", "certificate"},
+		{"跳过校验", true, "", ""},
+		{"固定 CA", false, certPEM(srv), ""},
+		{"其他 CA", false, otherCA, "certificate"},
+	}
+	for _, c := range cases {
+		output, _, err := s.executeHTTP(&model.Task{ExecType: model.ExecTypeHTTP, Command: srv.URL, Timeout: 5, InsecureSkipVerify: c.insecure, CACert: c.caCert})
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%s: 错误为 %v，期望证书校验失败", c.name, err)
+			}
+			continue
+		}
+		if err != nil || !strings.Contains(string(output), "secure") {
+			t.Errorf("%s: 输出 %q，错误 %v", c.name, output, err)
+		}
+	}
+
+	if _, err := ParseCACert("not a certificate"); err == nil {
+		t.Error("无效的 CA 证书应报错")
+	}
+}
+
+func TestHTTPTaskClientCertificate(t *testing.T) {
+	clientCert, clientKey := newTestCert(t, "happx1-client")
+	otherCert, otherKey := newTestCert(t, "other-client")
+	pool, err := ParseCACert(clientCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello " + r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	srv.StartTLS()
+	defer srv.Close()
+
+	s := newGuardedScheduler(t, allowLoopback)
+	cases := []struct {
+		name       string
+		cert, key  string
+		wantOutput string
+	}{
+		{"受信任的客户端证书", clientCert, clientKey, "hello happx1-client"},
+		{"未提供客户端证书", "", "", ""},
+		{"不受信任的客户端证书", otherCert, otherKey, ""},
+	}
+	for _, c := range cases {
+		task := &model.Task{ExecType: model.ExecTypeHTTP, Command: srv.URL, Timeout: 5, CACert: certPEM(srv), ClientCert: c.cert, ClientKey: c.key}
+		output, _, err := s.executeHTTP(task)
+		if c.wantOutput == "" {
+			if err == nil {
+				t.Errorf("%s: 期望服务端拒绝握手，输出 %q", c.name, output)
+			}
+			continue
+		}
+		if err != nil || !strings.Contains(string(output), c.wantOutput) {
+			t.Errorf("%s: 输出 %q，错误 %v", c.name, output, err)
+		}
+	}
+
+	// 证书与私钥不匹配时不发送请求
+	if _, _, err := s.executeHTTP(&model.Task{ExecType: model.ExecTypeHTTP, Command: srv.URL, Timeout: 5, ClientCert: clientCert, ClientKey: otherKey}); err == nil || !strings.Contains(err.Error(), "无效的客户端证书") {
+		t.Errorf("不匹配的证书和私钥错误为 %v", err)
+	}
+
+	// 接口返回时私钥脱敏，证书保留
+	data, err := json.Marshal(&model.Task{ClientCert: clientCert, ClientKey: clientKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded model.Task
+	json.Unmarshal(data, &decoded)
+	if decoded.ClientKey != model.RedactedSecret || decoded.ClientCert != clientCert {
+		t.Errorf("序列化后的私钥为 %q，期望脱敏", decoded.ClientKey)
+	}
+}
+
+func TestHTTPTaskRedirects(t *testing.T) {
+	var mux http.ServeMux
+	srv := httptest.NewServer(&mux)
+	defer srv.Close()
+	// /hop/n 重定向到 /hop/n-1，/hop/0 返回最终响应
+	mux.HandleFunc("/hop/", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+		if n == 0 {
+			w.Write([]byte("arrived"))
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/hop/%d", n-1), http.StatusFound)
+	})
+
+	s := newGuardedScheduler(t, allowLoopback)
+	follow := func(b bool) *bool { return &b }
+	cases := []struct {
+		name       string
+		hops       int
+		follow     *bool
+		max        int
+		wantOutput string
+		wantErr    string
+	}{
+		{"默认跟随重定向", 3, nil, 0, "HTTP 200\narrived", ""},
+		{"不跟随重定向", 1, follow(false), 0, "HTTP 302", ""},
+		{"重定向次数在上限内", 2, follow(true), 2, "HTTP 200\narrived", ""},
+		{"重定向次数超过上限", 3, follow(true), 2, "", "重定向次数超过上限 2"},
+	}
+	for _, c := range cases {
+		task := &model.Task{ExecType: model.ExecTypeHTTP, Command: fmt.Sprintf("%s/hop/%d", srv.URL, c.hops), Timeout: 5, FollowRedirects: c.follow, MaxRedirects: c.max}
+		output, _, err := s.executeHTTP(task)
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%s: 错误为 %v，期望包含 %q", c.name, err, c.wantErr)
+			}
+			continue
+		}
+		if err != nil || !strings.HasPrefix(string(output), c.wantOutput) {
+			t.Errorf("%s: 输出 %q，错误 %v，期望 %q", c.name, output, err, c.wantOutput)
+		}
+	}
+}
+
+func TestRedirectStripsAuthorizationAcrossHosts(t *testing.T) {
+	task := &model.Task{}
+	check := checkRedirect(task)
+	newRequest := func(rawURL string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, rawURL, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		return req
+	}
+	via := []*http.Request{newRequest("http://api.example.com/start")}
+
+	sameHost := newRequest("http://api.example.com/next")
+	if err := check(sameHost, via); err != nil || sameHost.Header.Get("Authorization") == "" {
+		t.Errorf("同主机重定向错误 %v，Authorization=%q，期望保留", err, sameHost.Header.Get("Authorization"))
+	}
+	otherHost := newRequest("http://evil.example.net/next")
+	if err := check(otherHost, via); err != nil || otherHost.Header.Get("Authorization") != "" {
+		t.Errorf("跨主机重定向错误 %v，Authorization=%q，期望移除", err, otherHost.Header.Get("Authorization"))
+	}
+}
+
+func TestHTTPTaskResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	// 等待响应头的超时先于任务的整体超时触发
+	s := newGuardedScheduler(t, allowLoopback)
+	start := time.Now()
+	_, _, err := s.executeHTTP(&model.Task{ExecType: model.ExecTypeHTTP, Command: srv.URL, Timeout: 10, ResponseHeaderTimeout: 1})
+	if err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Fatalf("错误为 %v，期望等待响应头超时", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("等待了 %v，期望约1秒后超时", elapsed)
+	}
+}
+
+func TestHTTPTaskResponseTruncatedAfterCheck(t *testing.T) {
+	body := strings.Repeat("é", 500) + "DONE"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s := newGuardedScheduler(t, allowLoopback)
+	s.config.HTTPMaxResponseBytes = 101
+	// 响应体匹配按完整内容判断，标记只在截断部分之后出现
+	output, _, err := s.executeHTTP(&model.Task{ExecType: model.ExecTypeHTTP, Command: srv.URL, Timeout: 5, ExpectBodyRegex: "DONE$"})
+	if err != nil {
+		t.Fatalf("完整响应体应匹配: %v", err)
+	}
+	if strings.Contains(string(output), "DONE") || !strings.Contains(string(output), fmt.Sprintf("响应体共 %d 字节，已截断", len(body))) {
+		t.Fatalf("保存的输出未截断: %q", output)
+	}
+	if !utf8.Valid(output) {
+		t.Fatal("截断位置破坏了 UTF-8 字符")
+	}
+
+	if _, _, err := s.executeHTTP(&model.Task{ExecType: model.ExecTypeHTTP, Command: srv.URL, Timeout: 5, ExpectBodyRegex: "MISSING"}); err == nil {
+		t.Fatal("完整响应体不匹配时应失败")
+	}
+}
+
+func TestHTTPTaskDefaultHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.UserAgent(), r.Header.Get("X-Request-ID"))
+	}))
+	defer srv.Close()
+
+	s := newGuardedScheduler(t, allowLoopback)
+	cases := []struct {
+		name      string
+		userAgent string // 配置的默认 User-Agent
+		runID     string
+		headers   model.Headers
+		want      string
+	}{
+		{"默认请求头", "", "run-1", nil, "happx1-scheduler/" + Version + "|run-1"},
+		{"配置的 User-Agent", "custom-agent/2.0", "run-2", nil, "custom-agent/2.0|run-2"},
+		{"没有执行ID", "", "", nil, "happx1-scheduler/" + Version + "|"},
+		{"任务请求头覆盖默认值", "custom-agent/2.0", "run-3", model.Headers{"User-Agent": "task-agent", "X-Request-ID": "task-request"}, "task-agent|task-request"},
+		{"请求头名称不区分大小写", "", "run-4", model.Headers{"user-agent": "lower-agent", "x-request-id": "lower-request"}, "lower-agent|lower-request"},
+	}
+	for _, c := range cases {
+		s.config.HTTPUserAgent = c.userAgent
+		task := &model.Task{ExecType: model.ExecTypeHTTP, Command: srv.URL, Timeout: 5, RunID: c.runID, Headers: c.headers}
+		output, _, err := s.executeHTTP(task)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got := strings.TrimPrefix(string(output), "HTTP 200\n"); got != c.want {
+			t.Errorf("%s: 服务端收到 %q，期望 %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// jitterDelay 返回任务在 at 触发时的随机延迟，范围为 [0, MaxJitter)
+// 配置了 JitterSeed 时由种子、任务ID和触发时间所在的分钟决定，相同输入得到相同的延迟
+func (s *Scheduler) jitterDelay(task *model.Task, at time.Time) time.Duration {
+	if task.MaxJitter <= 0 {
+		return 0
+	}
+	maxJitter := int64(time.Duration(task.MaxJitter) * time.Second)
+	if s.config.JitterSeed == 0 {
+		return time.Duration(rand.Int63n(maxJitter))
+	}
+
+	var buf [24]byte
+	binary.BigEndian.PutUint64(buf[0:], uint64(s.config.JitterSeed))
+	binary.BigEndian.PutUint64(buf[8:], uint64(task.ID))
+	binary.BigEndian.PutUint64(buf[16:], uint64(at.Unix()/60))
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return time.Duration(h.Sum64() % uint64(maxJitter))
+}
+
+// waitJitter 等待任务本次触发的随机延迟，等待期间调度器停止时返回 false
+func (s *Scheduler) waitJitter(task *model.Task) bool {
+	delay := s.jitterDelay(task, time.Now())
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.stopCh:
+		return false
+	}
+}
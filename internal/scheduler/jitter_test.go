@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestJitterDelay(t *testing.T) {
+	random := NewScheduler(nil, nil, &Config{})
+	seeded := NewScheduler(nil, nil, &Config{JitterSeed: 42})
+	task := &model.Task{MaxJitter: 10}
+	task.ID = 1
+	at := time.Date(2030, 1, 1, 8, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 100; i++ {
+		if d := random.jitterDelay(task, at); d < 0 || d >= 10*time.Second {
+			t.Fatalf("随机延迟 %v 超出 [0, 10s)", d)
+		}
+	}
+
+	// 设置种子时同一任务在同一分钟内的延迟固定
+	first := seeded.jitterDelay(task, at)
+	if first < 0 || first >= 10*time.Second {
+		t.Fatalf("随机延迟 %v 超出 [0, 10s)", first)
+	}
+	if d := seeded.jitterDelay(task, at.Add(30*time.Second)); d != first {
+		t.Fatalf("同一分钟内的延迟 %v 与 %v 不同", d, first)
+	}
+	differs := false
+	for i := 1; i <= 10 && !differs; i++ {
+		differs = seeded.jitterDelay(task, at.Add(time.Duration(i)*time.Minute)) != first
+	}
+	if !differs {
+		t.Fatal("不同分钟的延迟全部相同")
+	}
+
+	if d := seeded.jitterDelay(&model.Task{}, at); d != 0 {
+		t.Fatalf("未设置 max_jitter 时延迟为 %v，期望0", d)
+	}
+}
+
+func TestTriggerWaitsForJitter(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{JitterSeed: 7})
+	task := createTestTask(t, db, &model.Task{Command: "echo jitter", MaxJitter: 2})
+
+	start := time.Now()
+	delay := s.jitterDelay(task, start)
+	s.trigger(task)
+	waitFor(t, 5*time.Second, "延迟后执行完成", func() bool { return countLogs(t, db, task.ID) == 1 })
+
+	var taskLog model.TaskLog
+	if err := db.Where("task_id = ?", task.ID).First(&taskLog).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 触发可能恰好跨过分钟边界，此时延迟按下一分钟计算，只断言不超过上限
+	waited := taskLog.StartTime.Sub(start)
+	if start.Truncate(time.Minute).Equal(time.Now().Truncate(time.Minute)) && waited < delay {
+		t.Fatalf("执行在触发后 %v 开始，早于随机延迟 %v", waited, delay)
+	}
+	if waited >= 2*time.Second+500*time.Millisecond {
+		t.Fatalf("执行在触发后 %v 开始，超过 max_jitter", waited)
+	}
+}
+
+func TestJitteredOnceTaskDoesNotBlockOtherOnceTasks(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	due := time.Now().Add(time.Second).Truncate(time.Second).Add(time.Second)
+	once := func(name string, maxJitter int) *model.Task {
+		task := &model.Task{Name: name, Type: model.TaskTypeOnce, Spec: due.Format(time.RFC3339), Command: "echo " + name, Timeout: 5, Status: 1, MaxJitter: maxJitter}
+		if err := s.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	// 带随机延迟的任务先加入，同时到期时先于另一个任务触发
+	once("jittered", 3600)
+	plain := once("plain", 0)
+
+	// 随机延迟在后台等待，不阻塞定时队列触发同时到期的其他任务
+	waitFor(t, 5*time.Second, "同时到期的任务按时执行", func() bool { return countLogs(t, db, plain.ID) == 1 })
+}
@@ -0,0 +1,129 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxResultValue 执行日志中结果值的最大长度（字节）
+const maxResultValue = 255
+
+// jsonPathStep JSON 路径中的一级：对象字段名或数组下标
+type jsonPathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// CheckJSONPath 校验 JSON 路径
+func CheckJSONPath(path string) error {
+	_, err := parseJSONPath(path)
+	return err
+}
+
+// parseJSONPath 解析 JSON 路径，支持 $ 开头的 .字段、['字段'] 和 [下标] 形式，如 $.data.items[0].count
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("无效的 JSON 路径 %q，应以 $ 开头", path)
+	}
+
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("无效的 JSON 路径 %q，字段名不能为空", path)
+			}
+			steps = append(steps, jsonPathStep{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("无效的 JSON 路径 %q，缺少 ]", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("无效的 JSON 路径 %q，数组下标应为非负整数: %s", path, inner)
+			}
+			steps = append(steps, jsonPathStep{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("无效的 JSON 路径 %q，应使用 .字段 或 [下标]", path)
+		}
+	}
+	return steps, nil
+}
+
+// EvalJSONPath 按 JSON 路径从 JSON 数据中取值，字符串返回原始内容，其他值（数字、布尔、null、对象、数组）返回紧凑的 JSON 文本
+func EvalJSONPath(data []byte, path string) (string, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// 保留数字的原始文本，避免大整数丢失精度
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("响应体不是有效的 JSON: %v", err)
+	}
+
+	for _, step := range steps {
+		if step.isIndex {
+			items, ok := value.([]interface{})
+			if !ok {
+				return "", fmt.Errorf("JSON 路径 %s 处不是数组", path)
+			}
+			if step.index >= len(items) {
+				return "", fmt.Errorf("JSON 路径 %s 的下标 %d 超出数组长度 %d", path, step.index, len(items))
+			}
+			value = items[step.index]
+			continue
+		}
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("JSON 路径 %s 处不是对象", path)
+		}
+		if value, ok = fields[step.key]; !ok {
+			return "", fmt.Errorf("JSON 路径 %s 中的字段 %s 不存在", path, step.key)
+		}
+	}
+
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// truncateResultValue 截断超过日志字段长度的结果值，在 UTF-8 字符边界处截断
+func truncateResultValue(value string) string {
+	if len(value) <= maxResultValue {
+		return value
+	}
+	n := maxResultValue
+	for n > 0 && !utf8.RuneStart(value[n]) {
+		n--
+	}
+	return value[:n]
+}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestEvalJSONPath(t *testing.T) {
+	data := []byte(`{"data":{"count":12345678901234567890,"name":"批量","ok":true,"items":[{"id":1},{"id":2}],"a.b":null}}`)
+	cases := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"$.data.count", "12345678901234567890", false},
+		{"$.data.name", "批量", false},
+		{"$.data.ok", "true", false},
+		{"$.data.items[1].id", "2", false},
+		{"$.data.items[0]", `{"id":1}`, false},
+		{"$.data['a.b']", "null", false},
+		{"$", `{"data":{"a.b":null,"count":12345678901234567890,"items":[{"id":1},{"id":2}],"name":"批量","ok":true}}`, false},
+		{"$.data.missing", "", true},
+		{"$.data.items[2]", "", true},
+		{"$.data.name[0]", "", true},
+		{"data.count", "", true},
+		{"$.data..count", "", true},
+		{"$.data.items[-1]", "", true},
+	}
+	for _, c := range cases {
+		got, err := EvalJSONPath(data, c.path)
+		if (err != nil) != c.wantErr || got != c.want {
+			t.Errorf("%s: 结果 %q 错误 %v，期望 %q 出错=%v", c.path, got, err, c.want, c.wantErr)
+		}
+	}
+	if _, err := EvalJSONPath([]byte("not json"), "$.data"); err == nil {
+		t.Error("响应体不是 JSON 时应出错")
+	}
+	if got := truncateResultValue(strings.Repeat("值", 100)); len(got) != 255 {
+		t.Errorf("截断后长度 %d，期望在字符边界处截断为255字节", len(got))
+	}
+}
+
+func TestHTTPTaskResultValue(t *testing.T) {
+	received := make(chan map[string]interface{}, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/callback" {
+			var data map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&data)
+			received <- data
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"count":42}}`))
+	}))
+	defer srv.Close()
+
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{HTTPBlockedCIDRs: []string{allowLoopback}})
+	cases := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"提取计数", "$.data.count", "42"},
+		// 提取失败只记录警告，执行仍然成功
+		{"路径不存在", "$.data.total", ""},
+	}
+	for _, c := range cases {
+		task := createTestTask(t, db, &model.Task{Name: c.name, ExecType: model.ExecTypeHTTP, Command: srv.URL, OutputJSONPath: c.path, CallbackURL: srv.URL + "/callback"})
+		taskLog := s.ExecuteTask(task)
+		if taskLog.Status != 1 || taskLog.ResultValue != c.want {
+			t.Fatalf("%s: 状态 %d 结果值 %q，期望成功且结果值为 %q", c.name, taskLog.Status, taskLog.ResultValue, c.want)
+		}
+
+		var saved model.TaskLog
+		if err := db.Where("task_id = ?", task.ID).First(&saved).Error; err != nil {
+			t.Fatal(err)
+		}
+		if saved.ResultValue != c.want {
+			t.Fatalf("%s: 保存的结果值 %q，期望 %q", c.name, saved.ResultValue, c.want)
+		}
+		select {
+		case data := <-received:
+			if data["result_value"] != c.want {
+				t.Fatalf("%s: 回调中的结果值 %v，期望 %q", c.name, data["result_value"], c.want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: 未收到回调", c.name)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"log/slog"
+
+	"gorm.io/gorm"
+	"happx1/internal/model"
+)
+
+// runLimitReached 判断任务的执行次数是否已达到 MaxRuns，查询失败时视为未达到
+func (s *Scheduler) runLimitReached(task *model.Task) bool {
+	if task.MaxRuns <= 0 {
+		return false
+	}
+
+	var stats model.TaskStats
+	if err := s.db.Where("task_id = ?", task.ID).Limit(1).Find(&stats).Error; err != nil {
+		slog.Error("查询任务执行次数失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+		return false
+	}
+	return stats.TotalRuns >= int64(task.MaxRuns)
+}
+
+// disableTask 自动禁用任务并移除触发器，reason 为禁用原因
+func (s *Scheduler) disableTask(task *model.Task, reason string) {
+	s.RemoveTask(task.ID)
+	if err := s.db.Model(&model.Task{}).Where("id = ? AND status = ?", task.ID, 1).Updates(map[string]interface{}{
+		"status":  0,
+		"version": gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		slog.Error("自动禁用任务失败", "task_id", task.ID, "task_name", task.Name, "reason", reason, "error", err)
+		return
+	}
+	slog.Info("任务已自动禁用", "task_id", task.ID, "task_name", task.Name, "reason", reason)
+}
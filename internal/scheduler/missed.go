@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"log/slog"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// maxCatchUpRuns run_all 策略单个任务最多补执行的次数，避免长时间停机后集中执行大量任务
+const maxCatchUpRuns = 100
+
+// missedRuns 返回 cron 任务在 now 之前错过的、在生效时间窗口和活动时间内的触发时间，从保存的下次执行时间开始，最多 limit 个
+// 下次执行时间在每次触发时（检查执行条件之前）、注册触发器和执行后保存，早于当前时间说明服务停止期间没有触发
+func (s *Scheduler) missedRuns(task *model.Task, now time.Time, limit int) ([]time.Time, error) {
+	if task.Type != model.TaskTypeCron || task.NextRunTime.IsZero() || !task.NextRunTime.Before(now) {
+		return nil, nil
+	}
+	schedule, err := s.cronParser.Parse(task.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var missed []time.Time
+	for at := task.NextRunTime.In(s.location); at.Before(now) && len(missed) < limit; at = schedule.Next(at) {
+		// 生效时间窗口和活动时间之外的触发本来就会被跳过
+		if task.ValidUntil != nil && at.After(*task.ValidUntil) {
+			break
+		}
+		if task.ValidFrom != nil && at.Before(*task.ValidFrom) {
+			continue
+		}
+		if !s.inActiveHours(task, at) {
+			continue
+		}
+		missed = append(missed, at)
+	}
+	return missed, nil
+}
+
+// catchUpTimes 按任务的 MissedPolicy 返回需要补执行的错过的触发时间，需在注册触发器更新下次执行时间之前调用
+// run_once 只补执行最近一次错过的触发
+func (s *Scheduler) catchUpTimes(task *model.Task, now time.Time) []time.Time {
+	runs := 0
+	switch task.MissedPolicy {
+	case model.MissedPolicyRunOnce:
+		runs = 1
+	case model.MissedPolicyRunAll:
+		runs = maxCatchUpRuns
+	}
+
+	missed, err := s.missedRuns(task, now, maxCatchUpRuns)
+	if err != nil {
+		slog.Error("计算错过的触发失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+		return nil
+	}
+	if len(missed) == 0 {
+		return nil
+	}
+	if runs > len(missed) {
+		runs = len(missed)
+	}
+	slog.Info("任务在服务停止期间错过触发",
+		"task_id", task.ID,
+		"task_name", task.Name,
+		"missed", len(missed),
+		"first", missed[0],
+		"policy", task.MissedPolicy,
+		"catch_up_runs", runs,
+	)
+	return missed[len(missed)-runs:]
+}
+
+// catchUp 补执行错过的触发，补执行的日志标记为 catch_up
+// 每次补执行都按错过的触发时间检查执行条件（见 admit），不等待随机延迟；检查时任务被自动禁用则停止补执行
+func (s *Scheduler) catchUp(task *model.Task, times []time.Time) {
+	for _, at := range times {
+		run := *task
+		run.CatchUp = true
+		run.ScheduledAt = at
+		// 队列模式下同时启动的实例读到相同的下次执行时间，按错过的触发时间去重，每次补执行只由一个实例处理
+		if s.queueMode() {
+			claimed, err := s.claimFire(&run)
+			if err != nil {
+				slog.Error("跳过补执行", "task_id", task.ID, "task_name", task.Name, "error", err)
+				continue
+			}
+			if !claimed {
+				continue
+			}
+		}
+		if !s.admit(&run, at) {
+			if !s.scheduled(task.ID) {
+				return
+			}
+			continue
+		}
+		if err := s.submitScheduled(&run); err != nil {
+			slog.Info("跳过补执行", "task_id", task.ID, "task_name", task.Name, "reason", err)
+		}
+	}
+}
+
+// scheduled 返回任务是否已注册 cron 触发器
+func (s *Scheduler) scheduled(taskID uint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.entries[taskID]
+	return ok
+}
@@ -0,0 +1,129 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// missedTask 返回服务停止期间错过了3次整点触发的任务
+func missedTask(policy string) *model.Task {
+	return &model.Task{
+		Spec:         "0 * * * *",
+		Command:      "echo catch-up",
+		MissedPolicy: policy,
+		NextRunTime:  time.Now().Truncate(time.Hour).Add(-2 * time.Hour),
+	}
+}
+
+func TestCatchUpPolicies(t *testing.T) {
+	db := newTestDB(t)
+	skip := createTestTask(t, db, missedTask(model.MissedPolicySkip))
+	once := createTestTask(t, db, missedTask(model.MissedPolicyRunOnce))
+	all := createTestTask(t, db, missedTask(model.MissedPolicyRunAll))
+	startTestScheduler(t, db, nil, &Config{})
+
+	waitFor(t, 5*time.Second, "补执行完成", func() bool {
+		return countLogs(t, db, once.ID) == 1 && countLogs(t, db, all.ID) == 3
+	})
+	if n := countLogs(t, db, skip.ID); n != 0 {
+		t.Fatalf("skip 策略补执行了 %d 次", n)
+	}
+	var taskLog model.TaskLog
+	db.Where("task_id = ?", once.ID).First(&taskLog)
+	if !taskLog.CatchUp {
+		t.Fatal("补执行的日志未标记 catch_up")
+	}
+}
+
+func TestCatchUpChecksTriggerConditions(t *testing.T) {
+	db := newTestDB(t)
+	upstream := createTestTask(t, db, &model.Task{Name: "upstream", Command: "echo upstream"})
+
+	// 依赖的任务尚未执行
+	dependent := missedTask(model.MissedPolicyRunAll)
+	dependent.Name, dependent.DependsOn = "dependent", &upstream.ID
+	dependent = createTestTask(t, db, dependent)
+
+	// 执行次数已达上限
+	limited := missedTask(model.MissedPolicyRunAll)
+	limited.Name, limited.MaxRuns = "limited", 1
+	limited = createTestTask(t, db, limited)
+	if err := db.Create(&model.TaskStats{TaskID: limited.ID, TotalRuns: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := startTestScheduler(t, db, nil, &Config{})
+	waitFor(t, 5*time.Second, "达到执行次数上限的任务被禁用", func() bool {
+		var current model.Task
+		db.First(&current, limited.ID)
+		return current.Status == 0
+	})
+	waitFor(t, time.Second, "补执行的检查结束", func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_, scheduled := s.entries[limited.ID]
+		return !scheduled
+	})
+	// 依赖未满足时没有可等待的状态变化，留出补执行的时间
+	time.Sleep(300 * time.Millisecond)
+	if n := countLogs(t, db, dependent.ID); n != 0 {
+		t.Fatalf("依赖未满足的任务补执行了 %d 次", n)
+	}
+	if n := countLogs(t, db, limited.ID); n != 0 {
+		t.Fatalf("达到执行次数上限的任务补执行了 %d 次", n)
+	}
+}
+
+// inactiveDays 返回不包含今天的活动日期，只在后天生效
+func inactiveDays() string {
+	return strings.ToLower(time.Now().AddDate(0, 0, 2).Weekday().String()[:3])
+}
+
+func TestMissedRunsSkipsInactiveHours(t *testing.T) {
+	s := startTestScheduler(t, newTestDB(t), nil, &Config{})
+	task := missedTask(model.MissedPolicyRunAll)
+	task.Type, task.ActiveDays = model.TaskTypeCron, inactiveDays()
+
+	// 活动时间之外的触发本来就会被跳过，不算错过
+	missed, err := s.missedRuns(task, time.Now(), maxCatchUpRuns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missed) != 0 {
+		t.Fatalf("活动时间之外错过的触发为 %v，期望没有", missed)
+	}
+}
+
+func TestSkippedTriggerNotReplayedAfterRestart(t *testing.T) {
+	db := newTestDB(t)
+	config := &Config{EnableSecondsField: true}
+	task := createTestTask(t, db, &model.Task{Spec: "* * * * * *", Command: "echo skipped", MissedPolicy: model.MissedPolicyRunAll, ActiveDays: inactiveDays()})
+
+	// 不在活动时间内，每次触发都被跳过
+	s := NewScheduler(db, nil, config)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2500 * time.Millisecond)
+	s.Stop()
+	stoppedAt := time.Now()
+
+	// 跳过的触发也更新了下次执行时间
+	var current model.Task
+	if err := db.First(&current, task.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if current.NextRunTime.Before(stoppedAt.Add(-time.Second)) {
+		t.Fatalf("下次执行时间 %v 未随跳过的触发更新", current.NextRunTime)
+	}
+
+	// 重启后跳过的触发不会被当作错过的触发补执行
+	startTestScheduler(t, db, nil, config)
+	time.Sleep(300 * time.Millisecond)
+	if n := countLogs(t, db, task.ID); n != 0 {
+		t.Fatalf("重启后补执行了 %d 次跳过的触发", n)
+	}
+}
@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// Producer 消息队列生产者
+type Producer interface {
+	// Publish 发布消息，返回消息写入的分区和偏移量
+	Publish(ctx context.Context, topic string, body []byte, headers map[string]string) (partition int, offset int64, err error)
+	// Check 检查 broker 是否可连接且主题存在
+	Check(ctx context.Context, topic string) error
+}
+
+// ProducerFactory 按 broker 地址创建生产者
+type ProducerFactory func(brokers []string) (Producer, error)
+
+var (
+	producerMu        sync.RWMutex
+	producerFactories = map[string]ProducerFactory{}
+)
+
+// RegisterProducer 注册消息队列类型，scheme 对应 mq 类型任务 Command 中的协议名，如 kafka
+func RegisterProducer(scheme string, factory ProducerFactory) {
+	producerMu.Lock()
+	defer producerMu.Unlock()
+	producerFactories[scheme] = factory
+}
+
+// MQTarget mq 类型任务的发布目标
+type MQTarget struct {
+	Scheme  string
+	Brokers []string
+	Topic   string
+}
+
+// ParseMQTarget 解析 mq 类型任务的 Command，格式为 scheme://broker1:port,broker2:port/topic
+func ParseMQTarget(command string) (*MQTarget, error) {
+	u, err := url.Parse(strings.TrimSpace(command))
+	if err != nil {
+		return nil, fmt.Errorf("无效的消息队列地址: %v", err)
+	}
+	topic := strings.TrimPrefix(u.Path, "/")
+	if u.Scheme == "" || u.Host == "" || topic == "" || strings.Contains(topic, "/") {
+		return nil, fmt.Errorf("无效的消息队列地址，应为 scheme://broker:port/topic: %s", command)
+	}
+
+	producerMu.RLock()
+	_, ok := producerFactories[u.Scheme]
+	producerMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("不支持的消息队列类型: %s", u.Scheme)
+	}
+
+	return &MQTarget{
+		Scheme:  u.Scheme,
+		Brokers: strings.Split(u.Host, ","),
+		Topic:   topic,
+	}, nil
+}
+
+// newProducer 按发布目标创建生产者
+func newProducer(target *MQTarget) (Producer, error) {
+	producerMu.RLock()
+	factory := producerFactories[target.Scheme]
+	producerMu.RUnlock()
+	return factory(target.Brokers)
+}
+
+// mqCheckTimeout 创建任务时检查 broker 连接的超时时间
+const mqCheckTimeout = 5 * time.Second
+
+// CheckMQ 校验 mq 类型任务的发布目标，并检查 broker 是否可连接
+func CheckMQ(command string) error {
+	target, err := ParseMQTarget(command)
+	if err != nil {
+		return err
+	}
+	producer, err := newProducer(target)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mqCheckTimeout)
+	defer cancel()
+	if err := producer.Check(ctx, target.Topic); err != nil {
+		return fmt.Errorf("连接消息队列失败: %v", err)
+	}
+	return nil
+}
+
+// executeMQ 将 Body 作为消息发布到 Command 指定的主题，Headers 作为消息头，输出分区和偏移量
+func (s *Scheduler) executeMQ(task *model.Task) ([]byte, error) {
+	target, err := ParseMQTarget(task.Command)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := newProducer(target)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := executionContext(task)
+	defer cancel()
+
+	partition, offset, err := producer.Publish(ctx, target.Topic, []byte(task.Body), task.Headers)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("topic: %s, partition: %d, offset: %d", target.Topic, partition, offset)), nil
+}
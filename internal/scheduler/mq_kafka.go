@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func init() {
+	RegisterProducer("kafka", newKafkaProducer)
+}
+
+// kafkaProducer Kafka 生产者
+type kafkaProducer struct {
+	brokers []string
+}
+
+func newKafkaProducer(brokers []string) (Producer, error) {
+	return &kafkaProducer{brokers: brokers}, nil
+}
+
+// Publish 同步发布一条消息，等待 leader 确认后返回分区和偏移量
+func (p *kafkaProducer) Publish(ctx context.Context, topic string, body []byte, headers map[string]string) (int, int64, error) {
+	var written kafka.Message
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(p.brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		BatchSize:    1,
+		RequiredAcks: kafka.RequireOne,
+		Completion: func(messages []kafka.Message, err error) {
+			if err == nil && len(messages) > 0 {
+				written = messages[0]
+			}
+		},
+	}
+	defer w.Close()
+
+	msg := kafka.Message{Value: body}
+	for k, v := range headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	if err := w.WriteMessages(ctx, msg); err != nil {
+		return 0, 0, err
+	}
+	return written.Partition, written.Offset, nil
+}
+
+// Check 依次尝试连接 broker，读取主题的分区信息
+func (p *kafkaProducer) Check(ctx context.Context, topic string) error {
+	var lastErr error
+	for _, broker := range p.brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		partitions, err := conn.ReadPartitions(topic)
+		conn.Close()
+		if err != nil {
+			return err
+		}
+		if len(partitions) == 0 {
+			return fmt.Errorf("主题不存在: %s", topic)
+		}
+		return nil
+	}
+	return lastErr
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"happx1/internal/model"
+)
+
+// mockProducer 记录发布的消息，topic 为 missing 时检查失败
+type mockProducer struct {
+	brokers []string
+	topic   string
+	body    []byte
+	headers map[string]string
+}
+
+func (p *mockProducer) Publish(ctx context.Context, topic string, body []byte, headers map[string]string) (int, int64, error) {
+	p.topic, p.body, p.headers = topic, body, headers
+	return 3, 42, nil
+}
+
+func (p *mockProducer) Check(ctx context.Context, topic string) error {
+	if topic == "missing" {
+		return errors.New("主题不存在")
+	}
+	return nil
+}
+
+// registerMockProducer 注册 mock 协议的生产者，返回最近一次创建的生产者
+func registerMockProducer() func() *mockProducer {
+	var last *mockProducer
+	RegisterProducer("mock", func(brokers []string) (Producer, error) {
+		last = &mockProducer{brokers: brokers}
+		return last, nil
+	})
+	return func() *mockProducer { return last }
+}
+
+func TestExecuteMQ(t *testing.T) {
+	lastProducer := registerMockProducer()
+	s := NewScheduler(nil, nil, &Config{})
+	task := &model.Task{
+		ExecType: model.ExecTypeMQ,
+		Command:  "mock://broker-1:9092,broker-2:9092/orders",
+		Body:     `{"order_id":1}`,
+		Headers:  model.Headers{"X-Source": "happx1"},
+		Timeout:  5,
+	}
+
+	output, err := s.executeMQ(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != "topic: orders, partition: 3, offset: 42" {
+		t.Fatalf("输出 %q 不包含分区和偏移量", output)
+	}
+	p := lastProducer()
+	if !reflect.DeepEqual(p.brokers, []string{"broker-1:9092", "broker-2:9092"}) || p.topic != "orders" {
+		t.Fatalf("发布到 %v/%s，期望两个 broker 的 orders 主题", p.brokers, p.topic)
+	}
+	if string(p.body) != task.Body || p.headers["X-Source"] != "happx1" {
+		t.Fatalf("发布的消息 %s，消息头 %v", p.body, p.headers)
+	}
+}
+
+func TestCheckMQ(t *testing.T) {
+	registerMockProducer()
+	cases := []struct {
+		command string
+		wantErr string
+	}{
+		{"mock://broker:9092/orders", ""},
+		{"mock://broker:9092/missing", "连接消息队列失败"},
+		{"amqp://broker:5672/orders", "不支持的消息队列类型: amqp"},
+		{"mock://broker:9092/", "无效的消息队列地址"},
+		{"mock:///orders", "无效的消息队列地址"},
+		{"mock://broker:9092/a/b", "无效的消息队列地址"},
+	}
+	for _, c := range cases {
+		err := CheckMQ(c.command)
+		if c.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: %v", c.command, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: 错误为 %v，期望包含 %q", c.command, err, c.wantErr)
+		}
+	}
+}
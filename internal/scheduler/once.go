@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"happx1/internal/model"
+)
+
+// ParseOnceSpec 解析一次性任务的执行时间（RFC3339 格式）
+func ParseOnceSpec(spec string) (time.Time, error) {
+	return time.Parse(time.RFC3339, spec)
+}
+
+// fireOnce 一次性任务到期：确认任务仍然有效后将其禁用并触发执行
+func (s *Scheduler) fireOnce(task *model.Task) {
+	var current model.Task
+	if err := s.db.First(&current, task.ID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Error("加载一次性任务失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+		}
+		return
+	}
+	// 任务已被禁用或执行时间已修改，跳过过期的触发
+	if current.Status != 1 || current.Type != model.TaskTypeOnce || current.Spec != task.Spec {
+		return
+	}
+
+	// 一次性任务触发后即禁用，避免重启后重复执行
+	if err := s.db.Model(&current).Update("status", 0).Error; err != nil {
+		slog.Error("禁用一次性任务失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+		return
+	}
+	current.Status = 0
+	current.ScheduledAt, _ = ParseOnceSpec(current.Spec)
+	// 触发前可能等待随机延迟或请求前置检查，不占用定时队列的 goroutine，避免阻塞其他到期的一次性任务
+	go s.trigger(&current)
+}
@@ -0,0 +1,28 @@
+package scheduler
+
+import (
+	"encoding/base64"
+	"strings"
+	"unicode/utf8"
+
+	"happx1/internal/model"
+)
+
+// outputEncodingReplace 配置 output_encoding 为该值时，非 UTF-8 输出中的无效字节替换为 U+FFFD
+const outputEncodingReplace = "replace"
+
+// setOutput 保存执行输出到日志；输出不是有效的 UTF-8 时按配置 base64 编码（默认）并标记 OutputEncoding，
+// 或将无效字节替换为 U+FFFD，避免日志在数据库和 JSON 中损坏
+func (s *Scheduler) setOutput(taskLog *model.TaskLog, output []byte) {
+	taskLog.OutputEncoding = ""
+	if utf8.Valid(output) {
+		taskLog.Output = string(output)
+		return
+	}
+	if s.config.OutputEncoding == outputEncodingReplace {
+		taskLog.Output = strings.ToValidUTF8(string(output), "\uFFFD")
+		return
+	}
+	taskLog.Output = base64.StdEncoding.EncodeToString(output)
+	taskLog.OutputEncoding = model.OutputEncodingBase64
+}
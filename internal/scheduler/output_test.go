@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+
+	"happx1/internal/model"
+)
+
+func TestBinaryOutputRoundTrip(t *testing.T) {
+	raw := []byte{0xff, 0xfe, 'a', 'b', 'c', '\n'}
+	cases := []struct {
+		name     string
+		encoding string
+		check    func(t *testing.T, taskLog *model.TaskLog)
+	}{
+		{"默认 base64 编码", "", func(t *testing.T, taskLog *model.TaskLog) {
+			if taskLog.OutputEncoding != model.OutputEncodingBase64 {
+				t.Fatalf("output_encoding 为 %q，期望 base64", taskLog.OutputEncoding)
+			}
+			decoded, err := base64.StdEncoding.DecodeString(taskLog.Output)
+			if err != nil || !bytes.Equal(decoded, raw) {
+				t.Fatalf("解码后的输出为 %q（%v），期望 %q", decoded, err, raw)
+			}
+		}},
+		{"替换无效字节", outputEncodingReplace, func(t *testing.T, taskLog *model.TaskLog) {
+			// 连续的无效字节替换为一个 U+FFFD
+			if taskLog.OutputEncoding != "" || taskLog.Output != string(utf8.RuneError)+"abc\n" {
+				t.Fatalf("输出为 %q，output_encoding 为 %q", taskLog.Output, taskLog.OutputEncoding)
+			}
+		}},
+	}
+	for _, c := range cases {
+		db := newTestDB(t)
+		s := startTestScheduler(t, db, nil, &Config{OutputEncoding: c.encoding})
+		task := createTestTask(t, db, &model.Task{Command: `printf '\377\376abc\n'`})
+		if _, err := s.SubmitAndWait(context.Background(), task); err != nil {
+			t.Fatal(err)
+		}
+
+		// 保存的日志经过 JSON 序列化后内容不变
+		var saved model.TaskLog
+		if err := db.Where("task_id = ?", task.ID).First(&saved).Error; err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(&saved)
+		if err != nil {
+			t.Fatalf("%s: 日志无法序列化为 JSON: %v", c.name, err)
+		}
+		var decoded model.TaskLog
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Output != saved.Output || !utf8.ValidString(decoded.Output) {
+			t.Fatalf("%s: JSON 往返后的输出 %q 与保存的 %q 不同", c.name, decoded.Output, saved.Output)
+		}
+		c.check(t, &decoded)
+	}
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"happx1/internal/database"
+	"happx1/internal/model"
+)
+
+// ErrSchedulerPaused 调度器处于暂停模式，不执行任何任务
+var ErrSchedulerPaused = errors.New("调度器已暂停，恢复前不执行任务")
+
+// Pause 暂停调度器（维护模式）：触发器保持注册，但所有定时触发和手动执行都被跳过，状态持久化，重启后保持暂停
+// 已在执行的任务不受影响
+func (s *Scheduler) Pause(actor string) error {
+	if err := s.savePaused(true); err != nil {
+		return err
+	}
+	s.paused.Store(true)
+	slog.Warn("调度器已暂停", "actor", actor)
+	return nil
+}
+
+// Resume 恢复调度器，之后的触发正常执行；暂停期间错过的触发不补执行，跳过时已更新下次执行时间，重启后也不会被当作停止期间错过的触发
+func (s *Scheduler) Resume(actor string) error {
+	if err := s.savePaused(false); err != nil {
+		return err
+	}
+	s.paused.Store(false)
+	slog.Warn("调度器已恢复", "actor", actor)
+	return nil
+}
+
+// Paused 返回调度器是否处于暂停模式
+func (s *Scheduler) Paused() bool {
+	return s.paused.Load()
+}
+
+// loadPaused 从数据库恢复暂停状态
+func (s *Scheduler) loadPaused() error {
+	var setting model.Setting
+	err := s.db.Where(&model.Setting{Key: model.SettingSchedulerPaused}).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("加载暂停状态失败: %v", err)
+	}
+	paused, _ := strconv.ParseBool(setting.Value)
+	s.paused.Store(paused)
+	if paused {
+		slog.Warn("调度器处于暂停状态，恢复前不执行任务")
+	}
+	return nil
+}
+
+// savePaused 持久化暂停状态
+func (s *Scheduler) savePaused(paused bool) error {
+	setting := model.Setting{Key: model.SettingSchedulerPaused, Value: strconv.FormatBool(paused)}
+	if err := database.WithDeadlockRetry(func() error {
+		return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&setting).Error
+	}); err != nil {
+		return fmt.Errorf("保存暂停状态失败: %v", err)
+	}
+	return nil
+}
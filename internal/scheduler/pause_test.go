@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestPauseSkipsExecutions(t *testing.T) {
+	db := newTestDB(t)
+	task := createTestTask(t, db, &model.Task{Spec: "* * * * * *", Command: "echo tick"})
+	s := NewScheduler(db, nil, &Config{EnableSecondsField: true})
+	if err := s.Pause("test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 暂停期间定时触发和手动执行都被跳过
+	if err := s.Submit(newRun(task)); !errors.Is(err, ErrSchedulerPaused) {
+		t.Fatalf("暂停时提交的错误为 %v，期望 ErrSchedulerPaused", err)
+	}
+	if _, err := s.SubmitAndWait(context.Background(), newRun(task)); !errors.Is(err, ErrSchedulerPaused) {
+		t.Fatalf("暂停时同步执行的错误为 %v，期望 ErrSchedulerPaused", err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if n := countLogs(t, db, task.ID); n != 0 {
+		t.Fatalf("暂停期间执行了 %d 次", n)
+	}
+
+	// 暂停状态在重启后保持
+	s.Stop()
+	s = startTestScheduler(t, db, nil, &Config{EnableSecondsField: true})
+	if !s.Paused() {
+		t.Fatal("重启后暂停状态丢失")
+	}
+
+	if err := s.Resume("test"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 3*time.Second, "恢复后定时触发执行", func() bool { return countLogs(t, db, task.ID) > 0 })
+	if s.Paused() {
+		t.Fatal("恢复后仍处于暂停状态")
+	}
+}
+
+func TestPausedTriggersNotCaughtUpAfterRestart(t *testing.T) {
+	db := newTestDB(t)
+	config := &Config{EnableSecondsField: true}
+	task := createTestTask(t, db, &model.Task{Spec: "* * * * * *", Command: "echo tick", MissedPolicy: model.MissedPolicyRunAll})
+	s := NewScheduler(db, nil, config)
+	if err := s.Pause("test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2500 * time.Millisecond)
+	s.Stop()
+
+	// 维护结束后重启时恢复，暂停期间跳过的触发不补执行
+	s = NewScheduler(db, nil, config)
+	if err := s.Resume("test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+	waitFor(t, 3*time.Second, "恢复后定时触发执行", func() bool { return countLogs(t, db, task.ID) > 0 })
+	var catchUps int64
+	if err := db.Model(&model.TaskLog{}).Where("task_id = ? AND catch_up = ?", task.ID, true).Count(&catchUps).Error; err != nil {
+		t.Fatal(err)
+	}
+	if catchUps != 0 {
+		t.Fatalf("暂停期间跳过的触发补执行了 %d 次", catchUps)
+	}
+}
@@ -0,0 +1,166 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"happx1/internal/model"
+	"happx1/internal/notifications"
+	"happx1/pkg/utils"
+)
+
+// job 等待执行的任务
+type job struct {
+	task *model.Task
+	seq  uint64              // 入队序号，同优先级按先进先出执行
+	done chan *model.TaskLog // 非nil时执行结束后写入执行日志
+}
+
+// jobQueue 按优先级排序的任务队列，实现 heap.Interface
+type jobQueue []*job
+
+func (q jobQueue) Len() int { return len(q) }
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].task.Priority != q[j].task.Priority {
+		return q[i].task.Priority > q[j].task.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q jobQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *jobQueue) Push(x interface{}) { *q = append(*q, x.(*job)) }
+
+func (q *jobQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return item
+}
+
+// workerPool 有界 worker 池，worker 繁忙时高优先级任务优先出队
+type workerPool struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    jobQueue
+	seq      uint64
+	closed   bool
+	wg       sync.WaitGroup
+	workers  int // 目标 worker 数量
+	active   int // 运行中的 worker 数量，缩容时多出的 worker 在空闲时退出
+	execute  func(task *model.Task) *model.TaskLog
+	notifier notifications.Notifier // 任务执行 panic 时发送告警，可为 nil
+}
+
+func newWorkerPool(workers int, execute func(task *model.Task) *model.TaskLog, notifier notifications.Notifier) *workerPool {
+	if workers <= 0 {
+		workers = defaultWorkerCount
+	}
+	p := &workerPool{execute: execute, notifier: notifier}
+	p.cond = sync.NewCond(&p.mu)
+	p.resize(workers)
+	return p
+}
+
+// resize 调整 worker 数量，扩容立即生效，缩容时多出的 worker 执行完当前任务后退出
+func (p *workerPool) resize(workers int) {
+	if workers <= 0 {
+		workers = defaultWorkerCount
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.workers = workers
+	for p.active < p.workers {
+		p.active++
+		p.wg.Add(1)
+		go p.worker()
+	}
+	p.cond.Broadcast()
+}
+
+// size 返回目标 worker 数量
+func (p *workerPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.workers
+}
+
+// submit 提交任务到队列，done 非nil时执行结束后写入执行日志，需带缓冲
+// worker 池已停止时不执行，关闭 done 并返回 false，由调用方释放提交时的登记
+func (p *workerPool) submit(task *model.Task, done chan *model.TaskLog) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		if done != nil {
+			close(done)
+		}
+		return false
+	}
+	p.seq++
+	heap.Push(&p.queue, &job{task: task, seq: p.seq, done: done})
+	p.cond.Signal()
+	return true
+}
+
+// stop 停止 worker 池，丢弃尚未开始的任务（关闭其 done）并等待执行中的任务结束，返回被丢弃的任务，由调用方释放提交时的登记
+func (p *workerPool) stop() []*model.Task {
+	p.mu.Lock()
+	p.closed = true
+	dropped := make([]*model.Task, 0, len(p.queue))
+	for _, j := range p.queue {
+		if j.done != nil {
+			close(j.done)
+		}
+		dropped = append(dropped, j.task)
+	}
+	p.queue = nil
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.wg.Wait()
+	return dropped
+}
+
+func (p *workerPool) worker() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && !p.closed && p.active <= p.workers {
+			p.cond.Wait()
+		}
+		if p.closed || p.active > p.workers {
+			p.active--
+			p.mu.Unlock()
+			return
+		}
+		j := heap.Pop(&p.queue).(*job)
+		p.mu.Unlock()
+
+		p.run(j)
+	}
+}
+
+func (p *workerPool) run(j *job) {
+	finished := false
+	defer func() {
+		// 执行 panic 时也通知等待结果的调用方，返回失败的执行日志，避免一直等到超时
+		if !finished && j.done != nil {
+			now := time.Now()
+			j.done <- &model.TaskLog{TaskID: j.task.ID, RunID: j.task.RunID, StartTime: now, EndTime: now, Error: "执行发生 panic，详见服务日志"}
+		}
+	}()
+	defer utils.Recover(fmt.Sprintf("Task-%d", j.task.ID), context.Background(), p.notifier)
+	taskLog := p.execute(j.task)
+	finished = true
+	if j.done != nil {
+		j.done <- taskLog
+	}
+}
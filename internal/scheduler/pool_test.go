@@ -0,0 +1,205 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestPoolRunsHigherPriorityFirst(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p := newWorkerPool(1, func(task *model.Task) *model.TaskLog {
+		if task.Name == "blocker" {
+			close(started)
+			<-release
+			return nil
+		}
+		mu.Lock()
+		order = append(order, task.Name)
+		mu.Unlock()
+		return nil
+	}, nil)
+	defer p.stop()
+
+	// 唯一的 worker 被占用，之后提交的任务排队
+	p.submit(&model.Task{Name: "blocker"}, nil)
+	<-started
+	done := make(chan *model.TaskLog, 5)
+	for _, task := range []*model.Task{
+		{Name: "low", Priority: 1},
+		{Name: "high", Priority: 10},
+		{Name: "default"},
+		{Name: "medium", Priority: 5},
+		{Name: "high-later", Priority: 10},
+	} {
+		p.submit(task, done)
+	}
+	close(release)
+	for i := 0; i < 5; i++ {
+		<-done
+	}
+
+	want := []string{"high", "high-later", "medium", "low", "default"}
+	mu.Lock()
+	defer mu.Unlock()
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("执行顺序 %v，期望 %v", order, want)
+		}
+	}
+}
+
+func TestPoolResize(t *testing.T) {
+	var running, peak int
+	var mu sync.Mutex
+	p := newWorkerPool(1, func(task *model.Task) *model.TaskLog {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}, nil)
+	defer p.stop()
+
+	p.resize(3)
+	done := make(chan *model.TaskLog, 6)
+	for i := 0; i < 6; i++ {
+		p.submit(&model.Task{}, done)
+	}
+	for i := 0; i < 6; i++ {
+		<-done
+	}
+	if p.size() != 3 || peak != 3 {
+		t.Fatalf("worker 数量 %d，同时执行 %d，期望都为3", p.size(), peak)
+	}
+}
+
+func TestPoolSubmitAfterStop(t *testing.T) {
+	p := newWorkerPool(1, func(task *model.Task) *model.TaskLog { return &model.TaskLog{} }, nil)
+	p.stop()
+
+	done := make(chan *model.TaskLog, 1)
+	if p.submit(&model.Task{}, done) {
+		t.Fatal("停止后提交应返回 false")
+	}
+	if _, ok := <-done; ok {
+		t.Fatal("停止后提交应关闭 done")
+	}
+}
+
+func TestPoolPanicNotifiesWaiter(t *testing.T) {
+	p := newWorkerPool(1, func(task *model.Task) *model.TaskLog { panic("boom") }, nil)
+	defer p.stop()
+
+	// 执行 panic 后等待结果的调用方立即收到失败的执行日志，worker 继续处理后续任务
+	for i := 0; i < 2; i++ {
+		done := make(chan *model.TaskLog, 1)
+		p.submit(&model.Task{}, done)
+		select {
+		case taskLog, ok := <-done:
+			if !ok || taskLog == nil || taskLog.Status != 0 || taskLog.Error == "" {
+				t.Fatalf("panic 后收到的执行日志为 %+v，期望失败的执行日志", taskLog)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("执行 panic 后等待结果的调用方未收到通知")
+		}
+	}
+}
+
+func TestPoolStopDropsQueuedJobs(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p := newWorkerPool(1, func(task *model.Task) *model.TaskLog {
+		close(started)
+		<-release
+		return &model.TaskLog{}
+	}, nil)
+
+	running := make(chan *model.TaskLog, 1)
+	p.submit(&model.Task{Name: "running"}, running)
+	<-started
+	queued := make(chan *model.TaskLog, 1)
+	p.submit(&model.Task{Name: "queued"}, queued)
+
+	stopped := make(chan []*model.Task)
+	go func() { stopped <- p.stop() }()
+	// 丢弃的任务立即收到 done 关闭，执行中的任务完成后 stop 才返回
+	if _, ok := <-queued; ok {
+		t.Fatal("丢弃的任务应关闭 done")
+	}
+	close(release)
+	dropped := <-stopped
+	if len(dropped) != 1 || dropped[0].Name != "queued" {
+		t.Fatalf("丢弃的任务 %v，期望只有 queued", dropped)
+	}
+	if taskLog := <-running; taskLog == nil {
+		t.Fatal("执行中的任务应正常完成")
+	}
+}
+
+func TestSubmitAfterStopReleasesRun(t *testing.T) {
+	db := newTestDB(t)
+	s := NewScheduler(db, nil, &Config{})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+
+	task := createTestTask(t, db, &model.Task{Command: "echo", Manual: true})
+	for i := 0; i < 3; i++ {
+		if err := s.Submit(task); !errors.Is(err, ErrSchedulerStopped) {
+			t.Fatalf("停止后提交的错误为 %v，期望 ErrSchedulerStopped", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := s.SubmitAndWait(ctx, task)
+		cancel()
+		if !errors.Is(err, ErrSchedulerStopped) {
+			t.Fatalf("停止后同步执行的错误为 %v，期望 ErrSchedulerStopped", err)
+		}
+	}
+	if n := s.manualRuns[task.ID]; n != 0 {
+		t.Fatalf("被拒绝的手动执行未释放登记，剩余 %d", n)
+	}
+}
+
+func TestStopReleasesDroppedRuns(t *testing.T) {
+	db := newTestDB(t)
+	s := NewScheduler(db, nil, &Config{WorkerCount: 1})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	slow := createTestTask(t, db, &model.Task{Name: "slow", Command: "sleep 0.3"})
+	queued := createTestTask(t, db, &model.Task{Name: "queued", Command: "echo", MaxConcurrent: 1})
+	if err := s.Submit(slow); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 2*time.Second, "慢任务开始执行", func() bool {
+		s.pool.mu.Lock()
+		defer s.pool.mu.Unlock()
+		return len(s.pool.queue) == 0
+	})
+	if err := s.Submit(queued); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+
+	if n := s.activeRuns[queued.ID]; n != 0 {
+		t.Fatalf("丢弃的执行未释放并发登记，剩余 %d", n)
+	}
+	if n := countLogs(t, db, queued.ID); n != 0 {
+		t.Fatalf("丢弃的执行被执行了 %d 次", n)
+	}
+}
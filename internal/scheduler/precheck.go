@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// preCheckTimeout 前置检查请求的超时时间
+const preCheckTimeout = 10 * time.Second
+
+// maxPreCheckBody 前置检查读取的响应体上限
+const maxPreCheckBody = 64 << 10
+
+// checkPrecondition 请求任务的前置检查地址，返回 2xx 且响应体匹配 PreCheckExpect 时条件满足，返回nil
+// 请求与 http 类型任务一样受禁止访问网段的限制，但不使用任务的 TLS 配置（跳过证书校验、CA 证书和客户端证书只用于任务本身的请求）
+func (s *Scheduler) checkPrecondition(task *model.Task) error {
+	if task.PreCheckURL == "" {
+		return nil
+	}
+
+	transport := s.guardedTransport(0)
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, CheckRedirect: checkRedirect(task)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), preCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, task.PreCheckURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", s.userAgent())
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求前置检查地址失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("前置检查返回状态 %d", resp.StatusCode)
+	}
+	if task.PreCheckExpect == "" {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPreCheckBody))
+	if err != nil {
+		return fmt.Errorf("读取前置检查响应失败: %v", err)
+	}
+	pattern, err := regexp.Compile(task.PreCheckExpect)
+	if err != nil {
+		return fmt.Errorf("无效的前置检查匹配规则: %v", err)
+	}
+	if !pattern.Match(bytes.TrimSpace(body)) {
+		return fmt.Errorf("前置检查响应不匹配 %q", task.PreCheckExpect)
+	}
+	return nil
+}
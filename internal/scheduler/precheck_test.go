@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestCheckPrecondition(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "status: ready")
+	}))
+	defer srv.Close()
+
+	s := newGuardedScheduler(t, allowLoopback)
+	cases := []struct {
+		name    string
+		url     string
+		expect  string
+		wantErr bool
+	}{
+		{"未设置检查地址", "", "", false},
+		{"2xx 即满足", srv.URL, "", false},
+		{"响应匹配", srv.URL, "^status: ready$", false},
+		{"响应不匹配", srv.URL, "not-ready", true},
+		{"非 2xx", srv.URL + "/down", "", true},
+	}
+	for _, c := range cases {
+		err := s.checkPrecondition(&model.Task{PreCheckURL: c.url, PreCheckExpect: c.expect})
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: 错误为 %v，期望出错 %v", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestCheckPreconditionBlockedAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("禁止访问的前置检查地址收到了请求")
+	}))
+	defer srv.Close()
+
+	s := newGuardedScheduler(t)
+	if err := s.checkPrecondition(&model.Task{PreCheckURL: srv.URL}); err == nil || !strings.Contains(err.Error(), "禁止访问的地址") {
+		t.Fatalf("错误为 %v，期望连接被拦截", err)
+	}
+}
+
+func TestCheckPreconditionIgnoresTaskTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("证书校验失败的前置检查地址收到了请求")
+	}))
+	defer srv.Close()
+
+	// 任务关闭了证书校验，只对任务本身的请求生效，前置检查仍校验自签名证书
+	s := newGuardedScheduler(t, allowLoopback)
+	task := &model.Task{PreCheckURL: srv.URL, InsecureSkipVerify: true}
+	if err := s.checkPrecondition(task); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("错误为 %v，期望证书校验失败", err)
+	}
+}
+
+func TestSlowPreconditionDoesNotBlockOtherOnceTasks(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{HTTPBlockedCIDRs: []string{allowLoopback}})
+	due := time.Now().Add(time.Second).Truncate(time.Second).Add(time.Second)
+	once := func(name, preCheckURL string) *model.Task {
+		task := &model.Task{Name: name, Type: model.TaskTypeOnce, Spec: due.Format(time.RFC3339), Command: "echo " + name, Timeout: 5, Status: 1, PreCheckURL: preCheckURL}
+		if err := s.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	// 前置检查无响应的任务先加入，同时到期时先于另一个任务触发
+	once("slow-precheck", srv.URL)
+	plain := once("plain", "")
+
+	// 前置检查在后台等待，不阻塞定时队列触发同时到期的其他任务
+	waitFor(t, 5*time.Second, "同时到期的任务按时执行", func() bool { return countLogs(t, db, plain.ID) == 1 })
+}
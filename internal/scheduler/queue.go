@@ -0,0 +1,235 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"happx1/internal/model"
+)
+
+// 执行模式
+const (
+	ExecutionModeInline = "inline" // 触发后直接提交到本实例的 worker 池（默认）
+	ExecutionModeQueue  = "queue"  // 触发后写入 Redis 执行队列，由各实例的 worker 取出执行
+)
+
+const (
+	executionQueueKey      = "happx1:execution:queue"
+	executionProcessingKey = "happx1:execution:processing:" // 加消费者ID，保存已取出、尚未执行完的执行
+	executionFiredKey      = "happx1:execution:fired:"      // 加任务ID和计划触发时间，标记已被某个实例处理的定时触发
+	queuePollTimeout       = time.Second
+	// firedKeyTTL 定时触发标记的保留时间，只需覆盖各实例时钟偏差和触发延迟
+	firedKeyTTL = 10 * time.Minute
+)
+
+// queuedExecution 执行队列中的一次执行，取出时按任务ID重新加载任务配置
+type queuedExecution struct {
+	TaskID     uint              `json:"task_id"`
+	RunID      string            `json:"run_id"`
+	Params     map[string]string `json:"params,omitempty"`
+	CatchUp    bool              `json:"catch_up,omitempty"`
+	ChainDepth int               `json:"chain_depth,omitempty"`
+	Once       bool              `json:"once,omitempty"` // 一次性任务，触发时已被禁用，取出时不检查启用状态
+	EnqueuedAt time.Time         `json:"enqueued_at"`
+}
+
+// queueMode 是否使用 Redis 执行队列
+func (s *Scheduler) queueMode() bool {
+	return s.config.ExecutionMode == ExecutionModeQueue
+}
+
+// checkExecutionMode 校验执行模式配置，队列模式需要 Redis
+func (s *Scheduler) checkExecutionMode() error {
+	switch s.config.ExecutionMode {
+	case "", ExecutionModeInline:
+		return nil
+	case ExecutionModeQueue:
+		if s.redis == nil {
+			return fmt.Errorf("执行模式 queue 需要配置 Redis")
+		}
+		return nil
+	default:
+		return fmt.Errorf("无效的执行模式: %s，可选 inline、queue", s.config.ExecutionMode)
+	}
+}
+
+// consumerID 本实例的消费者ID，未配置时为主机名
+func (s *Scheduler) consumerID() string {
+	if s.config.QueueConsumerID != "" {
+		return s.config.QueueConsumerID
+	}
+	consumer, _ := os.Hostname()
+	return consumer
+}
+
+// processingKey 本实例已取出、尚未执行完的执行列表
+func (s *Scheduler) processingKey() string {
+	return executionProcessingKey + s.consumerID()
+}
+
+// claimFire 队列模式下每个实例都注册了触发器，同一次定时触发（包括错过的触发的补执行）只由第一个写入标记的实例处理，返回是否由本实例处理
+// 没有计划触发时间的执行（如后续任务、after 类型任务）只在一个实例上发起，不去重
+func (s *Scheduler) claimFire(task *model.Task) (bool, error) {
+	if task.ScheduledAt.IsZero() {
+		return true, nil
+	}
+	key := fmt.Sprintf("%s%d:%d", executionFiredKey, task.ID, task.ScheduledAt.Unix())
+	claimed, err := s.redis.SetNX(context.Background(), key, s.consumerID(), firedKeyTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("写入定时触发标记失败: %v", err)
+	}
+	return claimed, nil
+}
+
+// enqueue 将一次执行写入 Redis 执行队列
+func (s *Scheduler) enqueue(task *model.Task) error {
+	data, err := json.Marshal(queuedExecution{
+		TaskID:     task.ID,
+		RunID:      task.RunID,
+		Params:     task.RunParams,
+		CatchUp:    task.CatchUp,
+		ChainDepth: task.ChainDepth,
+		Once:       task.Type == model.TaskTypeOnce,
+		EnqueuedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.redis.LPush(context.Background(), executionQueueKey, data).Err(); err != nil {
+		return fmt.Errorf("写入执行队列失败: %v", err)
+	}
+	return nil
+}
+
+// requeueProcessing 将本实例上次停止时已取出但未执行完的执行放回队列，保证至少执行一次
+func (s *Scheduler) requeueProcessing() error {
+	ctx := context.Background()
+	key := s.processingKey()
+	for {
+		err := s.redis.RPopLPush(ctx, key, executionQueueKey).Err()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("恢复未执行完的队列执行失败: %v", err)
+		}
+	}
+}
+
+// runQueueConsumer 从执行队列取出执行并提交到 worker 池，同时进行的执行数不超过 worker 数量，直到调度器停止
+// 取出的执行先移到本实例的处理中列表，执行结束后删除；执行中途服务停止时，下次启动会放回队列
+func (s *Scheduler) runQueueConsumer() {
+	defer s.wg.Done()
+
+	ctx := context.Background()
+	key := s.processingKey()
+	var inflight atomic.Int64
+	// 每次执行结束时通知有空闲的 worker
+	freed := make(chan struct{}, 1)
+	for {
+		for inflight.Load() >= int64(s.pool.size()) {
+			select {
+			case <-freed:
+			case <-s.stopCh:
+				return
+			}
+		}
+
+		select {
+		case <-s.stopCh:
+			return
+		default:
+		}
+		raw, err := s.redis.BRPopLPush(ctx, executionQueueKey, key, queuePollTimeout).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			slog.Error("读取执行队列失败", "error", err)
+			select {
+			case <-time.After(queuePollTimeout):
+			case <-s.stopCh:
+				return
+			}
+			continue
+		}
+
+		task, err := s.dequeuedTask(raw)
+		if err != nil {
+			slog.Warn("跳过队列中的执行", "reason", err)
+			s.ackQueued(raw)
+			continue
+		}
+		if err := s.acquireRun(task); err != nil {
+			slog.Info("跳过任务", "task_id", task.ID, "task_name", task.Name, "run_id", task.RunID, "reason", err)
+			s.ackQueued(raw)
+			continue
+		}
+
+		done := make(chan *model.TaskLog, 1)
+		parked, ok := s.dispatch(task, done)
+		if !ok {
+			// 调度器正在停止，执行留在处理中列表，下次启动时放回队列
+			s.releaseRun(task)
+			return
+		}
+		// 等待并发名额的执行不占用 worker，不计入同时进行的执行数，避免阻塞其他任务的执行出队
+		if !parked {
+			inflight.Add(1)
+		}
+		// 停止调度器时等待确认完成，避免停止期间执行完的执行在重启后被重复执行
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			// 调度器停止时尚未开始的执行被丢弃（done 被关闭），留在处理中列表，下次启动时放回队列
+			if _, executed := <-done; executed {
+				s.ackQueued(raw)
+			}
+			if !parked {
+				inflight.Add(-1)
+			}
+			select {
+			case freed <- struct{}{}:
+			default:
+			}
+		}()
+	}
+}
+
+// dequeuedTask 按队列中的执行加载任务，任务已删除或已禁用时返回错误
+// 一次性任务在触发时已被禁用（见 fireOnce），只要求任务存在且仍是一次性任务
+func (s *Scheduler) dequeuedTask(raw string) (*model.Task, error) {
+	var item queuedExecution
+	if err := json.Unmarshal([]byte(raw), &item); err != nil {
+		return nil, fmt.Errorf("无法解析队列中的执行: %v", err)
+	}
+	query := s.db.Where("id = ?", item.TaskID)
+	if item.Once {
+		query = query.Where("type = ?", model.TaskTypeOnce)
+	} else {
+		query = query.Where("status = ?", 1)
+	}
+	var task model.Task
+	if err := query.Limit(1).Find(&task).Error; err != nil {
+		return nil, err
+	}
+	if task.ID == 0 {
+		return nil, fmt.Errorf("任务 %d 不存在或已禁用", item.TaskID)
+	}
+	task.RunID, task.RunParams, task.CatchUp, task.ChainDepth = item.RunID, item.Params, item.CatchUp, item.ChainDepth
+	return &task, nil
+}
+
+// ackQueued 从处理中列表删除已结束的执行
+func (s *Scheduler) ackQueued(raw string) {
+	if err := s.redis.LRem(context.Background(), s.processingKey(), 1, raw).Err(); err != nil {
+		slog.Error("删除处理中的队列执行失败", "error", err)
+	}
+}
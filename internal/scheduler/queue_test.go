@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"happx1/internal/model"
+)
+
+// newTestRedis 启动内存 Redis，测试结束时关闭
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
+
+func queueConfig() *Config {
+	return &Config{WorkerCount: 2, ExecutionMode: ExecutionModeQueue, QueueConsumerID: "test"}
+}
+
+func TestQueueModeRequiresRedis(t *testing.T) {
+	s := NewScheduler(newTestDB(t), nil, queueConfig())
+	if err := s.checkExecutionMode(); err == nil {
+		t.Fatal("队列模式没有 Redis 时应报错")
+	}
+}
+
+func TestQueueModeExecutesEnqueuedRun(t *testing.T) {
+	db := newTestDB(t)
+	mr, rdb := newTestRedis(t)
+	s := startTestScheduler(t, db, rdb, queueConfig())
+	task := createTestTask(t, db, &model.Task{Command: "echo queued"})
+
+	if err := s.submitScheduled(task); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 5*time.Second, "队列中的执行被取出执行", func() bool { return countLogs(t, db, task.ID) == 1 })
+
+	var taskLog model.TaskLog
+	db.Where("task_id = ?", task.ID).First(&taskLog)
+	if taskLog.Status != 1 || taskLog.RunID != task.RunID {
+		t.Fatalf("执行结果 status=%d run_id=%q，期望成功且 run_id=%q", taskLog.Status, taskLog.RunID, task.RunID)
+	}
+	waitFor(t, time.Second, "执行结束后从处理中列表删除", func() bool {
+		return !mr.Exists(executionProcessingKey+"test") && !mr.Exists(executionQueueKey)
+	})
+}
+
+func TestQueueModeRunsOnceTask(t *testing.T) {
+	db := newTestDB(t)
+	_, rdb := newTestRedis(t)
+	s := startTestScheduler(t, db, rdb, queueConfig())
+	task := createTestTask(t, db, &model.Task{
+		Type:    model.TaskTypeOnce,
+		Spec:    time.Now().Add(time.Hour).Format(time.RFC3339),
+		Command: "echo once",
+	})
+
+	// 到期时任务先被禁用再写入队列，取出时仍应执行
+	s.fireOnce(task)
+	waitFor(t, 5*time.Second, "一次性任务被执行", func() bool { return countLogs(t, db, task.ID) == 1 })
+
+	var current model.Task
+	db.First(&current, task.ID)
+	if current.Status != 0 {
+		t.Fatalf("一次性任务执行后 status=%d，期望已禁用", current.Status)
+	}
+}
+
+func TestQueueModeSkipsDisabledTask(t *testing.T) {
+	db := newTestDB(t)
+	mr, rdb := newTestRedis(t)
+	s := startTestScheduler(t, db, rdb, queueConfig())
+	task := createTestTask(t, db, &model.Task{Command: "echo disabled"})
+
+	// 写入队列后、取出前任务被禁用
+	db.Model(&model.Task{}).Where("id = ?", task.ID).Update("status", 0)
+	if err := s.enqueue(task); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 5*time.Second, "队列中的执行被取出", func() bool {
+		return !mr.Exists(executionQueueKey) && !mr.Exists(executionProcessingKey+"test")
+	})
+	if n := countLogs(t, db, task.ID); n != 0 {
+		t.Fatalf("已禁用任务执行了 %d 次", n)
+	}
+}
+
+func TestQueueModeRequeuesUnfinishedRunsOnStart(t *testing.T) {
+	db := newTestDB(t)
+	mr, rdb := newTestRedis(t)
+	task := createTestTask(t, db, &model.Task{Command: "echo resumed"})
+
+	// 模拟上次停止时已取出但未执行完的执行
+	data, _ := json.Marshal(queuedExecution{TaskID: task.ID, RunID: "left-over", EnqueuedAt: time.Now()})
+	if err := rdb.LPush(context.Background(), executionProcessingKey+"test", data).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	startTestScheduler(t, db, rdb, queueConfig())
+	waitFor(t, 5*time.Second, "未执行完的执行在启动后被重新执行", func() bool { return countLogs(t, db, task.ID) == 1 })
+
+	var taskLog model.TaskLog
+	db.Where("task_id = ?", task.ID).First(&taskLog)
+	if taskLog.RunID != "left-over" {
+		t.Fatalf("run_id=%q，期望沿用队列中的执行ID", taskLog.RunID)
+	}
+	waitFor(t, time.Second, "处理中列表被清空", func() bool { return !mr.Exists(executionProcessingKey + "test") })
+}
+
+func TestQueueModeStopKeepsPendingRuns(t *testing.T) {
+	db := newTestDB(t)
+	mr, rdb := newTestRedis(t)
+	config := queueConfig()
+	config.WorkerCount = 1
+	s := NewScheduler(db, rdb, config)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	slow := createTestTask(t, db, &model.Task{Name: "slow", Command: "sleep 1"})
+	pending := createTestTask(t, db, &model.Task{Name: "pending", Command: "echo pending"})
+	if err := s.submitScheduled(slow); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 5*time.Second, "慢任务被取出", func() bool { return !mr.Exists(executionQueueKey) })
+	// 唯一的 worker 正在执行慢任务，这次执行留在队列中
+	if err := s.submitScheduled(pending); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+
+	// 停止时等待执行中的慢任务完成并确认，尚未取出的执行保留在队列中，重启后执行
+	if mr.Exists(executionProcessingKey + "test") {
+		t.Fatal("执行完成的执行仍在处理中列表")
+	}
+	if n := countLogs(t, db, slow.ID); n != 1 {
+		t.Fatalf("慢任务执行了 %d 次，期望1次", n)
+	}
+	if n, _ := rdb.LLen(context.Background(), executionQueueKey).Result(); n != 1 {
+		t.Fatalf("队列中剩余 %d 次执行，期望1次", n)
+	}
+	if n := countLogs(t, db, pending.ID); n != 0 {
+		t.Fatalf("停止后仍执行了排队的任务 %d 次", n)
+	}
+
+	startTestScheduler(t, db, rdb, config)
+	waitFor(t, 5*time.Second, "重启后执行队列中剩余的执行", func() bool { return countLogs(t, db, pending.ID) == 1 })
+}
+
+func TestQueueModeDedupesFiresAcrossInstances(t *testing.T) {
+	db := newTestDB(t)
+	_, rdb := newTestRedis(t)
+	// 两个实例共用数据库和 Redis，都注册了同一任务的每秒触发
+	var instances []*Scheduler
+	for _, consumer := range []string{"a", "b"} {
+		config := queueConfig()
+		config.QueueConsumerID, config.EnableSecondsField = consumer, true
+		instances = append(instances, startTestScheduler(t, db, rdb, config))
+	}
+	task := createTestTask(t, db, &model.Task{Spec: "* * * * * *", Command: "echo tick"})
+	for _, s := range instances {
+		if err := s.RescheduleTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitFor(t, 5*time.Second, "执行了3次触发", func() bool { return countLogs(t, db, task.ID) >= 3 })
+	for _, s := range instances {
+		s.RemoveTask(task.ID)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	// 每次触发只执行一次：各次执行的开始时间在不同的秒内
+	var logs []model.TaskLog
+	if err := db.Where("task_id = ?", task.ID).Order("start_time").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int64]bool{}
+	for _, l := range logs {
+		second := l.StartTime.Unix()
+		if seen[second] {
+			t.Fatalf("同一次触发被执行了多次: %v", l.StartTime)
+		}
+		seen[second] = true
+	}
+}
+
+func TestQueueModeDedupesCatchUpAcrossInstances(t *testing.T) {
+	db := newTestDB(t)
+	_, rdb := newTestRedis(t)
+	var instances []*Scheduler
+	for _, consumer := range []string{"a", "b"} {
+		config := queueConfig()
+		config.QueueConsumerID = consumer
+		instances = append(instances, startTestScheduler(t, db, rdb, config))
+	}
+	task := createTestTask(t, db, missedTask(model.MissedPolicyRunAll))
+	times := instances[0].catchUpTimes(task, time.Now())
+	if len(times) != 3 {
+		t.Fatalf("错过的触发为 %v，期望3次", times)
+	}
+
+	// 同时启动的实例读到相同的下次执行时间，都尝试补执行
+	for _, s := range instances {
+		s.catchUp(task, times)
+	}
+	waitFor(t, 5*time.Second, "补执行完成", func() bool { return countLogs(t, db, task.ID) >= 3 })
+	time.Sleep(300 * time.Millisecond)
+	if n := countLogs(t, db, task.ID); n != 3 {
+		t.Fatalf("补执行了 %d 次，期望每次错过的触发只执行一次", n)
+	}
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"happx1/internal/model"
+)
+
+// ParseRedisCommand 将 Redis 命令行解析为参数，支持单引号、双引号和双引号内的反斜杠转义
+func ParseRedisCommand(line string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == '\\' && quote == '"' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("Redis 命令引号不匹配")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("Redis 命令不能为空")
+	}
+	return args, nil
+}
+
+// reservedRedisKeyPrefix 调度器自身使用的键前缀：执行队列、定时触发标记、回调缓冲和接口限流等，
+// redis 类型任务与调度器共用同一个连接，不能读写这些键
+const reservedRedisKeyPrefix = "happx1:"
+
+// CheckRedis 校验 redis 类型任务的命令：能正确解析、命令名在允许列表中，且参数不引用调度器自身使用的键
+func (s *Scheduler) CheckRedis(command string) error {
+	args, err := ParseRedisCommand(command)
+	if err != nil {
+		return err
+	}
+	allowed := false
+	for _, name := range s.config.RedisAllowedCommands {
+		if strings.EqualFold(args[0], strings.TrimSpace(name)) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("不允许执行的 Redis 命令: %s", strings.ToUpper(args[0]))
+	}
+	// 不同命令的键位置不同，保守地检查所有参数
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, reservedRedisKeyPrefix) {
+			return fmt.Errorf("不允许访问调度器使用的键: %s", arg)
+		}
+	}
+	return nil
+}
+
+// executeRedis 执行 Redis 命令，输出命令的返回值
+func (s *Scheduler) executeRedis(task *model.Task) ([]byte, error) {
+	if s.redis == nil {
+		return nil, fmt.Errorf("未初始化 Redis 连接")
+	}
+	// 执行前再次校验，配置可能在创建任务后修改
+	if err := s.CheckRedis(task.Command); err != nil {
+		return nil, err
+	}
+	args, _ := ParseRedisCommand(task.Command)
+
+	ctx, cancel := executionContext(task)
+	defer cancel()
+
+	cmdArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		cmdArgs[i] = arg
+	}
+	reply, err := s.redis.Do(ctx, cmdArgs...).Result()
+	if errors.Is(err, redis.Nil) {
+		return []byte("(nil)"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(formatRedisReply(reply)), nil
+}
+
+// formatRedisReply 格式化 Redis 返回值，数组每个元素一行
+func formatRedisReply(reply interface{}) string {
+	switch v := reply.(type) {
+	case nil:
+		return "(nil)"
+	case []interface{}:
+		lines := make([]string, len(v))
+		for i, item := range v {
+			lines[i] = formatRedisReply(item)
+		}
+		return strings.Join(lines, "\n")
+	default:
+		return fmt.Sprint(v)
+	}
+}
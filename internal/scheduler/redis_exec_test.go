@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"happx1/internal/model"
+)
+
+func TestExecuteRedis(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	s := NewScheduler(nil, rdb, &Config{RedisAllowedCommands: []string{"set", "GET", " del "}})
+	run := func(command string) (string, error) {
+		output, err := s.executeRedis(&model.Task{ExecType: model.ExecTypeRedis, Command: command, Timeout: 5})
+		return string(output), err
+	}
+
+	if output, err := run(`SET greeting "hello world"`); err != nil || output != "OK" {
+		t.Fatalf("SET 输出 %q，错误 %v", output, err)
+	}
+	if output, err := run("get greeting"); err != nil || output != "hello world" {
+		t.Fatalf("GET 输出 %q，错误 %v，期望 hello world", output, err)
+	}
+	if output, err := run("GET missing"); err != nil || output != "(nil)" {
+		t.Fatalf("GET 不存在的键输出 %q，错误 %v", output, err)
+	}
+
+	if _, err := run("FLUSHALL"); err == nil || !strings.Contains(err.Error(), "不允许执行的 Redis 命令: FLUSHALL") {
+		t.Fatalf("FLUSHALL 的错误为 %v，期望被拒绝", err)
+	}
+	if !mr.Exists("greeting") {
+		t.Fatal("被拒绝的 FLUSHALL 仍被执行")
+	}
+
+	// 允许列表中的命令也不能访问调度器自身使用的键
+	if _, err := rdb.LPush(context.Background(), executionQueueKey, "pending").Result(); err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range []string{"DEL " + executionQueueKey, "del greeting " + executionFiredKey + "1", `SET "happx1:ratelimit:user:1" 0`} {
+		if _, err := run(command); err == nil || !strings.Contains(err.Error(), "不允许访问调度器使用的键") {
+			t.Fatalf("%s 的错误为 %v，期望被拒绝", command, err)
+		}
+	}
+	if !mr.Exists(executionQueueKey) || !mr.Exists("greeting") {
+		t.Fatal("被拒绝的 DEL 仍被执行")
+	}
+}
+
+func TestParseRedisCommand(t *testing.T) {
+	cases := []struct {
+		line string
+		want []string
+	}{
+		{"SET key value", []string{"SET", "key", "value"}},
+		{`SET key "a b"`, []string{"SET", "key", "a b"}},
+		{`SET key 'it"s'`, []string{"SET", "key", `it"s`}},
+		{`SET key "say \"hi\""`, []string{"SET", "key", `say "hi"`}},
+		{`SET key ""`, []string{"SET", "key", ""}},
+		{"  EXPIRE\tkey  60 ", []string{"EXPIRE", "key", "60"}},
+	}
+	for _, c := range cases {
+		args, err := ParseRedisCommand(c.line)
+		if err != nil || !reflect.DeepEqual(args, c.want) {
+			t.Errorf("ParseRedisCommand(%q) = %q, %v，期望 %q", c.line, args, err, c.want)
+		}
+	}
+	for _, line := range []string{"", "   ", `SET key "unterminated`} {
+		if _, err := ParseRedisCommand(line); err == nil {
+			t.Errorf("ParseRedisCommand(%q) 应报错", line)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+//go:build linux
+
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"happx1/internal/model"
+)
+
+// defaultCgroupParent 未配置时存放 shell 任务 cgroup 的目录
+const defaultCgroupParent = "/sys/fs/cgroup/happx1"
+
+// cpuPeriod cgroup cpu.max 的调度周期（微秒）
+const cpuPeriod = 100000
+
+// cgroupParent 返回存放 shell 任务 cgroup 的目录
+func (s *Scheduler) cgroupParent() string {
+	if s.config.CgroupParent != "" {
+		return s.config.CgroupParent
+	}
+	return defaultCgroupParent
+}
+
+// initCgroups 检测 cgroup v2 并为父目录开启 memory 和 cpu 控制器
+// 不可用时内存限制退化为 rlimit，CPU 配额不可用
+func (s *Scheduler) initCgroups() {
+	parent := s.cgroupParent()
+	err := func() error {
+		if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+			return fmt.Errorf("未检测到 cgroup v2")
+		}
+		if err := os.MkdirAll(parent, 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+memory +cpu"), 0644)
+	}()
+	if err != nil {
+		slog.Info("cgroup v2 不可用，shell 任务内存限制使用 rlimit，不支持 CPU 配额", "cgroup_parent", parent, "error", err)
+		return
+	}
+	s.cgroupV2 = true
+}
+
+// CheckResourceLimits 校验当前环境是否支持任务的资源限制
+func (s *Scheduler) CheckResourceLimits(task *model.Task) error {
+	if task.CPUQuota > 0 && !s.cgroupV2 {
+		return fmt.Errorf("当前环境不支持 CPU 配额，需要 cgroup v2")
+	}
+	return nil
+}
+
+// applyResourceLimits 为 shell 命令应用内存和 CPU 限制，需在命令启动前调用
+// 返回的 release 在命令结束后调用，清理 cgroup，进程因超出内存限制被终止时返回错误
+func (s *Scheduler) applyResourceLimits(cmd *exec.Cmd, task *model.Task) (release func() error, err error) {
+	noop := func() error { return nil }
+	if task.MemoryLimitMB <= 0 && task.CPUQuota <= 0 {
+		return noop, nil
+	}
+	if err := s.CheckResourceLimits(task); err != nil {
+		return nil, err
+	}
+
+	if !s.cgroupV2 {
+		// 退化为 rlimit：限制虚拟内存，超出时内存分配失败，进程随之退出
+		script := cmd.Args[len(cmd.Args)-1]
+		cmd.Args[len(cmd.Args)-1] = fmt.Sprintf("ulimit -v %d || exit 125\n%s", task.MemoryLimitMB*1024, script)
+		return noop, nil
+	}
+
+	dir, err := os.MkdirTemp(s.cgroupParent(), "task-")
+	if err != nil {
+		return nil, fmt.Errorf("创建 cgroup 失败: %v", err)
+	}
+	cleanup := func() {
+		// 命令退出后可能仍有后台子进程，先全部终止再删除 cgroup
+		os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0644)
+		os.Remove(dir)
+	}
+
+	if task.MemoryLimitMB > 0 {
+		limit := strconv.Itoa(task.MemoryLimitMB * 1024 * 1024)
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(limit), 0644); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("设置内存限制失败: %v", err)
+		}
+		// 不允许使用 swap 绕过内存限制，未开启 swap 记账时没有该文件
+		os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0644)
+	}
+	if task.CPUQuota > 0 {
+		quota := fmt.Sprintf("%d %d", task.CPUQuota*cpuPeriod/100, cpuPeriod)
+		if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(quota), 0644); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("设置 CPU 配额失败: %v", err)
+		}
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("打开 cgroup 失败: %v", err)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{UseCgroupFD: true, CgroupFD: int(f.Fd())}
+
+	return func() error {
+		defer cleanup()
+		f.Close()
+		if cgroupOOMKilled(dir) {
+			return fmt.Errorf("超出内存限制 %dMB，进程已被终止", task.MemoryLimitMB)
+		}
+		return nil
+	}, nil
+}
+
+// cgroupOOMKilled 判断 cgroup 内是否有进程因超出内存限制被终止
+func cgroupOOMKilled(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "oom_kill" {
+			return fields[1] != "0"
+		}
+	}
+	return false
+}
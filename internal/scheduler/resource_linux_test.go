@@ -0,0 +1,52 @@
+//go:build linux
+
+package scheduler
+
+import (
+	"testing"
+
+	"happx1/internal/model"
+)
+
+// memoryHog 读入 256MB 不含换行的数据，tail 需要在内存中保存整行
+const memoryHog = "head -c 268435456 /dev/zero | tail -n 1 > /dev/null"
+
+func TestMemoryLimitTerminatesCommand(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	cases := []struct {
+		name     string
+		cgroupV2 bool
+	}{
+		{"rlimit", false},
+		{"cgroup v2", true},
+	}
+	for _, c := range cases {
+		if c.cgroupV2 && !s.cgroupV2 {
+			t.Logf("%s: 当前环境不可用，跳过", c.name)
+			continue
+		}
+		available := s.cgroupV2
+		s.cgroupV2 = c.cgroupV2
+
+		limited := createTestTask(t, db, &model.Task{Name: c.name + "-limited", Command: memoryHog, Timeout: 30, MemoryLimitMB: 32})
+		if taskLog := s.ExecuteTask(limited); taskLog.Status != 0 {
+			t.Errorf("%s: 超出内存限制的命令执行成功", c.name)
+		}
+		small := createTestTask(t, db, &model.Task{Name: c.name + "-small", Command: "echo ok", Timeout: 30, MemoryLimitMB: 32})
+		if taskLog := s.ExecuteTask(small); taskLog.Status != 1 {
+			t.Errorf("%s: 内存限制内的命令执行失败: %s", c.name, taskLog.Error)
+		}
+		s.cgroupV2 = available
+	}
+}
+
+func TestCPUQuotaRequiresCgroupV2(t *testing.T) {
+	s := NewScheduler(nil, nil, &Config{})
+	if err := s.CheckResourceLimits(&model.Task{CPUQuota: 50}); err == nil {
+		t.Fatal("没有 cgroup v2 时 CPU 配额应报错")
+	}
+	if err := s.CheckResourceLimits(&model.Task{MemoryLimitMB: 64}); err != nil {
+		t.Fatalf("没有 cgroup v2 时内存限制使用 rlimit: %v", err)
+	}
+}
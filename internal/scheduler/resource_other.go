@@ -0,0 +1,29 @@
+//go:build !linux
+
+package scheduler
+
+import (
+	"fmt"
+	"os/exec"
+
+	"happx1/internal/model"
+)
+
+// initCgroups 非 Linux 系统不支持资源限制
+func (s *Scheduler) initCgroups() {}
+
+// CheckResourceLimits 校验当前环境是否支持任务的资源限制，非 Linux 系统不支持
+func (s *Scheduler) CheckResourceLimits(task *model.Task) error {
+	if task.MemoryLimitMB > 0 || task.CPUQuota > 0 {
+		return fmt.Errorf("当前系统不支持资源限制，仅支持 Linux")
+	}
+	return nil
+}
+
+// applyResourceLimits 非 Linux 系统不支持资源限制，设置了限制时返回错误
+func (s *Scheduler) applyResourceLimits(cmd *exec.Cmd, task *model.Task) (release func() error, err error) {
+	if err := s.CheckResourceLimits(task); err != nil {
+		return nil, err
+	}
+	return func() error { return nil }, nil
+}
@@ -0,0 +1,26 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"happx1/internal/model"
+)
+
+// newRunID 生成执行ID（UUID v4）
+func newRunID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("生成执行ID失败: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// assignRunID 任务尚无执行ID时生成
+func assignRunID(task *model.Task) {
+	if task.RunID == "" {
+		task.RunID = newRunID()
+	}
+}
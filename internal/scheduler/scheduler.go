@@ -2,35 +2,125 @@ package scheduler
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"gorm.io/gorm"
-	"log"
-	"os/exec"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/robfig/cron/v3"
 	"happx1/internal/database"
+	"happx1/internal/eventbus"
 	"happx1/internal/model"
+	"happx1/internal/notifications"
+	"happx1/internal/secrets"
 	"happx1/pkg/utils"
 )
 
 type Scheduler struct {
-	cron *cron.Cron
-	db   *gorm.DB
+	cron   *cron.Cron
+	db     *gorm.DB
+	redis  *redis.Client
+	config *Config
+	pool   *workerPool
+	timers *timerQueue
+	events *eventbus.Bus
+
+	notifier notifications.Notifier // 任务执行 panic、失败和恢复时的告警通知，可为 nil
+	secrets  secrets.Provider       // 执行时解析 ${secret.NAME} 占位符的密钥来源，可为 nil
+
+	httpBlocked    []*net.IPNet     // http、grpc 类型任务、前置检查和回调禁止访问的网段
+	shellAllowlist []*regexp.Regexp // shell 类型任务允许的命令
+	cgroupV2       bool             // 是否可用 cgroup v2 限制 shell 任务的资源
+
+	callbackTransport *http.Transport // 发送回调的连接，与 http 类型任务一样检查目标地址
+
+	location       *time.Location
+	timezoneSource string
+	cronParser     utils.CronParser // cron 表达式解析器，注册触发器和校验表达式共用
+
+	paused atomic.Bool // 暂停（维护）模式，暂停期间跳过所有执行
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+
+	mu          sync.Mutex
+	entries     map[uint]cron.EntryID // 任务ID到cron条目ID的映射
+	afterTimers map[uint]*time.Timer  // after 类型任务等待触发的定时器
+	inflight    map[string]bool       // 开启参数去重的任务正在排队或执行的去重键
+	manualRuns  map[uint]int          // 每个任务正在排队或执行的手动执行数
+	sqlDBs      map[string]*sql.DB    // sql 类型任务的连接池，按连接名缓存
+
+	// 并发上限登记，同样由 mu 保护
+	activeRuns  map[uint]int    // skip 并发策略的任务正在排队或执行的执行数
+	queuedRuns  map[uint]int    // queue 并发策略的任务已提交到 worker 池的执行数
+	pendingRuns map[uint][]*job // queue 并发策略的任务等待并发名额的执行，按提交顺序
 }
 
-func NewScheduler() *Scheduler {
-	return &Scheduler{
-		cron: cron.New(cron.WithSeconds()),
-		db:   database.DB,
+// NewScheduler 创建调度器，db 存放任务和执行日志，redis 用于缓存回调等
+func NewScheduler(db *gorm.DB, redis *redis.Client, config *Config) *Scheduler {
+	s := &Scheduler{
+		db:          db,
+		redis:       redis,
+		config:      config,
+		stopCh:      make(chan struct{}),
+		entries:     make(map[uint]cron.EntryID),
+		afterTimers: make(map[uint]*time.Timer),
+		inflight:    make(map[string]bool),
+		manualRuns:  make(map[uint]int),
+		activeRuns:  make(map[uint]int),
+		queuedRuns:  make(map[uint]int),
+		pendingRuns: make(map[uint][]*job),
+		sqlDBs:      make(map[string]*sql.DB),
 	}
+	s.cronParser = utils.NewCronParser(config.EnableSecondsField)
+	s.timers = newTimerQueue(s.fireOnce)
+	s.events = eventbus.New()
+	return s
+}
+
+// CronParser 返回调度器使用的 cron 表达式解析器，创建任务时用于校验表达式
+func (s *Scheduler) CronParser() utils.CronParser {
+	return s.cronParser
+}
+
+// UseNotifier 设置告警通知，任务执行发生 panic、失败和恢复时发送告警，需在 Start 之前调用
+func (s *Scheduler) UseNotifier(notifier notifications.Notifier) {
+	s.notifier = notifier
 }
 
 // Start 启动调度器
 func (s *Scheduler) Start() error {
-	// 自动迁移数据库表
-	if err := s.db.AutoMigrate(&model.Task{}, &model.TaskLog{}); err != nil {
-		return fmt.Errorf("数据库迁移失败: %v", err)
+	// 确定调度时区，cron 表达式按该时区解释
+	loc, source, err := resolveLocation(s.config.Timezone)
+	if err != nil {
+		return err
+	}
+	s.location, s.timezoneSource = loc, source
+	s.cron = cron.New(cron.WithParser(s.cronParser), cron.WithLocation(loc))
+	slog.Info("调度器时区", "timezone", loc.String(), "source", source)
+
+	if err := s.loadHTTPBlocked(); err != nil {
+		return err
+	}
+	s.callbackTransport = s.guardedTransport(0)
+	if err := s.loadShellAllowlist(); err != nil {
+		return err
+	}
+	s.initCgroups()
+	if err := s.loadPaused(); err != nil {
+		return err
+	}
+	if err := s.checkExecutionMode(); err != nil {
+		return err
 	}
 
 	// 加载所有启用的任务
@@ -39,29 +129,205 @@ func (s *Scheduler) Start() error {
 		return fmt.Errorf("加载任务失败: %v", err)
 	}
 
+	// 启动 worker 池
+	s.pool = newWorkerPool(s.config.WorkerCount, s.ExecuteTask, s.notifier)
+
+	// 计算停止期间错过的触发，需在注册触发器更新下次执行时间之前检查
+	now := time.Now()
+	catchUps := make(map[int][]time.Time)
+	for i := range tasks {
+		if times := s.catchUpTimes(&tasks[i], now); len(times) > 0 {
+			catchUps[i] = times
+		}
+	}
+
 	// 添加任务到调度器
-	for _, task := range tasks {
-		if err := s.AddTask(&task); err != nil {
-			log.Printf("添加任务失败 [%s]: %v", task.Name, err)
+	for i := range tasks {
+		if err := s.scheduleTask(&tasks[i]); err != nil {
+			slog.Error("添加任务失败", "task_id", tasks[i].ID, "task_name", tasks[i].Name, "error", err)
 			continue
 		}
 	}
 
+	// 注册触发器后再补执行，补执行前的检查可能自动禁用任务并移除触发器
+	for i, times := range catchUps {
+		s.wg.Add(1)
+		go func(task model.Task, times []time.Time) {
+			defer s.wg.Done()
+			s.catchUp(&task, times)
+		}(tasks[i], times)
+	}
+
 	// 启动调度器
 	s.cron.Start()
+
+	// 启动一次性任务定时队列和日志清理
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.timers.run(s.stopCh)
+	}()
+	go s.runLogCleanup()
+
+	// 启动成功率告警检查
+	if s.notifier != nil {
+		s.wg.Add(1)
+		go s.runSuccessRateCheck()
+	}
+
+	// 启动缓存回调补发
+	if s.redis != nil {
+		s.wg.Add(1)
+		go s.runCallbackFlusher()
+	}
+
+	// 队列模式下从 Redis 执行队列取出执行
+	if s.queueMode() {
+		if err := s.requeueProcessing(); err != nil {
+			return err
+		}
+		s.wg.Add(1)
+		go s.runQueueConsumer()
+	}
 	return nil
 }
 
-// Stop 停止调度器
+// ErrSchedulerStopped 调度器已停止，不再接受新的执行
+var ErrSchedulerStopped = errors.New("调度器已停止")
+
+// Stop 停止调度器，尚未开始的执行和待触发的 after 任务被丢弃
 func (s *Scheduler) Stop() {
-	s.cron.Stop()
+	close(s.stopCh)
+	<-s.cron.Stop().Done()
+	s.stopAfterTimers()
+	for _, task := range s.dropPending() {
+		s.releaseRun(task)
+	}
+	for _, task := range s.pool.stop() {
+		s.releaseSlot(task)
+		s.releaseRun(task)
+	}
+	s.wg.Wait()
+	s.closeSQLDBs()
+}
+
+// Reload 应用可以热更新的配置：worker 数量、日志保留天数和每个任务的日志条数上限，其余配置需重启后生效
+func (s *Scheduler) Reload(config *Config) {
+	s.mu.Lock()
+	s.config.WorkerCount = config.WorkerCount
+	s.config.LogRetentionDays = config.LogRetentionDays
+	s.config.MaxLogsPerTask = config.MaxLogsPerTask
+	s.mu.Unlock()
+
+	if s.pool != nil {
+		s.pool.resize(config.WorkerCount)
+	}
+	slog.Info("调度器配置已更新", "worker_count", config.WorkerCount, "log_retention_days", config.LogRetentionDays, "max_logs_per_task", config.MaxLogsPerTask)
+}
+
+// Status 调度器运行状态
+type Status struct {
+	Timezone       string    `json:"timezone"`        // 生效的调度时区
+	TimezoneSource string    `json:"timezone_source"` // 时区来源：config-配置，os-操作系统
+	Now            time.Time `json:"now"`             // 调度时区下的当前时间
+	WorkerCount    int       `json:"worker_count"`    // worker 数量
+	ScheduledTasks int       `json:"scheduled_tasks"` // 已注册触发器的任务数
 }
 
-// AddTask 添加任务
+// Status 返回调度器运行状态
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	scheduled := len(s.entries)
+	s.mu.Unlock()
+
+	return Status{
+		Timezone:       s.location.String(),
+		TimezoneSource: s.timezoneSource,
+		Now:            time.Now().In(s.location),
+		WorkerCount:    s.pool.size(),
+		ScheduledTasks: scheduled + s.timers.len(),
+	}
+}
+
+// Submit 提交任务到 worker 池执行，提交时为本次执行生成执行ID
+func (s *Scheduler) Submit(task *model.Task) error {
+	if s.Paused() {
+		return ErrSchedulerPaused
+	}
+	assignRunID(task)
+	if err := s.acquireRun(task); err != nil {
+		return err
+	}
+	if _, ok := s.dispatch(task, nil); !ok {
+		s.releaseRun(task)
+		return ErrSchedulerStopped
+	}
+	return nil
+}
+
+// submitScheduled 提交定时触发的执行：队列模式下写入 Redis 执行队列，由 worker 取出时再检查并发和去重限制，否则直接提交
+func (s *Scheduler) submitScheduled(task *model.Task) error {
+	if !s.queueMode() {
+		return s.Submit(task)
+	}
+	if s.Paused() {
+		return ErrSchedulerPaused
+	}
+	assignRunID(task)
+	return s.enqueue(task)
+}
+
+// SubmitAndWait 提交任务到 worker 池并等待执行结束，返回执行日志
+// ctx 结束时停止等待并返回 ctx 的错误，任务仍在后台继续执行
+func (s *Scheduler) SubmitAndWait(ctx context.Context, task *model.Task) (*model.TaskLog, error) {
+	if s.Paused() {
+		return nil, ErrSchedulerPaused
+	}
+	assignRunID(task)
+	if err := s.acquireRun(task); err != nil {
+		return nil, err
+	}
+
+	done := make(chan *model.TaskLog, 1)
+	if _, ok := s.dispatch(task, done); !ok {
+		s.releaseRun(task)
+		return nil, ErrSchedulerStopped
+	}
+	select {
+	case taskLog, executed := <-done:
+		// 开始执行前调度器停止时 done 被关闭
+		if !executed {
+			return nil, ErrSchedulerStopped
+		}
+		// 排队期间调度器被暂停时没有执行
+		if taskLog == nil {
+			return nil, ErrSchedulerPaused
+		}
+		return taskLog, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// AddTask 添加任务，事务提交后注册触发器
 func (s *Scheduler) AddTask(task *model.Task) error {
+	if err := database.WithDeadlockRetry(func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			return s.AddTaskTx(tx, task)
+		})
+	}); err != nil {
+		return err
+	}
+	s.ApplySchedule(task)
+	return nil
+}
+
+// AddTaskTx 在事务 tx 中保存任务和下次执行时间，不注册触发器
+// 事务提交后由调用方调用 ApplySchedule 注册，事务回滚时调度器不受影响
+func (s *Scheduler) AddTaskTx(tx *gorm.DB, task *model.Task) error {
 	// 检查任务是否已存在
 	var count int64
-	if err := s.db.Model(&model.Task{}).Where("name = ?", task.Name).Count(&count).Error; err != nil {
+	if err := tx.Model(&model.Task{}).Where("name = ?", task.Name).Count(&count).Error; err != nil {
 		return err
 	}
 	if count > 0 {
@@ -69,61 +335,414 @@ func (s *Scheduler) AddTask(task *model.Task) error {
 	}
 
 	// 保存任务到数据库
-	if err := s.db.Create(task).Error; err != nil {
+	if err := tx.Create(task).Error; err != nil {
+		return err
+	}
+	return s.RescheduleTaskTx(tx, task)
+}
+
+// RescheduleTask 按任务当前配置更新下次执行时间并重新注册触发器，禁用的任务只移除触发器
+func (s *Scheduler) RescheduleTask(task *model.Task) error {
+	if err := database.WithDeadlockRetry(func() error {
+		return s.RescheduleTaskTx(s.db, task)
+	}); err != nil {
+		return err
+	}
+	s.ApplySchedule(task)
+	return nil
+}
+
+// RescheduleTaskTx 在事务 tx 中按任务当前配置更新下次执行时间，不修改触发器，禁用的任务不更新
+// 事务提交后由调用方调用 ApplySchedule，避免提交失败或回滚后内存中的触发器与数据库不一致
+func (s *Scheduler) RescheduleTaskTx(tx *gorm.DB, task *model.Task) error {
+	if task.Status != 1 || task.Type == model.TaskTypeAfter {
+		return nil
+	}
+	next, err := s.nextFireTime(task)
+	if err != nil {
 		return err
 	}
+	return saveNextRunTime(tx, task, next)
+}
+
+// ApplySchedule 按任务当前配置更新内存中的触发器：移除旧的触发器，启用的任务重新注册
+// 在保存任务的事务提交之后调用
+func (s *Scheduler) ApplySchedule(task *model.Task) {
+	s.RemoveTask(task.ID)
+	if task.Status != 1 {
+		return
+	}
+	if err := s.registerTask(task); err != nil {
+		slog.Error("注册任务触发器失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+	}
+}
 
-	// 添加到调度器
-	_, err := s.cron.AddFunc(task.Spec, func() {
-		go func() {
-			defer utils.Recover(fmt.Sprintf("Task-%d", task.ID), context.Background())
-			s.ExecuteTask(task)
-		}()
+// RemoveTask 移除任务的触发器
+func (s *Scheduler) RemoveTask(taskID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entryID, ok := s.entries[taskID]; ok {
+		s.cron.Remove(entryID)
+		delete(s.entries, taskID)
+	}
+	if timer, ok := s.afterTimers[taskID]; ok {
+		timer.Stop()
+		delete(s.afterTimers, taskID)
+	}
+	// 取消尚未触发的一次性任务，避免删除或修改后仍按旧配置执行
+	s.timers.remove(taskID)
+}
+
+// scheduleTask 注册任务的触发器并保存下次执行时间，用于启动时加载任务
+func (s *Scheduler) scheduleTask(task *model.Task) error {
+	if err := s.registerTask(task); err != nil {
+		return err
+	}
+	if task.Type == model.TaskTypeAfter {
+		return nil
+	}
+	next := s.nextRunTime(task.ID)
+	if task.Type == model.TaskTypeOnce {
+		next, _ = ParseOnceSpec(task.Spec)
+	}
+	return database.WithDeadlockRetry(func() error {
+		return saveNextRunTime(s.db, task, next)
 	})
+}
+
+// registerTask 注册任务的 cron 触发器，每次触发时将任务快照提交到 worker 池
+// 一次性任务加入定时队列；after 类型的任务由触发源任务完成时安排，无需注册
+func (s *Scheduler) registerTask(task *model.Task) error {
+	switch task.Type {
+	case model.TaskTypeAfter:
+		return nil
+	case model.TaskTypeOnce:
+		at, err := ParseOnceSpec(task.Spec)
+		if err != nil {
+			return err
+		}
+		snapshot := *task
+		s.timers.add(at, &snapshot)
+		return nil
+	}
+
+	entryID, err := s.cron.AddJob(task.Spec, &cronJob{scheduler: s, task: *task})
 	if err != nil {
 		return err
 	}
 
+	s.mu.Lock()
+	if old, ok := s.entries[task.ID]; ok {
+		s.cron.Remove(old)
+	}
+	s.entries[task.ID] = entryID
+	s.mu.Unlock()
+	return nil
+}
+
+// nextFireTime 按任务配置计算下次执行时间：一次性任务为执行时间，cron 任务为表达式从当前时间起的下次触发时间
+func (s *Scheduler) nextFireTime(task *model.Task) (time.Time, error) {
+	if task.Type == model.TaskTypeOnce {
+		return ParseOnceSpec(task.Spec)
+	}
+	schedule, err := s.cronParser.Parse(task.Spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(time.Now().In(s.location)), nil
+}
+
+// firedAt 返回任务 cron 条目本次触发的计划时间，条目已被替换（如触发后任务被重新注册）时使用取整到秒的当前时间
+func (s *Scheduler) firedAt(taskID uint) time.Time {
+	s.mu.Lock()
+	entryID, ok := s.entries[taskID]
+	s.mu.Unlock()
+	if ok {
+		if prev := s.cron.Entry(entryID).Prev; !prev.IsZero() {
+			return prev
+		}
+	}
+	return time.Now().In(s.location).Truncate(time.Second)
+}
+
+// nextRunTime 返回任务 cron 条目的下次触发时间，未注册时返回零值
+func (s *Scheduler) nextRunTime(taskID uint) time.Time {
+	s.mu.Lock()
+	entryID, ok := s.entries[taskID]
+	s.mu.Unlock()
+	if !ok {
+		return time.Time{}
+	}
+
+	entry := s.cron.Entry(entryID)
+	if !entry.Valid() {
+		return time.Time{}
+	}
+	if !entry.Next.IsZero() {
+		return entry.Next
+	}
+	// 调度器尚未启动时条目还没有计算下次触发时间
+	return entry.Schedule.Next(time.Now().In(s.location))
+}
+
+// saveNextRunTime 更新任务的下次执行时间，db 可以是事务，不在这里重试死锁：事务中的死锁会回滚整个事务，需由调用方重试整个事务
+func saveNextRunTime(db *gorm.DB, task *model.Task, next time.Time) error {
+	task.NextRunTime = next
+	return db.Model(task).UpdateColumn("next_run_time", next).Error
+}
+
+// recordFire 在检查执行条件之前保存 cron 任务本次触发之后的下次执行时间
+// 因执行条件不满足（活动时间、熔断、暂停等）而跳过的触发不会执行，执行后也就不会更新下次执行时间，
+// 不在触发时保存的话重启后会被当作服务停止期间错过的触发补执行
+func (s *Scheduler) recordFire(task *model.Task) {
+	schedule, err := s.cronParser.Parse(task.Spec)
+	if err != nil {
+		slog.Error("计算下次执行时间失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+		return
+	}
+	next := schedule.Next(task.ScheduledAt.In(s.location))
+	if err := database.WithDeadlockRetry(func() error {
+		return saveNextRunTime(s.db, task, next)
+	}); err != nil {
+		slog.Error("更新下次执行时间失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+	}
+}
+
+// trigger 处理定时触发，等待随机延迟后检查前置条件，满足时提交执行
+// 队列模式下同一次定时触发只由一个实例处理，在随机延迟之前去重
+func (s *Scheduler) trigger(task *model.Task) {
+	if s.queueMode() {
+		claimed, err := s.claimFire(task)
+		if err != nil {
+			slog.Error("跳过任务", "task_id", task.ID, "task_name", task.Name, "error", err)
+			return
+		}
+		if !claimed {
+			return
+		}
+	}
+	if !s.waitJitter(task) {
+		return
+	}
+	if !s.admit(task, time.Now()) {
+		return
+	}
+	if err := s.submitScheduled(task); err != nil {
+		slog.Info("跳过任务", "task_id", task.ID, "task_name", task.Name, "reason", err)
+	}
+}
+
+// admit 检查定时触发和补执行的执行条件：生效时间、活动时间、熔断、执行次数、依赖和前置条件，不满足时记录原因并返回 false
+// 已过失效时间或达到最多执行次数时自动禁用任务
+func (s *Scheduler) admit(task *model.Task, now time.Time) bool {
+	if task.ValidUntil != nil && now.After(*task.ValidUntil) {
+		s.disableTask(task, "已过失效时间")
+		return false
+	}
+	if task.ValidFrom != nil && now.Before(*task.ValidFrom) {
+		slog.Info("跳过任务", "task_id", task.ID, "task_name", task.Name, "reason", "未到生效时间")
+		return false
+	}
+	if !s.inActiveHours(task, now) {
+		slog.Info("跳过任务", "task_id", task.ID, "task_name", task.Name, "reason", "不在活动时间内", "active_hours", task.ActiveHours, "active_days", task.ActiveDays)
+		return false
+	}
+	if until := s.suspendedUntil(task); now.Before(until) {
+		slog.Info("跳过任务", "task_id", task.ID, "task_name", task.Name, "reason", "连续失败已熔断", "suspended_until", until)
+		return false
+	}
+	if s.runLimitReached(task) {
+		s.disableTask(task, "已达到最多执行次数")
+		return false
+	}
+	if err := s.checkDependency(task); err != nil {
+		slog.Info("跳过任务", "task_id", task.ID, "task_name", task.Name, "reason", err)
+		return false
+	}
+	if err := s.checkPrecondition(task); err != nil {
+		slog.Info("跳过任务", "task_id", task.ID, "task_name", task.Name, "reason", "前置条件不满足", "error", err)
+		return false
+	}
+	return true
+}
+
+// checkDependency 检查依赖任务最近一次执行是否成功且在有效期内
+func (s *Scheduler) checkDependency(task *model.Task) error {
+	if task.DependsOn == nil {
+		return nil
+	}
+
+	var latest model.TaskLog
+	err := s.db.Where("task_id = ? AND retried = ?", *task.DependsOn, false).Order("start_time desc").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("依赖任务 %d 尚未执行", *task.DependsOn)
+	}
+	if err != nil {
+		return fmt.Errorf("查询依赖任务日志失败: %v", err)
+	}
+
+	if latest.Status != 1 {
+		return fmt.Errorf("依赖任务 %d 最近一次执行失败", *task.DependsOn)
+	}
+	if task.DependencyWindow > 0 && time.Since(latest.StartTime) > time.Duration(task.DependencyWindow)*time.Second {
+		return fmt.Errorf("依赖任务 %d 的成功结果已过期", *task.DependsOn)
+	}
 	return nil
 }
 
-// ExecuteTask 执行任务
-func (s *Scheduler) ExecuteTask(task *model.Task) {
-	// 创建任务日志
-	taskLog := &model.TaskLog{
-		TaskID:    task.ID,
-		StartTime: time.Now(),
-		Status:    0,
+// ExecuteTask 执行任务，每次尝试保存一条日志，同一次执行的日志使用相同的执行ID，返回最终结果的日志
+// 调度器处于暂停模式时不执行，返回nil
+func (s *Scheduler) ExecuteTask(task *model.Task) *model.TaskLog {
+	defer s.releaseSlot(task)
+	defer s.releaseRun(task)
+
+	// 提交后、开始执行前调度器被暂停时跳过，返回nil
+	if s.Paused() {
+		slog.Info("跳过任务", "task_id", task.ID, "task_name", task.Name, "run_id", task.RunID, "reason", ErrSchedulerPaused)
+		return nil
 	}
 
-	// 执行命令
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(task.Timeout)*time.Second)
-	defer cancel()
+	assignRunID(task)
+	runID, startTime := task.RunID, time.Now()
+	_, span := startExecutionSpan(task)
+	s.events.Publish(eventbus.TaskStarted{TaskEvent: taskEvent(task, startTime)})
 
-	cmd := exec.CommandContext(ctx, "sh", "-c", task.Command)
-	output, err := cmd.CombinedOutput()
+	var (
+		taskLog *model.TaskLog
+		err     error
+	)
+	if task.DryRun {
+		// 演练模式下只记录将要执行的命令
+		taskLog = newAttemptLog(task, runID, 1)
+		taskLog.DryRun = true
+		slog.Info("演练执行任务", "task_id", task.ID, "task_name", task.Name, "run_id", runID, "exec", describeExecution(task))
+		s.completeAttempt(task, taskLog, []byte("[dry-run] "+describeExecution(task)), nil)
+	} else {
+		// RetryTimes 为 0 时只执行一次，失败立即结束
+		retryTimes := task.GetRetryTimes()
+		for attempt := 1; ; attempt++ {
+			taskLog = newAttemptLog(task, runID, attempt)
+			var output []byte
+			output, taskLog.ResultValue, err = s.execute(task, attempt)
+			s.completeAttempt(task, taskLog, output, err)
+			// 已过截止时间时重试必然被立即终止，不再重试
+			if err == nil || attempt > retryTimes || deadlinePassed(task) {
+				break
+			}
 
-	// 更新任务日志
+			taskLog.Retried = true
+			s.saveLog(taskLog)
+			s.events.Publish(eventbus.TaskRetried{
+				TaskEvent: taskEvent(task, taskLog.EndTime),
+				Attempt:   attempt,
+				Error:     taskLog.Error,
+			})
+			time.Sleep(time.Duration(task.RetryDelay) * time.Second)
+		}
+	}
+
+	endExecutionSpan(span, taskLog, err)
+	s.saveResult(taskLog)
+	s.publishFinished(task, taskLog)
+	s.checkBreaker(task, taskLog)
+	s.checkFailureAlert(task, taskLog)
+	if s.runLimitReached(task) {
+		s.disableTask(task, "已达到最多执行次数")
+	}
+	slog.Info("任务执行完成",
+		"task_id", task.ID,
+		"task_name", task.Name,
+		"run_id", runID,
+		"status", taskLog.Status,
+		"duration", taskLog.Duration,
+		"attempts", taskLog.Attempt,
+	)
+
+	// 更新任务状态，只写运行时间，避免用执行开始时的任务快照覆盖执行期间对任务的修改
+	task.LastRunTime = startTime
+	task.NextRunTime = s.nextRunTime(task.ID)
+	if err := database.WithDeadlockRetry(func() error {
+		return s.db.Model(&model.Task{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+			"last_run_time": task.LastRunTime,
+			"next_run_time": task.NextRunTime,
+		}).Error
+	}); err != nil {
+		slog.Error("更新任务状态失败", "task_id", task.ID, "task_name", task.Name, "run_id", runID, "error", err)
+	}
+
+	// 发送回调
+	s.sendCallback(task, taskLog)
+
+	// 安排在本任务完成后触发的任务
+	s.scheduleAfterTasks(task, taskLog.EndTime)
+	s.triggerFollowUp(task, taskLog)
+	return taskLog
+}
+
+// Events 返回执行生命周期事件总线，可订阅任务开始、成功、失败和重试事件
+func (s *Scheduler) Events() *eventbus.Bus {
+	return s.events
+}
+
+// taskEvent 构造执行事件的公共字段
+func taskEvent(task *model.Task, at time.Time) eventbus.TaskEvent {
+	return eventbus.TaskEvent{TaskID: task.ID, TaskName: task.Name, RunID: task.RunID, Time: at}
+}
+
+// publishFinished 发布执行结束事件
+func (s *Scheduler) publishFinished(task *model.Task, taskLog *model.TaskLog) {
+	if taskLog.Status == 1 {
+		s.events.Publish(eventbus.TaskSucceeded{
+			TaskEvent: taskEvent(task, taskLog.EndTime),
+			Duration:  taskLog.Duration,
+			Attempts:  taskLog.Attempt,
+		})
+		return
+	}
+	s.events.Publish(eventbus.TaskFailed{
+		TaskEvent: taskEvent(task, taskLog.EndTime),
+		Duration:  taskLog.Duration,
+		Attempts:  taskLog.Attempt,
+		Error:     taskLog.Error,
+	})
+}
+
+// newAttemptLog 创建一次尝试的日志
+func newAttemptLog(task *model.Task, runID string, attempt int) *model.TaskLog {
+	return &model.TaskLog{
+		TaskID:     task.ID,
+		RunID:      runID,
+		Attempt:    attempt,
+		RetryCount: attempt - 1,
+		StartTime:  time.Now(),
+		CatchUp:    task.CatchUp,
+	}
+}
+
+// completeAttempt 记录一次尝试的结果
+func (s *Scheduler) completeAttempt(task *model.Task, taskLog *model.TaskLog, output []byte, err error) {
 	taskLog.EndTime = time.Now()
 	taskLog.Duration = int(taskLog.EndTime.Sub(taskLog.StartTime).Seconds())
-	taskLog.Output = string(output)
+	s.setOutput(taskLog, output)
+	taskLog.ExitCode = exitCode(err)
 
 	if err != nil {
 		taskLog.Status = 0
-		taskLog.Error = err.Error()
-	} else {
-		taskLog.Status = 1
+		taskLog.Error = strings.ToValidUTF8(err.Error(), "\uFFFD")
+		return
 	}
-
-	// 保存日志
-	if err := s.db.Create(taskLog).Error; err != nil {
-		log.Printf("保存任务日志失败: %v", err)
+	taskLog.Status = 1
+	// 静默成功：成功时只记录状态和耗时，不保存输出
+	if task.QuietSuccess || s.config.QuietSuccess {
+		taskLog.Output, taskLog.OutputEncoding = "", ""
 	}
+}
 
-	// 更新任务状态
-	task.LastRunTime = taskLog.StartTime
-	task.NextRunTime = s.cron.Entry(cron.EntryID(task.ID)).Next
-	if err := s.db.Save(task).Error; err != nil {
-		log.Printf("更新任务状态失败: %v", err)
+// saveLog 保存任务日志
+func (s *Scheduler) saveLog(taskLog *model.TaskLog) {
+	if err := database.WithDeadlockRetry(func() error {
+		return s.db.Create(taskLog).Error
+	}); err != nil {
+		slog.Error("保存任务日志失败", "task_id", taskLog.TaskID, "run_id", taskLog.RunID, "attempt", taskLog.Attempt, "error", err)
 	}
 }
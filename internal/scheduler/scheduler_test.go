@@ -0,0 +1,437 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"happx1/internal/database"
+	"happx1/internal/eventbus"
+	"happx1/internal/model"
+)
+
+// newTestDB 创建测试用的 SQLite 数据库并迁移数据表
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn, err := database.DSN(&database.MySQLConfig{Driver: database.DriverSQLite, Database: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := model.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// startTestScheduler 启动测试用的调度器，测试结束时停止
+func startTestScheduler(t *testing.T, db *gorm.DB, rdb *redis.Client, config *Config) *Scheduler {
+	t.Helper()
+	s := NewScheduler(db, rdb, config)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+// createTestTask 保存测试任务，未设置的字段使用不重试、5秒超时的 shell 任务
+func createTestTask(t *testing.T, db *gorm.DB, task *model.Task) *model.Task {
+	t.Helper()
+	if task.Name == "" {
+		task.Name = t.Name()
+	}
+	if task.Spec == "" {
+		task.Spec = "0 0 1 1 *"
+	}
+	if task.Timeout == 0 {
+		task.Timeout = 5
+	}
+	if task.RetryTimes == nil {
+		zero := 0
+		task.RetryTimes = &zero
+	}
+	if err := db.Create(task).Error; err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// waitFor 等待条件成立，超时后测试失败
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时: %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// countLogs 返回任务最终结果的日志数
+func countLogs(t *testing.T, db *gorm.DB, taskID uint) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&model.TaskLog{}).Where("task_id = ? AND retried = ?", taskID, false).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestDryRunRecordsWithoutExecuting(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	marker := filepath.Join(t.TempDir(), "marker")
+	task := createTestTask(t, db, &model.Task{Command: "touch " + marker, DryRun: true})
+
+	taskLog := s.ExecuteTask(task)
+	if taskLog == nil || taskLog.Status != 1 || !taskLog.DryRun {
+		t.Fatalf("演练执行的日志为 %+v，期望成功且标记 dry_run", taskLog)
+	}
+	if want := "[dry-run] sh -c \"touch " + marker + "\""; taskLog.Output != want {
+		t.Fatalf("演练输出 %q，期望 %q", taskLog.Output, want)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatal("演练执行实际运行了命令")
+	}
+
+	// 演练执行保存日志，但不计入执行统计
+	if n := countLogs(t, db, task.ID); n != 1 {
+		t.Fatalf("保存了 %d 条日志，期望1条", n)
+	}
+	var stats model.TaskStats
+	db.Where("task_id = ?", task.ID).Limit(1).Find(&stats)
+	if stats.TotalRuns != 0 {
+		t.Fatalf("演练执行计入了 %d 次执行统计", stats.TotalRuns)
+	}
+}
+
+func TestDryRunSkipsHTTPRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("演练执行发送了 HTTP 请求")
+	}))
+	defer srv.Close()
+
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{HTTPBlockedCIDRs: []string{allowLoopback}})
+	task := createTestTask(t, db, &model.Task{ExecType: model.ExecTypeHTTP, Method: http.MethodPost, Command: srv.URL, DryRun: true})
+
+	taskLog := s.ExecuteTask(task)
+	if taskLog == nil || taskLog.Output != "[dry-run] http POST "+srv.URL {
+		t.Fatalf("演练执行的日志为 %+v", taskLog)
+	}
+}
+
+func TestTriggerGatedByDependency(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	upstream := createTestTask(t, db, &model.Task{Name: "upstream", Command: "echo a"})
+	downstream := createTestTask(t, db, &model.Task{Name: "downstream", Command: "echo b", DependsOn: &upstream.ID, DependencyWindow: 600})
+
+	// 依赖的任务尚未执行
+	if err := s.checkDependency(downstream); err == nil {
+		t.Fatal("依赖的任务尚未执行时应跳过")
+	}
+
+	// 最近一次执行失败
+	seedLog := func(status int, startTime time.Time) {
+		t.Helper()
+		if err := db.Create(&model.TaskLog{TaskID: upstream.ID, Status: status, StartTime: startTime, Attempt: 1}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	seedLog(1, time.Now().Add(-2*time.Minute))
+	seedLog(0, time.Now().Add(-time.Minute))
+	s.trigger(downstream)
+	if err := s.checkDependency(downstream); err == nil {
+		t.Fatal("依赖的任务最近一次执行失败时应跳过")
+	}
+
+	// 最近一次执行成功
+	seedLog(1, time.Now())
+	s.trigger(downstream)
+	waitFor(t, 5*time.Second, "依赖满足后执行", func() bool { return countLogs(t, db, downstream.ID) == 1 })
+}
+
+func TestDependencyWindow(t *testing.T) {
+	db := newTestDB(t)
+	s := NewScheduler(db, nil, &Config{})
+	upstream := createTestTask(t, db, &model.Task{Name: "upstream", Command: "echo a"})
+	if err := db.Create(&model.TaskLog{TaskID: upstream.ID, Status: 1, StartTime: time.Now().Add(-10 * time.Minute), Attempt: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := &model.Task{DependsOn: &upstream.ID, DependencyWindow: 3600}
+	if err := s.checkDependency(fresh); err != nil {
+		t.Fatalf("有效期内的成功结果应满足依赖: %v", err)
+	}
+	stale := &model.Task{DependsOn: &upstream.ID, DependencyWindow: 60}
+	if err := s.checkDependency(stale); err == nil {
+		t.Fatal("超过有效期的成功结果不应满足依赖")
+	}
+	unlimited := &model.Task{DependsOn: &upstream.ID}
+	if err := s.checkDependency(unlimited); err != nil {
+		t.Fatalf("未设置有效期时任何时间的成功结果都满足依赖: %v", err)
+	}
+}
+
+func TestRetryTimes(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	retries := func(n int) *int { return &n }
+
+	cases := []struct {
+		name       string
+		retryTimes *int
+		attempts   int
+	}{
+		{"显式0只执行一次", retries(0), 1},
+		{"重试2次", retries(2), 3},
+	}
+	for _, c := range cases {
+		task := createTestTask(t, db, &model.Task{Name: c.name, Command: "exit 1", RetryTimes: c.retryTimes})
+		// 保存时 RetryDelay 的零值被替换为数据库默认值，执行时不等待
+		task.RetryDelay = 0
+		taskLog := s.ExecuteTask(task)
+		if taskLog.Status != 0 || taskLog.Attempt != c.attempts || taskLog.Retried {
+			t.Errorf("%s: 最终结果 status=%d attempt=%d retried=%v，期望第%d次尝试失败", c.name, taskLog.Status, taskLog.Attempt, taskLog.Retried, c.attempts)
+		}
+		var total int64
+		db.Model(&model.TaskLog{}).Where("task_id = ?", task.ID).Count(&total)
+		if total != int64(c.attempts) {
+			t.Errorf("%s: 保存了 %d 次尝试，期望 %d 次", c.name, total, c.attempts)
+		}
+	}
+
+	// 成功后不再重试
+	task := createTestTask(t, db, &model.Task{Name: "succeeds", Command: "true", RetryTimes: retries(3)})
+	if taskLog := s.ExecuteTask(task); taskLog.Status != 1 || taskLog.Attempt != 1 {
+		t.Errorf("成功的执行 status=%d attempt=%d，期望第1次尝试成功", taskLog.Status, taskLog.Attempt)
+	}
+
+	// 未设置时使用默认重试次数
+	if n := (&model.Task{}).GetRetryTimes(); n != model.DefaultRetryTimes {
+		t.Errorf("未设置时重试 %d 次，期望 %d 次", n, model.DefaultRetryTimes)
+	}
+}
+
+func TestQuietSuccess(t *testing.T) {
+	db := newTestDB(t)
+	cases := []struct {
+		name       string
+		config     bool
+		task       bool
+		command    string
+		wantOutput string
+	}{
+		{"任务开启时成功不保存输出", false, true, "echo hello", ""},
+		{"全局开启时成功不保存输出", true, false, "echo hello", ""},
+		{"失败时保存完整输出", true, true, "echo broken; exit 1", "broken\n"},
+		{"未开启时保存输出", false, false, "echo hello", "hello\n"},
+	}
+	for _, c := range cases {
+		s := startTestScheduler(t, db, nil, &Config{QuietSuccess: c.config})
+		task := createTestTask(t, db, &model.Task{Name: c.name, Command: c.command, QuietSuccess: c.task})
+		task.RetryDelay = 0
+		s.ExecuteTask(task)
+
+		var saved model.TaskLog
+		if err := db.Where("task_id = ?", task.ID).First(&saved).Error; err != nil {
+			t.Fatal(err)
+		}
+		if saved.Output != c.wantOutput {
+			t.Errorf("%s: 保存的输出 %q，期望 %q", c.name, saved.Output, c.wantOutput)
+		}
+		if saved.EndTime.IsZero() {
+			t.Errorf("%s: 未记录结束时间", c.name)
+		}
+	}
+}
+
+func TestNextRunTimeSetOnAdd(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	now := time.Now()
+	parser := s.CronParser()
+
+	// 不同的 cron 表达式使用各自触发器的下次执行时间
+	var tasks []*model.Task
+	for _, spec := range []string{"*/5 * * * *", "0 0 1 1 *"} {
+		task := &model.Task{Name: spec, Spec: spec, Command: "true", Timeout: 5, Status: 1}
+		if err := s.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+		schedule, err := parser.Parse(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := schedule.Next(now)
+		var saved model.Task
+		if err := db.First(&saved, task.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if saved.NextRunTime.IsZero() || !saved.NextRunTime.Equal(task.NextRunTime) || saved.NextRunTime.Sub(want).Abs() > time.Minute {
+			t.Errorf("%s: 保存的下次执行时间为 %v，期望 %v", spec, saved.NextRunTime, want)
+		}
+		tasks = append(tasks, task)
+	}
+
+	// 执行后按同一个触发器更新下次执行时间
+	before := tasks[0].NextRunTime
+	s.ExecuteTask(tasks[0])
+	if !tasks[0].NextRunTime.Equal(before) {
+		t.Errorf("执行后下次执行时间为 %v，期望 %v", tasks[0].NextRunTime, before)
+	}
+}
+
+func TestExecuteTaskPublishesEvents(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	events := make(chan eventbus.Event, 10)
+	defer s.Events().Subscribe(func(e eventbus.Event) { events <- e }, 10)()
+	// 处理缓慢的订阅者不阻塞执行
+	release := make(chan struct{})
+	defer close(release)
+	s.Events().Subscribe(func(e eventbus.Event) { <-release }, 1)
+
+	retries := 1
+	task := createTestTask(t, db, &model.Task{Command: "exit 1", RetryTimes: &retries})
+	task.RetryDelay = 0
+	start := time.Now()
+	s.ExecuteTask(task)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("执行耗时 %v，被订阅者阻塞", elapsed)
+	}
+
+	want := []string{eventbus.TypeTaskStarted, eventbus.TypeTaskRetried, eventbus.TypeTaskFailed}
+	for i, typ := range want {
+		select {
+		case e := <-events:
+			if e.Type() != typ {
+				t.Fatalf("第%d个事件为 %s，期望 %s", i+1, e.Type(), typ)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("未收到第%d个事件 %s", i+1, typ)
+		}
+	}
+}
+
+func TestReloadAppliesSettings(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{WorkerCount: 2, LogRetentionDays: 30})
+
+	s.Reload(&Config{WorkerCount: 5, LogRetentionDays: 7, MaxLogsPerTask: 100, Timezone: "Asia/Tokyo"})
+	if status := s.Status(); status.WorkerCount != 5 {
+		t.Fatalf("热更新后 worker 数量为 %d，期望5", status.WorkerCount)
+	}
+	if s.config.LogRetentionDays != 7 || s.config.MaxLogsPerTask != 100 {
+		t.Fatalf("热更新后日志保留 %d 天，每个任务最多 %d 条", s.config.LogRetentionDays, s.config.MaxLogsPerTask)
+	}
+	// 需要重启才能生效的设置保持不变
+	if s.config.Timezone != "" {
+		t.Fatalf("热更新修改了时区 %q", s.config.Timezone)
+	}
+}
+
+func TestSaveResultRollsBackLogWhenStatsFail(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	task := createTestTask(t, db, &model.Task{Command: "echo rollback"})
+
+	// 写入统计时失败，同一事务中已写入的执行日志应回滚
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_stats", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Model.(*model.TaskStats); ok {
+			tx.AddError(errors.New("injected stats failure"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SubmitAndWait(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+	if n := countLogs(t, db, task.ID); n != 0 {
+		t.Fatalf("统计写入失败后仍保存了 %d 条日志", n)
+	}
+
+	db.Callback().Create().Remove("test:fail_stats")
+	if _, err := s.SubmitAndWait(context.Background(), newRun(task)); err != nil {
+		t.Fatal(err)
+	}
+	var stats model.TaskStats
+	if err := db.Where("task_id = ?", task.ID).First(&stats).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n := countLogs(t, db, task.ID); n != 1 || stats.SuccessCount != 1 {
+		t.Fatalf("日志 %d 条，成功次数 %d，期望各1", n, stats.SuccessCount)
+	}
+}
+
+func TestMaxRunsDisablesTask(t *testing.T) {
+	db := newTestDB(t)
+	task := createTestTask(t, db, &model.Task{Spec: "* * * * * *", Command: "echo tick", MaxRuns: 3})
+	s := startTestScheduler(t, db, nil, &Config{EnableSecondsField: true})
+
+	waitFor(t, 10*time.Second, "执行3次后任务被禁用", func() bool {
+		var current model.Task
+		db.First(&current, task.ID)
+		return current.Status == 0
+	})
+	waitFor(t, time.Second, "任务移出调度", func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_, scheduled := s.entries[task.ID]
+		return !scheduled
+	})
+	// 每秒触发的任务在禁用后不再执行
+	time.Sleep(1500 * time.Millisecond)
+	if n := countLogs(t, db, task.ID); n != 3 {
+		t.Fatalf("执行了 %d 次，期望3次", n)
+	}
+}
+
+func TestValidityWindow(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	now := time.Now()
+	hourAgo, inHour := now.Add(-time.Hour), now.Add(time.Hour)
+
+	inside := createTestTask(t, db, &model.Task{Name: "inside", Command: "echo inside", ValidFrom: &hourAgo, ValidUntil: &inHour})
+	early := createTestTask(t, db, &model.Task{Name: "early", Command: "echo early", ValidFrom: &inHour})
+	expired := createTestTask(t, db, &model.Task{Name: "expired", Command: "echo expired", ValidUntil: &hourAgo})
+	for _, task := range []*model.Task{inside, early, expired} {
+		s.trigger(task)
+	}
+
+	waitFor(t, 5*time.Second, "生效期内的触发执行完成", func() bool { return countLogs(t, db, inside.ID) == 1 })
+	if n := countLogs(t, db, early.ID) + countLogs(t, db, expired.ID); n != 0 {
+		t.Fatalf("生效期外的触发执行了 %d 次", n)
+	}
+	var pending, disabled model.Task
+	db.First(&pending, early.ID)
+	if pending.Status != 1 {
+		t.Fatal("未到生效时间的任务不应被禁用")
+	}
+	db.First(&disabled, expired.ID)
+	if disabled.Status != 0 {
+		t.Fatal("已过失效时间的任务应被自动禁用")
+	}
+}
@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"happx1/internal/model"
+	"happx1/internal/secrets"
+)
+
+// UseSecrets 设置密钥来源，执行时解析命令、请求头和请求体中的 ${secret.NAME} 占位符，需在 Start 之前调用
+func (s *Scheduler) UseSecrets(provider secrets.Provider) {
+	s.secrets = provider
+}
+
+// secretEnvPrefix shell 命令中密钥对应的环境变量前缀，如 ${secret.DB_PASS} 对应 HAPPX1_SECRET_DB_PASS
+const secretEnvPrefix = "HAPPX1_SECRET_"
+
+// resolveSecrets 返回解析了密钥占位符的任务副本，原任务不变，保证密钥值不会被保存
+// shell 命令中的密钥改写为环境变量引用，值通过返回的环境变量传递，不拼接进命令
+func (s *Scheduler) resolveSecrets(task *model.Task) (*model.Task, *secrets.Resolver, []string, error) {
+	resolver := secrets.NewResolver(s.secrets)
+	if s.secrets == nil {
+		return task, resolver, nil, nil
+	}
+
+	resolved := *task
+	var env []string
+	var err error
+	if execTypeOf(task) == model.ExecTypeShell {
+		resolved.Command, env, err = resolver.ResolveEnv(task.Command, secretEnvPrefix)
+	} else {
+		resolved.Command, err = resolver.Resolve(task.Command)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if resolved.Body, err = resolver.Resolve(task.Body); err != nil {
+		return nil, nil, nil, err
+	}
+	if task.Headers != nil {
+		resolved.Headers = make(model.Headers, len(task.Headers))
+		for k, v := range task.Headers {
+			if resolved.Headers[k], err = resolver.Resolve(v); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	return &resolved, resolver, env, nil
+}
+
+// redactedError 脱敏后的错误，保留原错误用于判断类型（如取得退出码）
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError 将错误信息中的密钥值脱敏
+func redactError(resolver *secrets.Resolver, err error) error {
+	if err == nil {
+		return nil
+	}
+	if msg := resolver.Redact(err.Error()); msg != err.Error() {
+		return &redactedError{msg: msg, err: err}
+	}
+	return err
+}
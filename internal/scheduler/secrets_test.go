@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"happx1/internal/model"
+	"happx1/internal/secrets"
+)
+
+// startSecretScheduler 启动从环境变量读取密钥的调度器，TOKEN 的值为 tok-42
+func startSecretScheduler(t *testing.T, config *Config) *Scheduler {
+	t.Helper()
+	t.Setenv("HAPPX1_SECRET_TOKEN", "tok-42")
+	provider, err := secrets.New(&secrets.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScheduler(newTestDB(t), nil, config)
+	s.UseSecrets(provider)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func TestShellSecretResolvedAndRedacted(t *testing.T) {
+	s := startSecretScheduler(t, &Config{})
+	marker := filepath.Join(t.TempDir(), "token")
+	task := createTestTask(t, s.db, &model.Task{Command: "echo ${secret.TOKEN} > " + marker + "; cat " + marker + "; echo failed ${secret.TOKEN} >&2; exit 1"})
+	task.RetryDelay = 0
+
+	taskLog := s.ExecuteTask(task)
+	var saved []model.TaskLog
+	if err := s.db.Where("task_id = ?", task.ID).Find(&saved).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range saved {
+		if strings.Contains(l.Output, "tok-42") || strings.Contains(l.Error, "tok-42") {
+			t.Fatalf("保存的日志包含密钥值: output=%q error=%q", l.Output, l.Error)
+		}
+	}
+	if !strings.Contains(taskLog.Output, secrets.Redacted) {
+		t.Fatalf("输出 %q 中的密钥值未脱敏", taskLog.Output)
+	}
+	// 命令执行时使用解析后的密钥值，任务本身仍保存占位符
+	if data, err := os.ReadFile(marker); err != nil || string(data) != "tok-42\n" {
+		t.Fatalf("命令收到的密钥为 %q，错误 %v", data, err)
+	}
+	var current model.Task
+	s.db.First(&current, task.ID)
+	if !strings.Contains(current.Command, "${secret.TOKEN}") {
+		t.Fatalf("保存的命令 %q 不应包含解析后的密钥", current.Command)
+	}
+}
+
+func TestShellSecretPassedThroughEnv(t *testing.T) {
+	// 从文件读取密钥，确认值由调度器通过环境变量传给命令，而不是从进程环境继承
+	path := filepath.Join(t.TempDir(), "secrets")
+	value := `a'b"; echo injected $(echo injected)`
+	if err := os.WriteFile(path, []byte("TOKEN="+value+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := secrets.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScheduler(newTestDB(t), nil, &Config{})
+	s.UseSecrets(provider)
+
+	// 值中的引号、分号和命令替换不会被 shell 解析，命令行参数中只有环境变量引用
+	marker := filepath.Join(t.TempDir(), "token")
+	args := filepath.Join(t.TempDir(), "args")
+	task := createTestTask(t, s.db, &model.Task{Command: `printf '%s' "${secret.TOKEN}" > ` + marker + `; printf '%s' '${secret.TOKEN}' > ` + args})
+	if taskLog := s.ExecuteTask(task); taskLog.Status != 1 {
+		t.Fatalf("执行失败: %s", taskLog.Error)
+	}
+	if data, err := os.ReadFile(marker); err != nil || string(data) != value {
+		t.Fatalf("命令收到的密钥为 %q，错误 %v", data, err)
+	}
+	if data, err := os.ReadFile(args); err != nil || string(data) != "${HAPPX1_SECRET_TOKEN}" {
+		t.Fatalf("命令中的密钥为 %q，错误 %v，期望环境变量引用", data, err)
+	}
+}
+
+func TestHTTPSecretResolved(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		w.Write([]byte("echo " + r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	s := startSecretScheduler(t, &Config{HTTPBlockedCIDRs: []string{allowLoopback}})
+	task := createTestTask(t, s.db, &model.Task{ExecType: model.ExecTypeHTTP, Command: srv.URL, Headers: model.Headers{"Authorization": "Bearer ${secret.TOKEN}"}})
+	taskLog := s.ExecuteTask(task)
+
+	if r := <-received; r.Header.Get("Authorization") != "Bearer tok-42" {
+		t.Fatalf("请求头为 %q，期望解析后的密钥", r.Header.Get("Authorization"))
+	}
+	if taskLog.Status != 1 || strings.Contains(taskLog.Output, "tok-42") {
+		t.Fatalf("响应中回显的密钥值未脱敏: %q", taskLog.Output)
+	}
+
+	// 密钥不存在时执行失败，不发送请求
+	missing := createTestTask(t, s.db, &model.Task{Name: "missing", ExecType: model.ExecTypeHTTP, Command: srv.URL, Body: "${secret.MISSING}", RetryTimes: new(int)})
+	if taskLog := s.ExecuteTask(missing); taskLog.Status != 0 || !strings.Contains(taskLog.Error, "密钥不存在") {
+		t.Fatalf("密钥不存在时 status=%d error=%q", taskLog.Status, taskLog.Error)
+	}
+	select {
+	case <-received:
+		t.Fatal("密钥不存在时仍发送了请求")
+	default:
+	}
+}
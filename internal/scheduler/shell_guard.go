@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// shellMetachars 配置了允许列表时命令中禁止出现的 shell 元字符：命令分隔、管道、重定向和命令替换，
+// 否则匹配允许列表的命令后面仍可以拼接任意命令，如 "/opt/jobs/run.sh; curl ... | sh"
+var shellMetachars = []string{";", "&", "|", "`", "$(", "<", ">", "\n", "\r"}
+
+// loadShellAllowlist 编译 shell 命令允许列表，每条规则需匹配整条命令
+func (s *Scheduler) loadShellAllowlist() error {
+	patterns := make([]*regexp.Regexp, 0, len(s.config.ShellAllowlist))
+	for _, expr := range s.config.ShellAllowlist {
+		pattern, err := regexp.Compile(`^(?:` + expr + `)$`)
+		if err != nil {
+			return fmt.Errorf("加载 shell_allowlist 失败，无效的正则表达式 %s: %v", expr, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	s.shellAllowlist = patterns
+	return nil
+}
+
+// CheckShell 校验 shell 命令是否允许执行：shell 类型任务被全局禁用时拒绝，
+// 配置了允许列表时命令不能包含 shell 元字符，且需完整匹配其中之一
+func (s *Scheduler) CheckShell(command string) error {
+	if s.config.DisableShellTasks {
+		return fmt.Errorf("已禁用 shell 类型任务")
+	}
+	if len(s.shellAllowlist) == 0 {
+		return nil
+	}
+	for _, meta := range shellMetachars {
+		if strings.Contains(command, meta) {
+			return fmt.Errorf("配置了允许列表时命令不能包含 shell 元字符 %q: %s", meta, command)
+		}
+	}
+	for _, pattern := range s.shellAllowlist {
+		if pattern.MatchString(command) {
+			return nil
+		}
+	}
+	return fmt.Errorf("命令不在允许列表中: %s", command)
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	"happx1/internal/model"
+)
+
+// CheckSQL 校验 sql 类型任务的连接名和语句：连接需在配置中存在，只允许单条语句且前缀在允许列表中
+func (s *Scheduler) CheckSQL(connection, query string) error {
+	if _, ok := s.config.SQLConnections[connection]; !ok {
+		return fmt.Errorf("未配置的数据库连接: %s", connection)
+	}
+
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	if query == "" {
+		return fmt.Errorf("SQL 语句不能为空")
+	}
+	if strings.Contains(query, ";") {
+		return fmt.Errorf("只允许执行单条 SQL 语句")
+	}
+
+	upper := strings.ToUpper(query)
+	for _, prefix := range s.config.SQLAllowedPrefixes {
+		if strings.HasPrefix(upper, strings.ToUpper(strings.TrimSpace(prefix))) {
+			return nil
+		}
+	}
+	return fmt.Errorf("不允许执行的 SQL 语句: %s", firstWord(query))
+}
+
+// firstWord 返回语句的第一个单词，用于错误提示
+func firstWord(query string) string {
+	if fields := strings.Fields(query); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// executeSQL 在任务指定的连接上执行 SQL 语句，输出影响的行数
+func (s *Scheduler) executeSQL(task *model.Task) ([]byte, error) {
+	// 执行前再次校验，配置可能在创建任务后修改
+	if err := s.CheckSQL(task.Target, task.Command); err != nil {
+		return nil, err
+	}
+
+	db, err := s.sqlDB(task.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := executionContext(task)
+	defer cancel()
+
+	result, err := db.ExecContext(ctx, strings.TrimSuffix(strings.TrimSpace(task.Command), ";"))
+	if err != nil {
+		return nil, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("affected rows: %d", affected)), nil
+}
+
+// sqlDB 返回连接名对应的连接池，首次使用时创建，所有任务共用
+func (s *Scheduler) sqlDB(connection string) (*sql.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if db, ok := s.sqlDBs[connection]; ok {
+		return db, nil
+	}
+
+	db, err := sql.Open("mysql", s.config.SQLConnections[connection])
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库连接失败 [%s]: %v", connection, err)
+	}
+	s.sqlDBs[connection] = db
+	return db, nil
+}
+
+// closeSQLDBs 关闭所有 sql 类型任务的连接池
+func (s *Scheduler) closeSQLDBs() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, db := range s.sqlDBs {
+		db.Close()
+		delete(s.sqlDBs, name)
+	}
+}
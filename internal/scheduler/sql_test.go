@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"happx1/internal/model"
+)
+
+// newSQLScheduler 创建 sql 连接 maint 指向测试数据库的调度器，只允许 UPDATE 和 DELETE 语句
+func newSQLScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	db := newTestDB(t)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewScheduler(db, nil, &Config{
+		SQLConnections:     map[string]string{"maint": "unused"},
+		SQLAllowedPrefixes: []string{"update", " DELETE "},
+	})
+	// 使用已打开的测试数据库代替按连接串创建的 MySQL 连接池
+	s.sqlDBs["maint"] = sqlDB
+	return s
+}
+
+func TestExecuteSQLUpdate(t *testing.T) {
+	s := newSQLScheduler(t)
+	for _, name := range []string{"a", "b", "c"} {
+		createTestTask(t, s.db, &model.Task{Name: name, Command: "true", Description: "old"})
+	}
+
+	output, err := s.executeSQL(&model.Task{ExecType: model.ExecTypeSQL, Target: "maint", Command: "UPDATE tasks SET description = 'new' WHERE name <> 'c';", Timeout: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != "affected rows: 2" {
+		t.Fatalf("输出 %q，期望 affected rows: 2", output)
+	}
+	var count int64
+	s.db.Model(&model.Task{}).Where("description = ?", "new").Count(&count)
+	if count != 2 {
+		t.Fatalf("更新了 %d 行，期望2行", count)
+	}
+}
+
+func TestCheckSQL(t *testing.T) {
+	s := newSQLScheduler(t)
+	cases := []struct {
+		name       string
+		connection string
+		query      string
+		wantErr    string
+	}{
+		{"允许的语句", "maint", "update tasks set status = 0", ""},
+		{"允许的语句带分号", "maint", "DELETE FROM task_logs;", ""},
+		{"不允许的语句", "maint", "DROP TABLE tasks", "不允许执行的 SQL 语句: DROP"},
+		{"多条语句", "maint", "UPDATE tasks SET status = 0; DROP TABLE tasks", "只允许执行单条 SQL 语句"},
+		{"空语句", "maint", " ; ", "SQL 语句不能为空"},
+		{"未配置的连接", "prod", "UPDATE tasks SET status = 0", "未配置的数据库连接"},
+	}
+	for _, c := range cases {
+		err := s.CheckSQL(c.connection, c.query)
+		if c.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: %v", c.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: 错误为 %v，期望包含 %q", c.name, err, c.wantErr)
+		}
+	}
+
+	// 执行前再次校验，被拒绝的语句不执行
+	if _, err := s.executeSQL(&model.Task{Target: "maint", Command: "DROP TABLE tasks", Timeout: 5}); err == nil {
+		t.Fatal("不允许的语句应被拒绝执行")
+	}
+	if !s.db.Migrator().HasTable(&model.Task{}) {
+		t.Fatal("被拒绝的语句仍被执行")
+	}
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"happx1/internal/database"
+	"happx1/internal/model"
+)
+
+// recordStats 在 db 上按执行的最终结果累加任务统计，演练执行不计入
+func recordStats(db *gorm.DB, taskLog *model.TaskLog) error {
+	if taskLog.DryRun {
+		return nil
+	}
+
+	stats := model.TaskStats{
+		TaskID:        taskLog.TaskID,
+		TotalRuns:     1,
+		TotalDuration: int64(taskLog.Duration),
+	}
+	updates := map[string]interface{}{
+		"total_runs":     gorm.Expr("task_stats.total_runs + 1"),
+		"total_duration": gorm.Expr("task_stats.total_duration + ?", taskLog.Duration),
+		"updated_at":     taskLog.EndTime,
+	}
+	if taskLog.Status == 1 {
+		stats.SuccessCount = 1
+		stats.LastSuccess = &taskLog.EndTime
+		updates["success_count"] = gorm.Expr("task_stats.success_count + 1")
+		updates["last_success"] = taskLog.EndTime
+		// 成功后关闭熔断
+		updates["consecutive_failures"] = 0
+		updates["breaker_trips"] = 0
+		updates["suspended_until"] = nil
+	} else {
+		stats.FailureCount = 1
+		stats.LastFailure = &taskLog.EndTime
+		stats.LastError = taskLog.Error
+		stats.ConsecutiveFailures = 1
+		updates["failure_count"] = gorm.Expr("task_stats.failure_count + 1")
+		updates["last_failure"] = taskLog.EndTime
+		updates["last_error"] = taskLog.Error
+		updates["consecutive_failures"] = gorm.Expr("task_stats.consecutive_failures + 1")
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(&stats).Error
+}
+
+// saveResult 在同一事务中保存最终结果的日志并累加统计，避免只写入其中之一
+func (s *Scheduler) saveResult(taskLog *model.TaskLog) {
+	if err := database.WithDeadlockRetry(func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(taskLog).Error; err != nil {
+				return err
+			}
+			return recordStats(tx, taskLog)
+		})
+	}); err != nil {
+		slog.Error("保存执行结果失败", "task_id", taskLog.TaskID, "run_id", taskLog.RunID, "attempt", taskLog.Attempt, "error", err)
+	}
+}
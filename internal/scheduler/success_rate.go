@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"happx1/internal/database"
+	"happx1/internal/model"
+)
+
+const (
+	defaultSuccessRateWindow   = 20
+	defaultSuccessRateInterval = 5 * time.Minute
+	// minSuccessRateRuns 计算成功率至少需要的执行次数，执行次数太少时成功率没有意义
+	minSuccessRateRuns = 5
+)
+
+// runSuccessRateCheck 定期检查设置了成功率告警阈值的任务，直到调度器停止；未配置告警通知时不启动
+func (s *Scheduler) runSuccessRateCheck() {
+	defer s.wg.Done()
+
+	interval := defaultSuccessRateInterval
+	if s.config.SuccessRateInterval > 0 {
+		interval = time.Duration(s.config.SuccessRateInterval) * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.checkSuccessRates()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// checkSuccessRates 执行一次成功率检查
+func (s *Scheduler) checkSuccessRates() {
+	var tasks []model.Task
+	if err := s.db.Where("status = ? AND success_rate_threshold > ?", 1, 0).Find(&tasks).Error; err != nil {
+		slog.Error("查询成功率告警任务失败", "error", err)
+		return
+	}
+	for i := range tasks {
+		if err := s.checkSuccessRate(&tasks[i]); err != nil {
+			slog.Error("检查任务成功率失败", "task_id", tasks[i].ID, "task_name", tasks[i].Name, "error", err)
+		}
+	}
+}
+
+// checkSuccessRate 计算任务最近执行的成功率，低于阈值时发送告警
+// 告警后记录发送时间，成功率恢复到阈值以上之前不再告警，避免每次检查都重复发送
+func (s *Scheduler) checkSuccessRate(task *model.Task) error {
+	window := s.config.SuccessRateWindow
+	if window <= 0 {
+		window = defaultSuccessRateWindow
+	}
+
+	var statuses []int
+	if err := s.db.Model(&model.TaskLog{}).
+		Where("task_id = ? AND retried = ? AND dry_run = ?", task.ID, false, false).
+		Order("start_time desc, id desc").
+		Limit(window).
+		Pluck("status", &statuses).Error; err != nil {
+		return err
+	}
+	if len(statuses) < minSuccessRateRuns {
+		return nil
+	}
+	succeeded := 0
+	for _, status := range statuses {
+		if status == 1 {
+			succeeded++
+		}
+	}
+	rate := float64(succeeded) / float64(len(statuses))
+
+	var stats model.TaskStats
+	if err := s.db.Where("task_id = ?", task.ID).Limit(1).Find(&stats).Error; err != nil {
+		return err
+	}
+	if rate >= task.SuccessRateThreshold {
+		if stats.SuccessRateAlertedAt == nil {
+			return nil
+		}
+		slog.Info("任务成功率已恢复", "task_id", task.ID, "task_name", task.Name, "success_rate", rate)
+		return s.setSuccessRateAlerted(task.ID, nil)
+	}
+	if stats.SuccessRateAlertedAt != nil {
+		return nil
+	}
+
+	title := fmt.Sprintf("[成功率过低] %s", task.Name)
+	content := fmt.Sprintf("任务 %s（ID %d）最近 %d 次执行的成功率为 %.1f%%，低于告警阈值 %.1f%%",
+		task.Name, task.ID, len(statuses), rate*100, task.SuccessRateThreshold*100)
+	slog.Warn("任务成功率过低", "task_id", task.ID, "task_name", task.Name, "success_rate", rate, "threshold", task.SuccessRateThreshold, "runs", len(statuses))
+	if err := s.notifier.Notify(context.Background(), title, content); err != nil {
+		return fmt.Errorf("发送成功率告警失败: %v", err)
+	}
+	now := time.Now()
+	return s.setSuccessRateAlerted(task.ID, &now)
+}
+
+// setSuccessRateAlerted 记录或清空成功率告警的发送时间，任务还没有统计时创建
+func (s *Scheduler) setSuccessRateAlerted(taskID uint, at *time.Time) error {
+	return database.WithDeadlockRetry(func() error {
+		return s.db.Where(model.TaskStats{TaskID: taskID}).
+			Assign(map[string]interface{}{"success_rate_alerted_at": at}).
+			FirstOrCreate(&model.TaskStats{}).Error
+	})
+}
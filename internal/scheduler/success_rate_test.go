@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"happx1/internal/model"
+)
+
+// recordingNotifier 记录发送的告警标题
+type recordingNotifier struct {
+	mu     sync.Mutex
+	titles []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, title, content string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.titles = append(n.titles, title)
+	return nil
+}
+
+func (n *recordingNotifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.titles)
+}
+
+// insertRuns 按 statuses 的顺序插入执行日志，越靠后的执行越新
+func insertRuns(t *testing.T, db *gorm.DB, taskID uint, statuses ...int) {
+	t.Helper()
+	var latest model.TaskLog
+	db.Where("task_id = ?", taskID).Order("start_time desc").Limit(1).Find(&latest)
+	start := time.Now().Add(-time.Hour)
+	if latest.ID != 0 {
+		start = latest.StartTime.Add(time.Second)
+	}
+	for i, status := range statuses {
+		taskLog := model.TaskLog{TaskID: taskID, Status: status, StartTime: start.Add(time.Duration(i) * time.Second), Attempt: 1}
+		if err := db.Create(&taskLog).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSuccessRateAlertDebounced(t *testing.T) {
+	db := newTestDB(t)
+	s := NewScheduler(db, nil, &Config{SuccessRateWindow: 10})
+	notifier := &recordingNotifier{}
+	s.UseNotifier(notifier)
+	task := createTestTask(t, db, &model.Task{Command: "echo", SuccessRateThreshold: 0.8})
+
+	// 执行次数不足时不计算成功率
+	insertRuns(t, db, task.ID, 0, 0, 0)
+	s.checkSuccessRates()
+	if n := notifier.count(); n != 0 {
+		t.Fatalf("执行次数不足时发送了 %d 次告警", n)
+	}
+
+	// 成功率低于阈值后只告警一次
+	insertRuns(t, db, task.ID, 1, 0, 1, 0, 0)
+	for i := 0; i < 3; i++ {
+		s.checkSuccessRates()
+	}
+	if n := notifier.count(); n != 1 {
+		t.Fatalf("成功率持续过低时发送了 %d 次告警，期望1次", n)
+	}
+
+	// 恢复到阈值以上后再次低于阈值时重新告警
+	insertRuns(t, db, task.ID, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1)
+	s.checkSuccessRates()
+	insertRuns(t, db, task.ID, 0, 0, 0, 0, 0)
+	s.checkSuccessRates()
+	s.checkSuccessRates()
+	if n := notifier.count(); n != 2 {
+		t.Fatalf("恢复后再次过低时共发送了 %d 次告警，期望2次", n)
+	}
+}
@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// timedTask 在指定时间触发的任务
+type timedTask struct {
+	at    time.Time
+	task  *model.Task
+	index int // 在堆中的位置，用于取消
+}
+
+// timerHeap 按触发时间排序的最小堆，实现 heap.Interface
+type timerHeap []*timedTask
+
+func (h timerHeap) Len() int { return len(h) }
+
+func (h timerHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+
+func (h timerHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *timerHeap) Push(x interface{}) {
+	item := x.(*timedTask)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *timerHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+// timerQueue 一次性任务的定时队列，所有任务共用一个定时器和一个 goroutine，
+// 每次只为最早到期的任务设置定时器。每个任务最多有一个待触发项，可随时取消
+type timerQueue struct {
+	mu     sync.Mutex
+	items  timerHeap
+	byTask map[uint]*timedTask
+	wake   chan struct{}
+	fire   func(task *model.Task)
+}
+
+func newTimerQueue(fire func(task *model.Task)) *timerQueue {
+	return &timerQueue{
+		byTask: make(map[uint]*timedTask),
+		wake:   make(chan struct{}, 1),
+		fire:   fire,
+	}
+}
+
+// add 添加定时任务，替换该任务已有的待触发项；队首变化时唤醒调度 goroutine 重新设置定时器
+func (q *timerQueue) add(at time.Time, task *model.Task) {
+	q.mu.Lock()
+	if old, ok := q.byTask[task.ID]; ok {
+		heap.Remove(&q.items, old.index)
+	}
+	item := &timedTask{at: at, task: task}
+	heap.Push(&q.items, item)
+	q.byTask[task.ID] = item
+	q.mu.Unlock()
+
+	q.notify()
+}
+
+// remove 取消任务的待触发项，返回是否存在
+func (q *timerQueue) remove(taskID uint) bool {
+	q.mu.Lock()
+	item, ok := q.byTask[taskID]
+	if ok {
+		heap.Remove(&q.items, item.index)
+		delete(q.byTask, taskID)
+	}
+	q.mu.Unlock()
+
+	if ok {
+		q.notify()
+	}
+	return ok
+}
+
+// notify 唤醒调度 goroutine 按新的队首重新设置定时器
+func (q *timerQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// len 返回等待触发的任务数
+func (q *timerQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// pending 返回所有等待触发的任务项的副本
+func (q *timerQueue) pending() []timedTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := make([]timedTask, 0, len(q.items))
+	for _, item := range q.items {
+		items = append(items, *item)
+	}
+	return items
+}
+
+// run 循环等待最早到期的任务并触发，直到 stop 关闭
+func (q *timerQueue) run(stop <-chan struct{}) {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		for _, task := range q.popDue(time.Now()) {
+			q.fire(task)
+		}
+
+		var timerC <-chan time.Time
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			timer.Reset(time.Until(q.items[0].at))
+			timerC = timer.C
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-timerC:
+		case <-q.wake:
+			if !timer.Stop() && timerC != nil {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// popDue 取出所有已到期的任务
+func (q *timerQueue) popDue(now time.Time) []*model.Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var due []*model.Task
+	for len(q.items) > 0 && !q.items[0].at.After(now) {
+		item := heap.Pop(&q.items).(*timedTask)
+		delete(q.byTask, item.task.ID)
+		due = append(due, item.task)
+	}
+	return due
+}
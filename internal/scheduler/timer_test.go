@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// firedRecorder 记录定时队列触发的任务和触发时间
+type firedRecorder struct {
+	mu    sync.Mutex
+	fired map[uint]time.Time
+	order []uint
+}
+
+func newFiredRecorder() *firedRecorder {
+	return &firedRecorder{fired: make(map[uint]time.Time)}
+}
+
+func (r *firedRecorder) fire(task *model.Task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fired[task.ID] = time.Now()
+	r.order = append(r.order, task.ID)
+}
+
+func (r *firedRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.order)
+}
+
+// startTimerQueue 启动定时队列，测试结束时停止
+func startTimerQueue(t *testing.T, fire func(task *model.Task)) *timerQueue {
+	t.Helper()
+	q := newTimerQueue(fire)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.run(stop)
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+	})
+	return q
+}
+
+func TestTimerQueueFiresManyTasksOnTime(t *testing.T) {
+	r := newFiredRecorder()
+	q := startTimerQueue(t, r.fire)
+
+	const n = 200
+	before := runtime.NumGoroutine()
+	start := time.Now()
+	due := make(map[uint]time.Time, n)
+	// 倒序添加，触发顺序只取决于到期时间
+	for i := n; i > 0; i-- {
+		at := start.Add(100*time.Millisecond + time.Duration(i%20)*10*time.Millisecond)
+		due[uint(i)] = at
+		q.add(at, &model.Task{ID: uint(i)})
+	}
+	// 所有任务共用一个 goroutine，添加任务不创建新的 goroutine
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("添加 %d 个定时任务后 goroutine 从 %d 个增加到 %d 个", n, before, after)
+	}
+
+	waitFor(t, 5*time.Second, "所有定时任务触发", func() bool { return r.count() == n })
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, at := range due {
+		fired := r.fired[id]
+		if fired.Before(at) || fired.Sub(at) > 100*time.Millisecond {
+			t.Errorf("任务 %d 在 %v 触发，期望 %v", id, fired.Sub(start), at.Sub(start))
+		}
+	}
+	for i := 1; i < len(r.order); i++ {
+		if due[r.order[i]].Before(due[r.order[i-1]]) {
+			t.Fatalf("任务 %d 早于先到期的任务 %d 触发", r.order[i-1], r.order[i])
+		}
+	}
+}
+
+func TestTimerQueueReplaceAndRemove(t *testing.T) {
+	r := newFiredRecorder()
+	q := startTimerQueue(t, r.fire)
+	start := time.Now()
+
+	// 同一任务重新添加时替换原有的待触发项
+	q.add(start.Add(50*time.Millisecond), &model.Task{ID: 1})
+	q.add(start.Add(150*time.Millisecond), &model.Task{ID: 1})
+	q.add(start.Add(100*time.Millisecond), &model.Task{ID: 2})
+	if !q.remove(2) || q.remove(3) {
+		t.Fatal("remove 应只对存在的待触发项返回 true")
+	}
+	if q.len() != 1 {
+		t.Fatalf("待触发 %d 项，期望1项", q.len())
+	}
+
+	waitFor(t, time.Second, "任务1触发", func() bool { return r.count() == 1 })
+	time.Sleep(100 * time.Millisecond)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.order) != 1 || r.order[0] != 1 {
+		t.Fatalf("触发了 %v，期望只有任务1", r.order)
+	}
+	if elapsed := r.fired[1].Sub(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("任务1在 %v 触发，期望按替换后的 150ms", elapsed)
+	}
+}
+
+func TestTimerQueueEarlierTaskRearmsTimer(t *testing.T) {
+	r := newFiredRecorder()
+	q := startTimerQueue(t, r.fire)
+
+	// 队首为一小时后的任务时，新加入的更早任务仍按时触发
+	q.add(time.Now().Add(time.Hour), &model.Task{ID: 1})
+	q.add(time.Now().Add(50*time.Millisecond), &model.Task{ID: 2})
+	waitFor(t, time.Second, "更早的任务触发", func() bool { return r.count() == 1 })
+	if q.len() != 1 {
+		t.Fatalf("待触发 %d 项，期望只剩一小时后的任务", q.len())
+	}
+}
+
+func TestOnceTaskCancelledBeforeFiring(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	due := time.Now().Add(2 * time.Second).Truncate(time.Second)
+	once := func(name string) *model.Task {
+		task := &model.Task{Name: name, Type: model.TaskTypeOnce, Spec: due.Format(time.RFC3339), Command: "echo " + name, Timeout: 5, Status: 1}
+		if err := s.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	removed := once("removed")
+	rescheduled := once("rescheduled")
+	kept := once("kept")
+
+	// 触发前删除的任务和改到以后执行的任务都不应按原时间执行
+	time.Sleep(200 * time.Millisecond)
+	s.RemoveTask(removed.ID)
+	rescheduled.Spec = due.Add(time.Hour).Format(time.RFC3339)
+	if err := s.RescheduleTask(rescheduled); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, 5*time.Second, "未取消的任务按时执行", func() bool { return countLogs(t, db, kept.ID) == 1 })
+	time.Sleep(200 * time.Millisecond)
+	if n := countLogs(t, db, removed.ID); n != 0 {
+		t.Fatalf("已删除的一次性任务执行了 %d 次", n)
+	}
+	if n := countLogs(t, db, rescheduled.ID); n != 0 {
+		t.Fatalf("改期的一次性任务按原时间执行了 %d 次", n)
+	}
+}
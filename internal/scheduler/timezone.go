@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 时区来源
+const (
+	TimezoneSourceConfig = "config"
+	TimezoneSourceOS     = "os"
+)
+
+// resolveLocation 解析调度器时区：配置优先，未配置时从操作系统检测
+func resolveLocation(name string) (*time.Location, string, error) {
+	if name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, "", fmt.Errorf("无效的时区配置 %q: %v", name, err)
+		}
+		return loc, TimezoneSourceConfig, nil
+	}
+	return detectLocation(), TimezoneSourceOS, nil
+}
+
+// detectLocation 检测操作系统时区，尽量得到 IANA 名称而不是 "Local"
+func detectLocation() *time.Location {
+	candidates := []string{strings.TrimPrefix(os.Getenv("TZ"), ":")}
+	if data, err := os.ReadFile("/etc/timezone"); err == nil {
+		candidates = append(candidates, strings.TrimSpace(string(data)))
+	}
+	if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if idx := strings.Index(target, "zoneinfo/"); idx >= 0 {
+			candidates = append(candidates, target[idx+len("zoneinfo/"):])
+		}
+	}
+
+	for _, name := range candidates {
+		if name == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestConfiguredTimezone(t *testing.T) {
+	db := newTestDB(t)
+	task := createTestTask(t, db, &model.Task{Spec: "0 9 * * *", Command: "echo tz"})
+	s := startTestScheduler(t, db, nil, &Config{Timezone: "Asia/Shanghai"})
+
+	status := s.Status()
+	if status.Timezone != "Asia/Shanghai" || status.TimezoneSource != TimezoneSourceConfig {
+		t.Fatalf("状态中的时区为 %s（来源 %s），期望配置的 Asia/Shanghai", status.Timezone, status.TimezoneSource)
+	}
+	if status.Now.Location().String() != "Asia/Shanghai" {
+		t.Fatalf("状态中的当前时间时区为 %s", status.Now.Location())
+	}
+
+	// cron 表达式按配置的时区解释：上海每天9点即 UTC 1点
+	db.First(task, task.ID)
+	if next := task.NextRunTime.UTC(); next.Hour() != 1 || next.Minute() != 0 {
+		t.Fatalf("下次执行时间 %v，期望 UTC 1:00", next)
+	}
+}
+
+func TestInvalidTimezone(t *testing.T) {
+	s := NewScheduler(newTestDB(t), nil, &Config{Timezone: "Mars/Olympus"})
+	if err := s.Start(); err == nil {
+		t.Fatal("无效的时区配置应导致启动失败")
+	}
+}
+
+func TestDetectTimezoneFromOS(t *testing.T) {
+	t.Setenv("TZ", "America/New_York")
+	loc, source, err := resolveLocation("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.String() != "America/New_York" || source != TimezoneSourceOS {
+		t.Fatalf("检测到的时区为 %s（来源 %s），期望 TZ 环境变量中的 America/New_York", loc, source)
+	}
+
+	// 配置优先于操作系统
+	loc, source, err = resolveLocation("UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc != time.UTC || source != TimezoneSourceConfig {
+		t.Fatalf("时区为 %s（来源 %s），期望配置的 UTC", loc, source)
+	}
+}
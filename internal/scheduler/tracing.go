@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"happx1/internal/model"
+)
+
+// tracer 任务执行链路，未初始化 TracerProvider 时为空实现
+var tracer = otel.Tracer("happx1/scheduler")
+
+// startExecutionSpan 为一次任务执行创建 span
+func startExecutionSpan(task *model.Task) (context.Context, trace.Span) {
+	return tracer.Start(context.Background(), "task.execute", trace.WithAttributes(
+		attribute.Int64("task.id", int64(task.ID)),
+		attribute.String("task.name", task.Name),
+		attribute.String("task.run_id", task.RunID),
+		attribute.String("task.exec_type", execTypeOf(task)),
+		attribute.Bool("task.dry_run", task.DryRun),
+	))
+}
+
+// endExecutionSpan 记录执行结果并结束 span
+func endExecutionSpan(span trace.Span, taskLog *model.TaskLog, err error) {
+	status := "success"
+	if taskLog.Status != 1 {
+		status = "failed"
+	}
+	span.SetAttributes(
+		attribute.String("task.status", status),
+		attribute.Int("task.retries", taskLog.RetryCount),
+		attribute.Int("task.duration", taskLog.Duration),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"happx1/internal/model"
+)
+
+// newSpanRecorder 将执行链路导出到内存，测试结束时恢复原 TracerProvider
+func newSpanRecorder(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+// spanAttributes 返回 span 的属性
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestExecutionSpans(t *testing.T) {
+	exporter := newSpanRecorder(t)
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	retries := 1
+	ok := createTestTask(t, db, &model.Task{Name: "traced-ok", Command: "true"})
+	failed := createTestTask(t, db, &model.Task{Name: "traced-failed", Command: "exit 1", RetryTimes: &retries})
+	failed.RetryDelay = 0
+
+	okLog := s.ExecuteTask(ok)
+	s.ExecuteTask(failed)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("导出了 %d 个 span，期望每次执行一个", len(spans))
+	}
+	cases := []struct {
+		span    tracetest.SpanStub
+		task    *model.Task
+		status  string
+		retries int64
+		code    codes.Code
+	}{
+		{spans[0], ok, "success", 0, codes.Unset},
+		{spans[1], failed, "failed", 1, codes.Error},
+	}
+	for _, c := range cases {
+		attrs := spanAttributes(c.span)
+		if c.span.Name != "task.execute" {
+			t.Errorf("%s: span 名称为 %s", c.task.Name, c.span.Name)
+		}
+		if attrs["task.id"].AsInt64() != int64(c.task.ID) || attrs["task.name"].AsString() != c.task.Name || attrs["task.exec_type"].AsString() != model.ExecTypeShell {
+			t.Errorf("%s: span 的任务属性 %v 不正确", c.task.Name, attrs)
+		}
+		if attrs["task.status"].AsString() != c.status || attrs["task.retries"].AsInt64() != c.retries {
+			t.Errorf("%s: span 的结果属性 status=%s retries=%d，期望 %s/%d", c.task.Name, attrs["task.status"].AsString(), attrs["task.retries"].AsInt64(), c.status, c.retries)
+		}
+		if c.span.Status.Code != c.code {
+			t.Errorf("%s: span 状态为 %v，期望 %v", c.task.Name, c.span.Status.Code, c.code)
+		}
+	}
+	if runID := spanAttributes(spans[0])["task.run_id"].AsString(); runID != okLog.RunID {
+		t.Errorf("span 的执行ID %s 与日志的 %s 不一致", runID, okLog.RunID)
+	}
+}
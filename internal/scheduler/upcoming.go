@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// maxUpcomingPerTask 单个任务在执行队列视图中最多列出的触发次数，避免秒级任务在长时间窗口内产生大量条目
+const maxUpcomingPerTask = 100
+
+// cronJob cron 条目执行的任务，保存注册时的任务快照，每次触发时复制后提交
+type cronJob struct {
+	scheduler *Scheduler
+	task      model.Task
+}
+
+// Run 实现 cron.Job
+func (j *cronJob) Run() {
+	t := j.task
+	t.ScheduledAt = j.scheduler.firedAt(t.ID)
+	j.scheduler.recordFire(&t)
+	j.scheduler.trigger(&t)
+}
+
+// UpcomingRun 即将到来的一次触发
+type UpcomingRun struct {
+	TaskID   uint      `json:"task_id"`
+	TaskName string    `json:"task_name"`
+	Type     string    `json:"type"` // 触发类型：cron、once
+	At       time.Time `json:"at"`   // 触发时间（调度时区），不含随机延迟
+}
+
+// Upcoming 返回从现在起 within 时间内所有已注册触发器的触发时间，按时间排序
+// 包括 cron 任务和一次性任务；after 类型任务的触发取决于触发源任务何时完成，不包括在内
+func (s *Scheduler) Upcoming(within time.Duration) []UpcomingRun {
+	now := time.Now().In(s.location)
+	until := now.Add(within)
+
+	var runs []UpcomingRun
+	for _, entry := range s.cron.Entries() {
+		job, ok := entry.Job.(*cronJob)
+		if !ok {
+			continue
+		}
+		task := &job.task
+		window, _ := ParseActiveHours(task.ActiveHours, task.ActiveDays)
+		for at, n := entry.Schedule.Next(now), 0; !at.After(until) && n < maxUpcomingPerTask; at = entry.Schedule.Next(at) {
+			// 永远不会触发的表达式（如 2 月 30 日）返回零值
+			if at.IsZero() {
+				break
+			}
+			// 生效时间窗口和活动时间之外的触发会被跳过
+			if task.ValidUntil != nil && at.After(*task.ValidUntil) {
+				break
+			}
+			if task.ValidFrom != nil && at.Before(*task.ValidFrom) {
+				continue
+			}
+			if window != nil && !window.Contains(at) {
+				continue
+			}
+			runs = append(runs, UpcomingRun{TaskID: task.ID, TaskName: task.Name, Type: model.TaskTypeCron, At: at})
+			n++
+		}
+	}
+	for _, item := range s.timers.pending() {
+		if item.at.After(until) {
+			continue
+		}
+		runs = append(runs, UpcomingRun{TaskID: item.task.ID, TaskName: item.task.Name, Type: model.TaskTypeOnce, At: item.at.In(s.location)})
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].At.Equal(runs[j].At) {
+			return runs[i].At.Before(runs[j].At)
+		}
+		return runs[i].TaskID < runs[j].TaskID
+	})
+	return runs
+}
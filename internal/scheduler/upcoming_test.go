@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestUpcoming(t *testing.T) {
+	db := newTestDB(t)
+	quarter := createTestTask(t, db, &model.Task{Name: "quarter", Spec: "*/15 * * * *", Command: "echo"})
+	third := createTestTask(t, db, &model.Task{Name: "third", Spec: "*/20 * * * *", Command: "echo"})
+	once := createTestTask(t, db, &model.Task{Name: "once", Type: model.TaskTypeOnce, Spec: time.Now().Add(25 * time.Minute).Format(time.RFC3339), Command: "echo"})
+	later := createTestTask(t, db, &model.Task{Name: "later", Type: model.TaskTypeOnce, Spec: time.Now().Add(2 * time.Hour).Format(time.RFC3339), Command: "echo"})
+	s := startTestScheduler(t, db, nil, &Config{})
+
+	runs := s.Upcoming(time.Hour)
+	counts := make(map[uint]int)
+	for i, run := range runs {
+		counts[run.TaskID]++
+		if i > 0 {
+			prev := runs[i-1]
+			if run.At.Before(prev.At) || run.At.Equal(prev.At) && run.TaskID < prev.TaskID {
+				t.Fatalf("第%d项 %s@%v 排在 %s@%v 之后，顺序错误", i, run.TaskName, run.At, prev.TaskName, prev.At)
+			}
+		}
+	}
+	// 一小时内每15分钟触发4次、每20分钟触发3次，窗口外的一次性任务不列出
+	want := map[uint]int{quarter.ID: 4, third.ID: 3, once.ID: 1}
+	for id, n := range want {
+		if counts[id] != n {
+			t.Errorf("任务 %d 触发 %d 次，期望 %d 次", id, counts[id], n)
+		}
+	}
+	if counts[later.ID] != 0 || len(runs) != 8 {
+		t.Errorf("共 %d 项，期望8项且不含窗口外的任务", len(runs))
+	}
+
+	// 移除的任务不再出现
+	s.RemoveTask(quarter.ID)
+	for _, run := range s.Upcoming(time.Hour) {
+		if run.TaskID == quarter.ID {
+			t.Fatal("移除的任务仍在执行队列视图中")
+		}
+	}
+}
+
+func TestUpcomingSpecThatNeverFires(t *testing.T) {
+	db := newTestDB(t)
+	validFrom := time.Now().Add(time.Hour)
+	// 2 月 30 日不存在，设置了生效时间或活动时间时不能一直跳过零值
+	cases := []*model.Task{
+		{Name: "feb-30", Spec: "0 0 30 2 *", Command: "echo"},
+		{Name: "feb-30-valid-from", Spec: "0 0 30 2 *", Command: "echo", ValidFrom: &validFrom},
+		{Name: "feb-30-active-hours", Spec: "0 0 30 2 *", Command: "echo", ActiveHours: "09:00-18:00"},
+	}
+	for _, task := range cases {
+		createTestTask(t, db, task)
+	}
+	s := startTestScheduler(t, db, nil, &Config{})
+
+	result := make(chan []UpcomingRun, 1)
+	go func() { result <- s.Upcoming(365 * 24 * time.Hour) }()
+	select {
+	case runs := <-result:
+		if len(runs) != 0 {
+			t.Fatalf("永远不会触发的任务列出了 %d 次触发，第一次在 %v", len(runs), runs[0].At)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("永远不会触发的任务使执行队列视图一直循环")
+	}
+}
@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// 执行上下文变量，执行时替换命令、请求头和请求体中的 ${name} 占位符；shell 命令中的变量通过 HAPPX1_ 前缀的环境变量传递
+const (
+	VarDate       = "date"        // 执行日期（调度时区），如 2024-01-02
+	VarTimestamp  = "timestamp"   // 执行时的 Unix 时间戳（秒）
+	VarLastOutput = "last_output" // 上一次执行最终结果的输出，没有时为空
+	VarRunID      = "run_id"      // 本次执行的ID
+)
+
+// variableNames 可用的执行上下文变量
+var variableNames = []string{VarDate, VarTimestamp, VarLastOutput, VarRunID}
+
+// variablePattern 执行上下文变量占位符
+var variablePattern = regexp.MustCompile(`\$\{(` + strings.Join(variableNames, "|") + `)\}`)
+
+// placeholderPattern 任意 ${name} 占位符，用于创建时检查变量名
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// CheckVariables 校验任务中使用的变量名：shell 命令中的其他 ${name} 是 shell 变量，不检查；
+// 其他执行类型和请求头、请求体中只能使用执行上下文变量和 ${secret.NAME}
+func CheckVariables(task *model.Task) error {
+	texts := []string{task.Body}
+	if task.ExecType != "" && task.ExecType != model.ExecTypeShell {
+		texts = append(texts, task.Command)
+	}
+	for _, v := range task.Headers {
+		texts = append(texts, v)
+	}
+
+	for _, text := range texts {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			name := match[1]
+			if strings.HasPrefix(name, "secret.") || variablePattern.MatchString(match[0]) {
+				continue
+			}
+			return fmt.Errorf("未知的变量 ${%s}，可用的变量: %s", name, strings.Join(variableNames, "、"))
+		}
+	}
+	return nil
+}
+
+// variableEnvPrefix shell 命令中执行上下文变量对应的环境变量前缀，如 ${date} 对应 HAPPX1_DATE
+const variableEnvPrefix = "HAPPX1_"
+
+// maxVariableEnv 通过环境变量传递的变量值的最大长度（字节），避免超过系统对单个环境变量的长度限制
+const maxVariableEnv = 64 << 10
+
+// resolveVariables 返回替换了执行上下文变量的任务副本，原任务不变；没有使用变量时返回原任务
+// shell 命令中的变量不在这里替换，执行时通过环境变量传递，见 shellVariables
+func (s *Scheduler) resolveVariables(task *model.Task) *model.Task {
+	shell := execTypeOf(task) == model.ExecTypeShell
+	used := (!shell && variablePattern.MatchString(task.Command)) || variablePattern.MatchString(task.Body)
+	for _, v := range task.Headers {
+		used = used || variablePattern.MatchString(v)
+	}
+	if !used {
+		return task
+	}
+
+	values := s.variableValues(task)
+	replace := func(text string) string {
+		return variablePattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			return values(variablePattern.FindStringSubmatch(placeholder)[1])
+		})
+	}
+
+	resolved := *task
+	if !shell {
+		resolved.Command = replace(task.Command)
+	}
+	resolved.Body = replace(task.Body)
+	if task.Headers != nil {
+		resolved.Headers = make(model.Headers, len(task.Headers))
+		for k, v := range task.Headers {
+			resolved.Headers[k] = replace(v)
+		}
+	}
+	return &resolved
+}
+
+// shellVariables 把 shell 命令中的执行上下文变量改写为对应环境变量的引用（如 ${date} 改为 ${HAPPX1_DATE}），
+// 返回改写后的命令和需要设置的环境变量；变量值由 shell 展开而不是拼接进命令，上一次输出中的引号、分号等不会被当作命令执行
+func (s *Scheduler) shellVariables(task *model.Task) (string, []string) {
+	used := map[string]bool{}
+	command := variablePattern.ReplaceAllStringFunc(task.Command, func(placeholder string) string {
+		name := variablePattern.FindStringSubmatch(placeholder)[1]
+		used[name] = true
+		return "${" + variableEnvName(name) + "}"
+	})
+	if len(used) == 0 {
+		return task.Command, nil
+	}
+
+	values := s.variableValues(task)
+	env := make([]string, 0, len(used))
+	for _, name := range variableNames {
+		if !used[name] {
+			continue
+		}
+		// 环境变量不能包含 NUL
+		value := strings.ReplaceAll(values(name), "\x00", "")
+		if len(value) > maxVariableEnv {
+			value = value[:maxVariableEnv]
+		}
+		env = append(env, variableEnvName(name)+"="+value)
+	}
+	return command, env
+}
+
+// variableEnvName 返回执行上下文变量对应的环境变量名
+func variableEnvName(name string) string {
+	return variableEnvPrefix + strings.ToUpper(name)
+}
+
+// variableValues 返回按变量名取值的函数，执行时间在调用时确定，上一次的输出只在使用时查询一次
+func (s *Scheduler) variableValues(task *model.Task) func(name string) string {
+	now := time.Now().In(s.location)
+	var lastOutput *string
+	return func(name string) string {
+		switch name {
+		case VarDate:
+			return now.Format("2006-01-02")
+		case VarTimestamp:
+			return strconv.FormatInt(now.Unix(), 10)
+		case VarRunID:
+			return task.RunID
+		default:
+			if lastOutput == nil {
+				output := s.lastOutput(task)
+				lastOutput = &output
+			}
+			return *lastOutput
+		}
+	}
+}
+
+// lastOutput 返回任务上一次执行最终结果的输出，base64 编码保存的输出解码后返回，没有执行记录时为空
+func (s *Scheduler) lastOutput(task *model.Task) string {
+	var last model.TaskLog
+	err := s.db.Select("output", "output_encoding").
+		Where("task_id = ? AND retried = ? AND run_id <> ?", task.ID, false, task.RunID).
+		Order("start_time desc").
+		Limit(1).
+		Find(&last).Error
+	if err != nil {
+		slog.Error("查询上一次执行输出失败", "task_id", task.ID, "task_name", task.Name, "error", err)
+		return ""
+	}
+	if last.OutputEncoding == model.OutputEncodingBase64 {
+		if decoded, err := base64.StdEncoding.DecodeString(last.Output); err == nil {
+			return string(decoded)
+		}
+	}
+	return last.Output
+}
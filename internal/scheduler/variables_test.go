@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"happx1/internal/model"
+)
+
+func TestCommandVariables(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{Timezone: "Asia/Tokyo"})
+	task := createTestTask(t, db, &model.Task{Command: "echo ${date}"})
+
+	// 日期按调度时区计算
+	loc, _ := time.LoadLocation("Asia/Tokyo")
+	today := time.Now().In(loc).Format("2006-01-02")
+	taskLog := s.ExecuteTask(newRun(task))
+	if got := strings.TrimSpace(taskLog.Output); got != today {
+		t.Fatalf("${date} 输出 %q，期望 %q", got, today)
+	}
+
+	// ${last_output} 为上一次执行的输出，第一次执行时为空
+	chained := createTestTask(t, db, &model.Task{Name: "last-output", Command: "echo \"[${last_output}]\" ${run_id}"})
+	first := s.ExecuteTask(newRun(chained))
+	if !strings.HasPrefix(first.Output, "[] "+first.RunID) {
+		t.Fatalf("第一次执行输出 %q，期望上一次输出为空", first.Output)
+	}
+	second := s.ExecuteTask(newRun(chained))
+	if want := "[" + first.Output + "] " + second.RunID + "\n"; second.Output != want {
+		t.Fatalf("第二次执行输出 %q，期望 %q", second.Output, want)
+	}
+}
+
+func TestLastOutputNotInjectedIntoShell(t *testing.T) {
+	db := newTestDB(t)
+	s := startTestScheduler(t, db, nil, &Config{})
+	dir := t.TempDir()
+	payload := "'; touch pwned; ' \"; touch pwned2; \" $(touch pwned3) `touch pwned4`"
+
+	cases := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"双引号", `cd ` + dir + ` && echo "${last_output}"`, payload + "\n"},
+		{"未加引号", `cd ` + dir + ` && echo ${last_output}`, strings.Join(strings.Fields(payload), " ") + "\n"},
+		// 单引号中不展开变量，按原样输出
+		{"单引号", `cd ` + dir + ` && echo '${last_output}'`, "${HAPPX1_LAST_OUTPUT}\n"},
+	}
+	for _, c := range cases {
+		task := createTestTask(t, db, &model.Task{Name: c.name, Command: c.command})
+		// 上一次执行的输出包含引号、分号和命令替换
+		previous := model.TaskLog{TaskID: task.ID, RunID: "previous-" + c.name, Attempt: 1, Status: 1, StartTime: time.Now().Add(-time.Minute), Output: payload}
+		if err := db.Create(&previous).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		taskLog := s.ExecuteTask(newRun(task))
+		if taskLog.Status != 1 || taskLog.Output != c.want {
+			t.Fatalf("%s: 状态 %d 输出 %q，期望输出 %q", c.name, taskLog.Status, taskLog.Output, c.want)
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			t.Fatalf("%s: 上一次的输出被当作命令执行，创建了 %s", c.name, filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+func TestCheckVariables(t *testing.T) {
+	cases := []struct {
+		name    string
+		task    model.Task
+		wantErr bool
+	}{
+		{"shell 命令中的 shell 变量", model.Task{Command: "echo ${HOME} ${date}"}, false},
+		{"请求体中的执行上下文变量", model.Task{ExecType: model.ExecTypeHTTP, Command: "https://example.com/${date}", Body: `{"ts":${timestamp},"run":"${run_id}"}`}, false},
+		{"请求头中的密钥", model.Task{ExecType: model.ExecTypeHTTP, Headers: model.Headers{"Authorization": "Bearer ${secret.TOKEN}"}}, false},
+		{"请求体中的未知变量", model.Task{ExecType: model.ExecTypeHTTP, Body: `{"day":"${today}"}`}, true},
+		{"http 地址中的未知变量", model.Task{ExecType: model.ExecTypeHTTP, Command: "https://example.com/${HOME}"}, true},
+		{"请求头中的未知变量", model.Task{ExecType: model.ExecTypeHTTP, Headers: model.Headers{"X-Day": "${day}"}}, true},
+	}
+	for _, c := range cases {
+		err := CheckVariables(&c.task)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: 错误为 %v，期望出错=%v", c.name, err, c.wantErr)
+		}
+	}
+}
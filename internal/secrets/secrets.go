@@ -0,0 +1,187 @@
+package secrets
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Provider 按名称读取密钥
+type Provider interface {
+	Get(name string) (string, error)
+}
+
+// 密钥来源
+const (
+	SourceEnv  = "env"
+	SourceFile = "file"
+)
+
+// defaultEnvPrefix 从环境变量读取密钥时的默认前缀
+const defaultEnvPrefix = "HAPPX1_SECRET_"
+
+// Config 密钥配置
+type Config struct {
+	Source    string `mapstructure:"source"`     // 密钥来源：env（默认）-环境变量，file-密钥文件
+	EnvPrefix string `mapstructure:"env_prefix"` // env 来源：环境变量前缀，默认 HAPPX1_SECRET_，${secret.DB_PASS} 读取 HAPPX1_SECRET_DB_PASS
+	File      string `mapstructure:"file"`       // file 来源：密钥文件路径，每行一个 NAME=VALUE，# 开头为注释
+}
+
+// New 按配置创建密钥来源
+func New(config *Config) (Provider, error) {
+	switch config.Source {
+	case SourceEnv, "":
+		prefix := config.EnvPrefix
+		if prefix == "" {
+			prefix = defaultEnvPrefix
+		}
+		return &EnvProvider{Prefix: prefix}, nil
+	case SourceFile:
+		return LoadFile(config.File)
+	default:
+		return nil, fmt.Errorf("不支持的密钥来源: %s", config.Source)
+	}
+}
+
+// EnvProvider 从环境变量读取密钥
+type EnvProvider struct {
+	Prefix string
+}
+
+// Get 读取 Prefix+name 环境变量
+func (p *EnvProvider) Get(name string) (string, error) {
+	value, ok := os.LookupEnv(p.Prefix + name)
+	if !ok {
+		return "", fmt.Errorf("密钥不存在: %s", name)
+	}
+	return value, nil
+}
+
+// FileProvider 从密钥文件读取密钥
+type FileProvider struct {
+	values map[string]string
+}
+
+// LoadFile 加载 NAME=VALUE 格式的密钥文件
+func LoadFile(path string) (*FileProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("密钥文件第%d行格式无效，应为 NAME=VALUE", lineNo)
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+	return &FileProvider{values: values}, nil
+}
+
+// Get 读取密钥
+func (p *FileProvider) Get(name string) (string, error) {
+	value, ok := p.values[name]
+	if !ok {
+		return "", fmt.Errorf("密钥不存在: %s", name)
+	}
+	return value, nil
+}
+
+var placeholderPattern = regexp.MustCompile(`\$\{secret\.([A-Za-z0-9_.-]+)\}`)
+
+// Resolver 解析 ${secret.NAME} 占位符，并记录解析出的密钥值用于脱敏
+type Resolver struct {
+	provider Provider
+	values   []string
+}
+
+// NewResolver 创建占位符解析器
+func NewResolver(provider Provider) *Resolver {
+	return &Resolver{provider: provider}
+}
+
+// Resolve 将 s 中的 ${secret.NAME} 替换为密钥值
+func (r *Resolver) Resolve(s string) (string, error) {
+	var resolveErr error
+	resolved := placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		value, err := r.lookup(name)
+		if err != nil {
+			if resolveErr == nil {
+				resolveErr = err
+			}
+			return placeholder
+		}
+		return value
+	})
+	return resolved, resolveErr
+}
+
+// envNameInvalid 环境变量名中不能使用的字符
+var envNameInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// ResolveEnv 将 s 中的 ${secret.NAME} 改写为环境变量引用 ${prefix+NAME}（名称中的 . 和 - 替换为 _），
+// 返回改写后的字符串和需要设置的 NAME=VALUE 环境变量。用于 shell 命令：密钥值由 shell 展开而不是拼接进命令，
+// 值中的引号、分号等不会被当作命令执行，也不会出现在进程的命令行参数中
+func (r *Resolver) ResolveEnv(s, prefix string) (string, []string, error) {
+	var resolveErr error
+	var env []string
+	names := map[string]string{}
+	resolved := placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		envName := prefix + envNameInvalid.ReplaceAllString(name, "_")
+		if other, ok := names[envName]; ok {
+			if other != name && resolveErr == nil {
+				resolveErr = fmt.Errorf("密钥 %s 和 %s 对应同一个环境变量 %s", other, name, envName)
+			}
+			return "${" + envName + "}"
+		}
+		value, err := r.lookup(name)
+		if err != nil {
+			if resolveErr == nil {
+				resolveErr = err
+			}
+			return placeholder
+		}
+		names[envName] = name
+		env = append(env, envName+"="+value)
+		return "${" + envName + "}"
+	})
+	return resolved, env, resolveErr
+}
+
+// lookup 读取密钥并记录非空的值用于脱敏
+func (r *Resolver) lookup(name string) (string, error) {
+	value, err := r.provider.Get(name)
+	if err != nil {
+		return "", err
+	}
+	if value != "" {
+		r.values = append(r.values, value)
+	}
+	return value, nil
+}
+
+// Redacted 脱敏后替代密钥值的占位符
+const Redacted = "******"
+
+// Redact 将 s 中出现的已解析密钥值替换为 Redacted
+func (r *Resolver) Redact(s string) string {
+	for _, value := range r.values {
+		s = strings.ReplaceAll(s, value, Redacted)
+	}
+	return s
+}
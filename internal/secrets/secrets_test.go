@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("HAPPX1_SECRET_DB_PASS", "s3cret")
+	t.Setenv("HAPPX1_SECRET_API.KEY", "k-123")
+	provider, err := New(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(provider)
+
+	resolved, err := r.Resolve("mysql -p${secret.DB_PASS} --key=${secret.API.KEY} ${other}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != "mysql -ps3cret --key=k-123 ${other}" {
+		t.Fatalf("解析结果为 %q", resolved)
+	}
+	if redacted := r.Redact("login with s3cret and k-123"); redacted != "login with ****** and ******" {
+		t.Fatalf("脱敏结果为 %q", redacted)
+	}
+
+	if _, err := r.Resolve("${secret.MISSING}"); err == nil || !strings.Contains(err.Error(), "密钥不存在: MISSING") {
+		t.Fatalf("不存在的密钥错误为 %v", err)
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	t.Setenv("HAPPX1_SECRET_DB_PASS", `p'ss; $(rm -rf /)`)
+	t.Setenv("HAPPX1_SECRET_API.KEY", "k-123")
+	t.Setenv("HAPPX1_SECRET_API_KEY", "k-456")
+	provider, err := New(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(provider)
+
+	// 密钥改写为环境变量引用，值只出现在环境变量中
+	resolved, env, err := r.ResolveEnv("mysql -p${secret.DB_PASS} --key=${secret.API.KEY} -P${secret.DB_PASS} ${other}", "SECRET_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != "mysql -p${SECRET_DB_PASS} --key=${SECRET_API_KEY} -P${SECRET_DB_PASS} ${other}" {
+		t.Fatalf("改写结果为 %q", resolved)
+	}
+	if len(env) != 2 || env[0] != `SECRET_DB_PASS=p'ss; $(rm -rf /)` || env[1] != "SECRET_API_KEY=k-123" {
+		t.Fatalf("环境变量为 %q", env)
+	}
+	if redacted := r.Redact("key k-123"); redacted != "key ******" {
+		t.Fatalf("脱敏结果为 %q", redacted)
+	}
+
+	// 不同的密钥名不能对应同一个环境变量
+	if _, _, err := NewResolver(provider).ResolveEnv("${secret.API.KEY} ${secret.API_KEY}", "SECRET_"); err == nil || !strings.Contains(err.Error(), "同一个环境变量") {
+		t.Fatalf("环境变量名冲突时错误为 %v", err)
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets")
+	content := "# 数据库\nDB_PASS=p=ss\n\n TOKEN = abc\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := New(&Config{Source: SourceFile, File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"DB_PASS": "p=ss", "TOKEN": " abc"} {
+		if value, err := provider.Get(name); err != nil || value != want {
+			t.Errorf("%s = %q, %v，期望 %q", name, value, err, want)
+		}
+	}
+	if _, err := provider.Get("MISSING"); err == nil {
+		t.Error("不存在的密钥应报错")
+	}
+
+	if err := os.WriteFile(path, []byte("INVALID\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "第1行") {
+		t.Errorf("格式无效的密钥文件错误为 %v", err)
+	}
+	if _, err := New(&Config{Source: "vault"}); err == nil {
+		t.Error("不支持的密钥来源应报错")
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"happx1/internal/auth"
+	"happx1/internal/model"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// RegisterRoutes 注册路由，middlewares 作用于所有密钥管理接口，密钥管理需要 admin 角色
+func (h *APIKeyHandler) RegisterRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	middlewares = append(middlewares, auth.RequireRole(auth.RoleAdmin))
+	keys := r.Group("/api/keys", middlewares...)
+	{
+		// 创建密钥
+		keys.POST("", h.CreateKey)
+		// 获取密钥列表
+		keys.GET("", h.ListKeys)
+		// 吊销密钥
+		keys.POST("/:id/revoke", h.RevokeKey)
+	}
+}
+
+// CreateKeyRequest 创建密钥请求
+type CreateKeyRequest struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Scope string `json:"scope" binding:"required,oneof=read operate full"`
+}
+
+// CreateKey 创建密钥
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req CreateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	apiKey, key, err := h.apiKeyService.CreateKey(req.Name, req.Scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, struct {
+		*model.APIKey
+		Key string `json:"key"`
+	}{apiKey, key})
+}
+
+// ListKeys 获取密钥列表
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.ListKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeKey 吊销密钥
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的密钥ID"})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "密钥不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"happx1/internal/auth"
+	"happx1/internal/model"
+)
+
+const apiKeyPrefix = "hx1_"
+
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{
+		db: db,
+	}
+}
+
+// hashAPIKey 计算密钥的 SHA-256 哈希
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateKey 创建密钥，返回的明文密钥只在创建时可见
+func (s *APIKeyService) CreateKey(name, scope string) (*model.APIKey, string, error) {
+	switch scope {
+	case auth.ScopeRead, auth.ScopeOperate, auth.ScopeFull:
+	default:
+		return nil, "", fmt.Errorf("不支持的权限范围: %s", scope)
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", fmt.Errorf("生成密钥失败: %v", err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(buf)
+
+	apiKey := &model.APIKey{
+		Name:    name,
+		Prefix:  key[:len(apiKeyPrefix)+8],
+		KeyHash: hashAPIKey(key),
+		Scope:   scope,
+	}
+	if err := s.db.Create(apiKey).Error; err != nil {
+		return nil, "", err
+	}
+	return apiKey, key, nil
+}
+
+// ListKeys 获取密钥列表
+func (s *APIKeyService) ListKeys() ([]model.APIKey, error) {
+	var keys []model.APIKey
+	if err := s.db.Order("id desc").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeKey 吊销密钥
+func (s *APIKeyService) RevokeKey(id uint) error {
+	result := s.db.Delete(&model.APIKey{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ValidateAPIKey 校验密钥，实现 auth.APIKeyValidator
+func (s *APIKeyService) ValidateAPIKey(key string) (*auth.Identity, error) {
+	var apiKey model.APIKey
+	if err := s.db.Where("key_hash = ?", hashAPIKey(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	s.db.Model(&apiKey).UpdateColumn("last_used_at", now)
+
+	return &auth.Identity{
+		Subject: "apikey:" + apiKey.Name,
+		Role:    auth.RoleForScope(apiKey.Scope),
+	}, nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"happx1/internal/auth"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newAPIKeyRouter 创建使用 API Key 认证的任务和密钥管理路由
+func newAPIKeyRouter(t *testing.T) (*gin.Engine, *APIKeyService, *TaskService) {
+	t.Helper()
+	svc, db := newTestService(t, nil, nil)
+	keys := NewAPIKeyService(db)
+	authenticator, err := auth.NewAuthenticator(&auth.Config{JWTSecret: strings.Repeat("k", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator.UseAPIKeys(keys)
+
+	r := gin.New()
+	NewTaskHandler(svc).RegisterRoutes(r, authenticator.Middleware())
+	NewAPIKeyHandler(keys).RegisterRoutes(r, authenticator.Middleware())
+	return r, keys, svc
+}
+
+// apiKeyRequest 使用 API Key 发送请求，返回状态码
+func apiKeyRequest(r http.Handler, key, method, path, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	r, keys, svc := newAPIKeyRouter(t)
+	task := validTask("scoped")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	keyFor := func(scope string) string {
+		t.Helper()
+		_, key, err := keys.CreateKey(scope+"-client", scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	read, operate, full := keyFor(auth.ScopeRead), keyFor(auth.ScopeOperate), keyFor(auth.ScopeFull)
+
+	taskPath := fmt.Sprintf("/api/tasks/%d", task.ID)
+	newTask := `{"name":"created-by-key","spec":"*/5 * * * *","command":"echo hi","timeout":5}`
+	cases := []struct {
+		name, key, method, path, body string
+		want                          int
+	}{
+		{"只读查询任务列表", read, http.MethodGet, "/api/tasks", "", http.StatusOK},
+		{"只读查询日志", read, http.MethodGet, taskPath + "/logs", "", http.StatusOK},
+		{"只读查询统计", read, http.MethodGet, taskPath + "/stats", "", http.StatusOK},
+		{"只读创建任务", read, http.MethodPost, "/api/tasks", newTask, http.StatusForbidden},
+		{"只读执行任务", read, http.MethodPost, taskPath + "/run", "", http.StatusForbidden},
+		{"只读删除任务", read, http.MethodDelete, taskPath, "", http.StatusForbidden},
+		{"只读管理密钥", read, http.MethodGet, "/api/keys", "", http.StatusForbidden},
+		{"可执行创建任务", operate, http.MethodPost, "/api/tasks", newTask, http.StatusForbidden},
+		{"可执行删除任务", operate, http.MethodDelete, taskPath, "", http.StatusForbidden},
+		{"完全访问管理密钥", full, http.MethodGet, "/api/keys", "", http.StatusOK},
+		{"完全访问创建任务", full, http.MethodPost, "/api/tasks", newTask, http.StatusCreated},
+		{"无效密钥", "hx1_invalid", http.MethodGet, "/api/tasks", "", http.StatusUnauthorized},
+		{"缺少认证", "", http.MethodGet, "/api/tasks", "", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		if got := apiKeyRequest(r, c.key, c.method, c.path, c.body); got != c.want {
+			t.Errorf("%s: 状态码 %d，期望 %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	r, keys, _ := newAPIKeyRouter(t)
+	apiKey, key, err := keys.CreateKey("ci", auth.ScopeRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiKey.KeyHash == key || !strings.HasPrefix(key, apiKey.Prefix) {
+		t.Fatal("数据库中应只保存密钥的哈希和前缀")
+	}
+	if got := apiKeyRequest(r, key, http.MethodGet, "/api/tasks", ""); got != http.StatusOK {
+		t.Fatalf("吊销前状态码 %d", got)
+	}
+
+	if err := keys.RevokeKey(apiKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := apiKeyRequest(r, key, http.MethodGet, "/api/tasks", ""); got != http.StatusUnauthorized {
+		t.Fatalf("吊销后状态码 %d，期望401", got)
+	}
+	if err := keys.RevokeKey(apiKey.ID); err == nil {
+		t.Fatal("重复吊销应返回错误")
+	}
+	if _, _, err := keys.CreateKey("bad", "root"); err == nil {
+		t.Fatal("不支持的权限范围应被拒绝")
+	}
+}
+
+// newRoleRouter 创建使用 JWT 认证的任务路由，返回路由和指定角色用户的令牌
+func newRoleRouter(t *testing.T, svc *TaskService, role string) (*gin.Engine, string) {
+	t.Helper()
+	authenticator, err := auth.NewAuthenticator(&auth.Config{JWTSecret: strings.Repeat("k", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := authenticator.IssueToken("bob", role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	NewTaskHandler(svc).RegisterRoutes(r, authenticator.Middleware())
+	return r, token
+}
+
+// tokenRequest 使用 JWT 发送请求，body 为 JSON 请求体，返回响应
+func tokenRequest(r http.Handler, token, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRoleEnforcement(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	task := validTask("role-checked")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	taskPath := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	operator, operatorToken := newRoleRouter(t, svc, auth.RoleOperator)
+	w := tokenRequest(operator, operatorToken, http.MethodDelete, taskPath, "")
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "需要 admin 角色") {
+		t.Fatalf("operator 删除任务返回 %d %s，期望403并说明需要的角色", w.Code, w.Body)
+	}
+	if w := tokenRequest(operator, operatorToken, http.MethodPost, taskPath+"/run", ""); w.Code != http.StatusAccepted && w.Code != http.StatusOK {
+		t.Fatalf("operator 执行任务返回 %d %s", w.Code, w.Body)
+	}
+	if w := tokenRequest(operator, operatorToken, http.MethodPost, "/api/admin/pause", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("operator 暂停调度器返回 %d，期望403", w.Code)
+	}
+
+	viewer, viewerToken := newRoleRouter(t, svc, auth.RoleViewer)
+	if w := tokenRequest(viewer, viewerToken, http.MethodGet, taskPath, ""); w.Code != http.StatusOK {
+		t.Fatalf("viewer 查询任务返回 %d", w.Code)
+	}
+	if w := tokenRequest(viewer, viewerToken, http.MethodPost, taskPath+"/run", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("viewer 执行任务返回 %d，期望403", w.Code)
+	}
+
+	admin, adminToken := newRoleRouter(t, svc, auth.RoleAdmin)
+	if w := tokenRequest(admin, adminToken, http.MethodDelete, taskPath, ""); w.Code != http.StatusOK && w.Code != http.StatusNoContent {
+		t.Fatalf("admin 删除任务返回 %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"happx1/internal/model"
+)
+
+// auditIgnoredFields 不记录到审计差异中的字段，由系统维护而非配置变更
+var auditIgnoredFields = map[string]bool{
+	"ID":            true,
+	"CreatedAt":     true,
+	"UpdatedAt":     true,
+	"DeletedAt":     true,
+	"last_run_time": true,
+	"next_run_time": true,
+	"version":       true,
+}
+
+// auditChange 单个字段的变化
+type auditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// auditFields 将任务转换为字段名到值的映射，nil 表示任务不存在（创建前或删除后）
+// 使用任务的 JSON 序列化，私钥等敏感字段已脱敏
+func auditFields(task *model.Task) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if task == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k := range auditIgnoredFields {
+		delete(fields, k)
+	}
+	return fields, nil
+}
+
+// auditDiff 比较任务修改前后的字段，返回发生变化的字段，before 为 nil 表示创建，after 为 nil 表示删除
+func auditDiff(before, after *model.Task) (map[string]auditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := map[string]auditChange{}
+	for k, v := range beforeFields {
+		if !reflect.DeepEqual(v, afterFields[k]) {
+			diff[k] = auditChange{Before: v, After: afterFields[k]}
+		}
+	}
+	for k, v := range afterFields {
+		if _, ok := beforeFields[k]; !ok && v != nil {
+			diff[k] = auditChange{After: v}
+		}
+	}
+	return diff, nil
+}
+
+// writeAudit 记录一次任务配置变更
+func writeAudit(db *gorm.DB, taskID uint, action, actor string, before, after *model.Task) error {
+	diff, err := auditDiff(before, after)
+	if err != nil {
+		return fmt.Errorf("计算任务变更失败: %v", err)
+	}
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("编码任务变更失败: %v", err)
+	}
+	return db.Create(&model.TaskAudit{
+		TaskID: taskID,
+		Action: action,
+		Actor:  actor,
+		Diff:   data,
+	}).Error
+}
+
+// GetTaskAudit 获取任务的配置变更历史，按时间倒序，已删除的任务仍可查询
+func (s *TaskService) GetTaskAudit(taskID uint) ([]model.TaskAudit, error) {
+	audits := []model.TaskAudit{}
+	if err := s.db.Where("task_id = ?", taskID).Order("id DESC").Find(&audits).Error; err != nil {
+		return nil, err
+	}
+	return audits, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"happx1/internal/database"
+	"happx1/internal/model"
+)
+
+// 复制任务时名称的后缀，已被占用时依次尝试 -copy-2、-copy-3 …
+const (
+	cloneNameSuffix   = "-copy"
+	maxCloneAttempts  = 100
+	maxTaskNameLength = 100
+)
+
+// CloneTask 复制任务为一个新任务，名称加 -copy 后缀，配置与原任务相同，actor 为操作人
+// 新任务为禁用状态，便于修改后再启用；执行统计、日志和变更历史不复制
+func (s *TaskService) CloneTask(id uint, actor string) (*model.Task, error) {
+	source, err := s.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := *source
+	clone.ID = 0
+	clone.CreatedAt, clone.UpdatedAt = time.Time{}, time.Time{}
+	clone.DeletedAt = gorm.DeletedAt{}
+	clone.LastRunTime, clone.NextRunTime = time.Time{}, time.Time{}
+	clone.Version = 0
+	clone.Tags = append(model.Tags(nil), source.Tags...)
+	if source.Headers != nil {
+		clone.Headers = make(model.Headers, len(source.Headers))
+		for k, v := range source.Headers {
+			clone.Headers[k] = v
+		}
+	}
+	if err := s.ValidateTask(&clone); err != nil {
+		return nil, err
+	}
+
+	err = database.WithDeadlockRetry(func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			name, err := cloneName(tx, source.Name)
+			if err != nil {
+				return err
+			}
+			// 死锁重试时从头创建
+			clone.ID, clone.Name, clone.Status = 0, name, source.Status
+			// status 有数据库默认值，创建时无法直接保存为0，创建后再禁用；禁用的副本不注册触发器
+			if err := s.scheduler.AddTaskTx(tx, &clone); err != nil {
+				return err
+			}
+			clone.Status, clone.NextRunTime = 0, time.Time{}
+			if err := tx.Model(&clone).Updates(map[string]interface{}{"status": 0, "next_run_time": time.Time{}}).Error; err != nil {
+				return err
+			}
+			return writeAudit(tx, clone.ID, model.AuditActionCreate, actor, nil, &clone)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// cloneName 返回复制任务可用的名称：原名称加 -copy 后缀，已被占用时加序号，超长时截断原名称
+func cloneName(tx *gorm.DB, name string) (string, error) {
+	for i := 1; i <= maxCloneAttempts; i++ {
+		suffix := cloneNameSuffix
+		if i > 1 {
+			suffix = fmt.Sprintf("%s-%d", cloneNameSuffix, i)
+		}
+		base := []rune(name)
+		if maxBase := maxTaskNameLength - len([]rune(suffix)); len(base) > maxBase {
+			base = base[:maxBase]
+		}
+		candidate := string(base) + suffix
+
+		var count int64
+		if err := tx.Model(&model.Task{}).Where("name = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("没有可用的任务名称: %s%s", name, cloneNameSuffix)
+}
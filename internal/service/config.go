@@ -0,0 +1,29 @@
+package service
+
+// Config 任务服务配置
+type Config struct {
+	MaxScheduleAheadDays int `mapstructure:"max_schedule_ahead_days"` // 一次性任务执行时间最多可提前多少天设置，0表示不限制
+	RunWaitTimeout       int `mapstructure:"run_wait_timeout"`        // 同步执行最长等待时间（秒），0表示使用默认值
+	MaxTimeoutSeconds    int `mapstructure:"max_timeout_seconds"`     // 任务超时时间（timeout）的上限（秒），0表示不限制
+	MinCronInterval      int `mapstructure:"min_cron_interval"`       // cron 任务两次触发之间的最短间隔（秒），触发更频繁的表达式被拒绝，0表示不限制
+
+	IdempotencyKeyTTL int `mapstructure:"idempotency_key_ttl"` // 创建任务的幂等键保留时间（秒），0表示使用默认值
+
+	MaxHeadersSize int `mapstructure:"max_headers_size"` // 任务请求头或消息头（Headers）名称和值的总字节数上限，0表示使用默认值
+	MaxBodySize    int `mapstructure:"max_body_size"`    // 任务请求体或消息内容（Body）的字节数上限，0表示使用默认值
+}
+
+// cronIntervalSamples 计算 cron 表达式最短触发间隔时取的触发次数
+const cronIntervalSamples = 10
+
+// defaultRunWaitTimeout 同步执行默认最长等待时间（秒）
+const defaultRunWaitTimeout = 300
+
+// defaultIdempotencyKeyTTL 幂等键默认保留时间（秒）
+const defaultIdempotencyKeyTTL = 86400
+
+// 任务 Headers 和 Body 默认的字节数上限，Body 默认与 MySQL TEXT 列的容量相同
+const (
+	defaultMaxHeadersSize = 8 << 10
+	defaultMaxBodySize    = 64<<10 - 1
+)
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"happx1/internal/model"
+	"happx1/pkg/utils"
+)
+
+// crontabEntry crontab 中的一条任务
+type crontabEntry struct {
+	Line    int
+	Spec    string // 转换为调度器使用的表达式（开启秒字段时为 6 位）
+	Command string
+	Raw     string
+}
+
+// CrontabSkipped 导入时跳过或失败的行
+type CrontabSkipped struct {
+	Line   int    `json:"line"`
+	Raw    string `json:"raw"`
+	Reason string `json:"reason"`
+}
+
+// CrontabImportReport crontab 导入结果
+type CrontabImportReport struct {
+	Created []model.Task     `json:"created"`
+	Skipped []CrontabSkipped `json:"skipped"`
+	Failed  []CrontabSkipped `json:"failed"`
+}
+
+var (
+	crontabEnvPattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+	crontabNamePattern = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+)
+
+// parseCrontab 解析 crontab 内容，返回可导入的条目和跳过的行（注释、环境变量、无效行），parser 为调度器的 cron 表达式解析器
+func parseCrontab(content string, parser utils.CronParser) ([]crontabEntry, []CrontabSkipped) {
+	var (
+		entries []crontabEntry
+		skipped []CrontabSkipped
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			skipped = append(skipped, CrontabSkipped{Line: lineNo, Raw: raw, Reason: "注释"})
+			continue
+		case crontabEnvPattern.MatchString(line):
+			skipped = append(skipped, CrontabSkipped{Line: lineNo, Raw: raw, Reason: "环境变量"})
+			continue
+		}
+
+		var spec, command string
+		fields := strings.Fields(line)
+		if strings.HasPrefix(fields[0], "@") {
+			if fields[0] == "@reboot" {
+				skipped = append(skipped, CrontabSkipped{Line: lineNo, Raw: raw, Reason: "不支持 @reboot"})
+				continue
+			}
+			if len(fields) < 2 {
+				skipped = append(skipped, CrontabSkipped{Line: lineNo, Raw: raw, Reason: "缺少命令"})
+				continue
+			}
+			spec = fields[0]
+			command = strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+		} else {
+			if len(fields) < 6 {
+				skipped = append(skipped, CrontabSkipped{Line: lineNo, Raw: raw, Reason: "字段不足，应为5位时间表达式加命令"})
+				continue
+			}
+			// 标准 crontab 为 5 位表达式，调度器开启秒字段时补上秒
+			spec = strings.Join(fields[:5], " ")
+			if parser.WithSeconds() {
+				spec = "0 " + spec
+			}
+			command = line
+			for i := 0; i < 5; i++ {
+				command = strings.TrimSpace(strings.TrimPrefix(command, fields[i]))
+			}
+		}
+
+		if err := parser.Validate(spec); err != nil {
+			skipped = append(skipped, CrontabSkipped{Line: lineNo, Raw: raw, Reason: err.Error()})
+			continue
+		}
+		entries = append(entries, crontabEntry{Line: lineNo, Spec: spec, Command: command, Raw: raw})
+	}
+	return entries, skipped
+}
+
+// crontabTaskName 根据行号和命令生成任务名
+func crontabTaskName(entry crontabEntry) string {
+	slug := strings.Trim(crontabNamePattern.ReplaceAllString(strings.ToLower(entry.Command), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	return fmt.Sprintf("crontab-%d-%s", entry.Line, slug)
+}
+
+// ImportCrontab 将 crontab 内容导入为 shell 任务，单行失败不影响其他行，actor 为操作人
+func (s *TaskService) ImportCrontab(content, actor string) (*CrontabImportReport, error) {
+	entries, skipped := parseCrontab(content, s.scheduler.CronParser())
+	report := &CrontabImportReport{
+		Created: []model.Task{},
+		Skipped: skipped,
+		Failed:  []CrontabSkipped{},
+	}
+
+	for _, entry := range entries {
+		name, err := s.uniqueTaskName(crontabTaskName(entry))
+		if err != nil {
+			return nil, err
+		}
+
+		// 与 crontab 行为一致：默认不重试
+		retryTimes := 0
+		task := model.Task{
+			Name:        name,
+			Type:        model.TaskTypeCron,
+			Spec:        entry.Spec,
+			Command:     entry.Command,
+			RetryTimes:  &retryTimes,
+			Description: fmt.Sprintf("从 crontab 第%d行导入: %s", entry.Line, strings.TrimSpace(entry.Raw)),
+			Tags:        model.Tags{"crontab"},
+		}
+		if err := s.CreateTask(&task, actor); err != nil {
+			report.Failed = append(report.Failed, CrontabSkipped{Line: entry.Line, Raw: entry.Raw, Reason: err.Error()})
+			continue
+		}
+		report.Created = append(report.Created, task)
+	}
+	return report, nil
+}
+
+// uniqueTaskName 名称已被占用时追加序号
+func (s *TaskService) uniqueTaskName(base string) (string, error) {
+	name := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := s.db.Model(&model.Task{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return name, nil
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
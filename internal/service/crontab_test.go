@@ -0,0 +1,89 @@
+package service
+
+import (
+	"testing"
+
+	"happx1/internal/scheduler"
+)
+
+const testCrontab = `# nightly jobs
+SHELL=/bin/bash
+MAILTO=ops@example.com
+
+0 2 * * * /usr/local/bin/backup.sh --full
+*/15 * * * *   echo "ping"  >> /tmp/ping.log
+@daily /usr/bin/cleanup
+@reboot /usr/bin/start
+61 * * * * /usr/bin/bad-minute
+* * * echo short
+`
+
+func TestImportCrontab(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+
+	report, err := svc.ImportCrontab(testCrontab, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ name, spec, command string }{
+		{"crontab-5-usr-local-bin-backup-sh-full", "0 2 * * *", "/usr/local/bin/backup.sh --full"},
+		{"crontab-6-echo-ping-tmp-ping-log", "*/15 * * * *", `echo "ping"  >> /tmp/ping.log`},
+		{"crontab-7-usr-bin-cleanup", "@daily", "/usr/bin/cleanup"},
+	}
+	if len(report.Created) != len(want) {
+		t.Fatalf("创建了 %d 个任务，期望 %d 个: %+v", len(report.Created), len(want), report)
+	}
+	for i, w := range want {
+		task := report.Created[i]
+		if task.Name != w.name || task.Spec != w.spec || task.Command != w.command {
+			t.Errorf("第%d个任务 name=%q spec=%q command=%q，期望 %q %q %q", i+1, task.Name, task.Spec, task.Command, w.name, w.spec, w.command)
+		}
+		if task.GetRetryTimes() != 0 || len(task.Tags) != 1 || task.Tags[0] != "crontab" || task.Status != 1 {
+			t.Errorf("任务 %s 应默认不重试、带 crontab 标签且已启用", task.Name)
+		}
+	}
+
+	skippedLines := map[int]bool{}
+	for _, s := range report.Skipped {
+		skippedLines[s.Line] = true
+	}
+	for _, line := range []int{1, 2, 3, 8, 9, 10} {
+		if !skippedLines[line] {
+			t.Errorf("第%d行应被跳过，跳过的行 %+v", line, report.Skipped)
+		}
+	}
+	if len(report.Skipped) != 6 || len(report.Failed) != 0 {
+		t.Fatalf("跳过 %d 行、失败 %d 行，期望跳过6行", len(report.Skipped), len(report.Failed))
+	}
+	if n := svc.SchedulerStatus().ScheduledTasks; n != 3 {
+		t.Fatalf("已注册 %d 个触发器，期望3个", n)
+	}
+
+	// 再次导入时名称追加序号
+	report, err = svc.ImportCrontab("0 2 * * * /usr/local/bin/backup.sh --full\n", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Created) != 1 || report.Created[0].Name != "crontab-1-usr-local-bin-backup-sh-full" {
+		t.Fatalf("再次导入创建了 %+v", report.Created)
+	}
+	report, err = svc.ImportCrontab("0 2 * * * /usr/local/bin/backup.sh --full\n", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created[0].Name != "crontab-1-usr-local-bin-backup-sh-full-2" {
+		t.Fatalf("重名时的任务名为 %q", report.Created[0].Name)
+	}
+}
+
+func TestImportCrontabWithSecondsField(t *testing.T) {
+	svc, _ := newTestService(t, nil, &scheduler.Config{EnableSecondsField: true})
+
+	report, err := svc.ImportCrontab("30 4 * * 1 /usr/bin/weekly\n", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Created) != 1 || report.Created[0].Spec != "0 30 4 * * 1" {
+		t.Fatalf("开启秒字段时导入 %+v，期望表达式补上秒", report)
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"log/slog"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// deleteLogsBatchSize 删除任务日志时每批删除的条数，避免长时间锁表
+const deleteLogsBatchSize = 1000
+
+// DeleteTaskLogs 物理删除任务的执行日志（含每次尝试），before 不为 nil 时只删除开始时间早于 before 的日志
+// 返回删除的条数，actor 为操作人；任务不存在时返回 gorm.ErrRecordNotFound
+func (s *TaskService) DeleteTaskLogs(taskID uint, before *time.Time, actor string) (int64, error) {
+	if _, err := s.GetTask(taskID); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for {
+		db := s.db.Unscoped().Model(&model.TaskLog{}).Where("task_id = ?", taskID)
+		if before != nil {
+			db = db.Where("start_time < ?", *before)
+		}
+		var ids []uint
+		if err := db.Limit(deleteLogsBatchSize).Pluck("id", &ids).Error; err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		result := s.db.Unscoped().Delete(&model.TaskLog{}, ids)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if len(ids) < deleteLogsBatchSize {
+			break
+		}
+	}
+	slog.Info("已删除任务日志", "task_id", taskID, "deleted", total, "before", before, "actor", actor)
+	return total, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"happx1/internal/eventbus"
+)
+
+// 执行事件推送参数
+const (
+	eventBufferSize   = 64
+	eventWriteTimeout = 10 * time.Second
+	eventPingInterval = 30 * time.Second
+)
+
+var eventUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// eventMessage 推送给客户端的事件消息
+type eventMessage struct {
+	Type  string         `json:"type"`  // 事件类型：started、succeeded、failed、retried
+	Event eventbus.Event `json:"event"` // 事件内容
+}
+
+// Events 通过 WebSocket 推送所有任务的执行生命周期事件
+func (h *TaskHandler) Events(c *gin.Context) {
+	conn, err := eventUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已写入错误响应
+		return
+	}
+	defer conn.Close()
+
+	unsubscribe := h.taskService.SubscribeEvents(func(e eventbus.Event) {
+		conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+		if err := conn.WriteJSON(eventMessage{Type: e.Type(), Event: e}); err != nil {
+			slog.Debug("推送执行事件失败", "error", err)
+		}
+	}, eventBufferSize)
+	defer unsubscribe()
+
+	// 读取客户端消息以处理关闭和 pong，连接断开时结束推送
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(eventPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			// WriteControl 可与 WriteJSON 并发调用
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
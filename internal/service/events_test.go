@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"happx1/internal/auth"
+	"happx1/internal/eventbus"
+)
+
+// receivedEvent 客户端收到的事件消息
+type receivedEvent struct {
+	Type  string `json:"type"`
+	Event struct {
+		eventbus.TaskEvent
+		Duration int `json:"duration"`
+		Attempts int `json:"attempts"`
+	} `json:"event"`
+}
+
+func TestEventsWebSocket(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleViewer)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	task := validTask("streamed")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/events"
+	if _, resp, err := websocket.DefaultDialer.Dial(wsURL, nil); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("未认证的连接错误为 %v，期望401", err)
+	}
+
+	// 多个客户端同时订阅，每个都收到执行事件
+	var clients []*websocket.Conn
+	for i := 0; i < 3; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {"Bearer " + token}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		clients = append(clients, conn)
+	}
+	// 连接建立后服务端才订阅事件，等待片刻再执行
+	time.Sleep(100 * time.Millisecond)
+	current, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskLog, err := svc.RunTaskSync(context.Background(), current, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, conn := range clients {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var types []string
+		for len(types) < 2 {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("客户端%d 读取事件失败: %v", i+1, err)
+			}
+			var msg receivedEvent
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg.Event.TaskID != task.ID || msg.Event.RunID != taskLog.RunID {
+				t.Fatalf("客户端%d 收到的事件 %s 不属于本次执行", i+1, data)
+			}
+			types = append(types, msg.Type)
+			if msg.Type == eventbus.TypeTaskSucceeded && msg.Event.Attempts != 1 {
+				t.Fatalf("完成事件的尝试次数为 %d", msg.Event.Attempts)
+			}
+		}
+		if types[0] != eventbus.TypeTaskStarted || types[1] != eventbus.TypeTaskSucceeded {
+			t.Fatalf("客户端%d 收到的事件依次为 %v，期望 started、succeeded", i+1, types)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// maxExportTextLength 导出日志时输出和错误信息保留的最大字符数，超出部分截断
+const maxExportTextLength = 1000
+
+// logExportHeader 日志 CSV 的列
+var logExportHeader = []string{"id", "run_id", "attempt", "start_time", "end_time", "duration", "status", "retry_count", "exit_code", "output", "output_encoding", "error"}
+
+// ExportTaskLogsCSV 将任务的所有执行日志（含每次尝试）按开始时间顺序以 CSV 写入 w
+// 逐行读取并写出，不在内存中缓存全部日志；输出和错误信息超过 maxExportTextLength 个字符时截断
+func (s *TaskService) ExportTaskLogsCSV(taskID uint, w io.Writer) error {
+	rows, err := s.db.Model(&model.TaskLog{}).
+		Where("task_id = ?", taskID).
+		Order("start_time asc, id asc").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(logExportHeader); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var l model.TaskLog
+		if err := s.db.ScanRows(rows, &l); err != nil {
+			return err
+		}
+		record := []string{
+			strconv.FormatUint(uint64(l.ID), 10),
+			l.RunID,
+			strconv.Itoa(l.Attempt),
+			formatExportTime(l.StartTime),
+			formatExportTime(l.EndTime),
+			strconv.Itoa(l.Duration),
+			strconv.Itoa(l.Status),
+			strconv.Itoa(l.RetryCount),
+			strconv.Itoa(l.ExitCode),
+			truncateRunes(l.Output, maxExportTextLength),
+			l.OutputEncoding,
+			truncateRunes(l.Error, maxExportTextLength),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatExportTime 以 RFC3339 格式化时间，零值为空
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// truncateRunes 截断超过 max 个字符的字符串
+func truncateRunes(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max]) + "..."
+	}
+	return s
+}
@@ -7,11 +7,13 @@ import (
 )
 
 // Handler 处理所有的HTTP请求
-type Handler struct{}
+type Handler struct {
+	taskService *TaskService
+}
 
-// NewHandler 创建一个新的Handler实例
-func NewHandler() *Handler {
-	return &Handler{}
+// NewHandler 创建一个新的Handler实例，健康检查通过 taskService 报告调度器是否暂停
+func NewHandler(taskService *TaskService) *Handler {
+	return &Handler{taskService: taskService}
 }
 
 // RegisterRoutes 注册所有的路由
@@ -26,10 +28,11 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 	}
 }
 
-// HealthCheck 健康检查处理器
+// HealthCheck 健康检查处理器，paused 为 true 时调度器处于维护模式，不执行任务
 func (h *Handler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status": "ok",
+		"paused": h.taskService.Paused(),
 	})
 }
 
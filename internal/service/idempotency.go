@@ -0,0 +1,84 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"happx1/internal/model"
+)
+
+// maxIdempotencyKeyLength 幂等键的最大长度
+const maxIdempotencyKeyLength = 100
+
+// ErrIdempotencyKeyReused 幂等键已用于请求体不同的请求
+var ErrIdempotencyKeyReused = errors.New("幂等键已用于不同的请求")
+
+// CreateTaskIdempotent 按幂等键创建任务，body 为原始请求体
+// 保留期内相同键、相同请求体的重复请求不再创建任务，返回首次创建的任务且 replayed 为 true；请求体不同时返回 ErrIdempotencyKeyReused
+func (s *TaskService) CreateTaskIdempotent(task *model.Task, key string, body []byte, actor string) (result *model.Task, replayed bool, err error) {
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, false, &ValidationError{Errors: []string{fmt.Sprintf("幂等键长度不能超过 %d", maxIdempotencyKeyLength)}}
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+
+	s.expireIdempotencyKeys()
+	if result, err := s.idempotentResult(key, hash); err != nil || result != nil {
+		return result, result != nil, err
+	}
+
+	err = s.createTask(task, actor, func(tx *gorm.DB) error {
+		return tx.Create(&model.IdempotencyKey{Key: key, RequestHash: hash, TaskID: task.ID}).Error
+	})
+	if err != nil {
+		// 相同键的并发请求先完成时保存幂等键冲突，返回其结果
+		if result, lookupErr := s.idempotentResult(key, hash); lookupErr == nil && result != nil {
+			return result, true, nil
+		}
+		return nil, false, err
+	}
+	// 重复提交返回从数据库读取的任务，首次创建同样返回读取结果，使两次响应一致
+	created, err := s.GetTask(task.ID)
+	if err != nil {
+		return task, false, nil
+	}
+	return created, false, nil
+}
+
+// idempotentResult 查询幂等键对应的任务，键不存在时返回 nil
+func (s *TaskService) idempotentResult(key, hash string) (*model.Task, error) {
+	var record model.IdempotencyKey
+	if err := s.db.Where(&model.IdempotencyKey{Key: key}).Limit(1).Find(&record).Error; err != nil {
+		return nil, err
+	}
+	if record.TaskID == 0 {
+		return nil, nil
+	}
+	if record.RequestHash != hash {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	// 任务在首次创建后可能已被删除，仍返回该任务
+	var task model.Task
+	if err := s.db.Unscoped().First(&task, record.TaskID).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// expireIdempotencyKeys 删除超过保留期的幂等键
+func (s *TaskService) expireIdempotencyKeys() {
+	ttl := s.config.IdempotencyKeyTTL
+	if ttl <= 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+	cutoff := time.Now().Add(-time.Duration(ttl) * time.Second)
+	if err := s.db.Where("created_at < ?", cutoff).Delete(&model.IdempotencyKey{}).Error; err != nil {
+		slog.Error("清理过期幂等键失败", "error", err)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"happx1/internal/model"
+)
+
+// patchReadOnlyFields 由系统维护、不能通过部分更新修改的字段
+var patchReadOnlyFields = map[string]bool{
+	"last_run_time": true,
+	"next_run_time": true,
+}
+
+// patchableFields 可以部分更新的字段（JSON 名称），即任务中有 JSON 名称且非只读的字段
+var patchableFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(model.Task{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && !patchReadOnlyFields[name] {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// PatchTask 部分更新任务，patch 为 JSON 对象，只修改其中出现的字段，未出现的字段保持不变
+// patch 中的 version 为读取任务时的版本，未提供时使用当前版本；其余行为与 UpdateTask 相同
+func (s *TaskService) PatchTask(id uint, patch []byte, actor string) (*model.Task, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		return nil, &ValidationError{Errors: []string{fmt.Sprintf("请求体不是有效的 JSON 对象: %v", err)}}
+	}
+	var unknown []string
+	for name := range fields {
+		if !patchableFields[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, &ValidationError{Errors: []string{"不支持修改的字段: " + strings.Join(unknown, ", ")}}
+	}
+
+	task, err := s.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	clientKey := task.ClientKey
+	// 请求头整体替换，而不是与原有请求头合并
+	if _, ok := fields["headers"]; ok {
+		task.Headers = nil
+	}
+	if err := json.Unmarshal(patch, task); err != nil {
+		return nil, &ValidationError{Errors: []string{err.Error()}}
+	}
+	// 客户端回传脱敏后的私钥时保留原值
+	if task.ClientKey == model.RedactedSecret {
+		task.ClientKey = clientKey
+	}
+
+	if err := s.UpdateTask(task, actor); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
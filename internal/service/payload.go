@@ -0,0 +1,55 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	"happx1/internal/model"
+)
+
+// validatePayload 校验 Headers 和 Body 的大小，避免超大内容在每次执行时占用大量内存
+// http 类型任务的 Content-Type 为 JSON 时 Body 必须是有效的 JSON
+func (s *TaskService) validatePayload(task *model.Task) []error {
+	var errs []error
+
+	maxHeaders := s.config.MaxHeadersSize
+	if maxHeaders <= 0 {
+		maxHeaders = defaultMaxHeadersSize
+	}
+	size := 0
+	for k, v := range task.Headers {
+		size += len(k) + len(v)
+	}
+	if size > maxHeaders {
+		errs = append(errs, fmt.Errorf("headers 总大小 %d 字节，超过上限 %d 字节", size, maxHeaders))
+	}
+
+	maxBody := s.config.MaxBodySize
+	if maxBody <= 0 {
+		maxBody = defaultMaxBodySize
+	}
+	if len(task.Body) > maxBody {
+		errs = append(errs, fmt.Errorf("body 大小 %d 字节，超过上限 %d 字节", len(task.Body), maxBody))
+	} else if task.ExecType == model.ExecTypeHTTP && strings.TrimSpace(task.Body) != "" &&
+		isJSONContentType(task.Headers) && !json.Valid([]byte(task.Body)) {
+		errs = append(errs, fmt.Errorf("Content-Type 为 JSON 时 body 必须是有效的 JSON"))
+	}
+	return errs
+}
+
+// isJSONContentType 判断请求头中的 Content-Type（名称不区分大小写）是否为 application/json 或 +json 类型
+func isJSONContentType(headers model.Headers) bool {
+	for k, v := range headers {
+		if !strings.EqualFold(k, "Content-Type") {
+			continue
+		}
+		mediaType, _, err := mime.ParseMediaType(v)
+		if err != nil {
+			return false
+		}
+		return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	}
+	return false
+}
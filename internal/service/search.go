@@ -0,0 +1,61 @@
+package service
+
+import (
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"happx1/internal/model"
+)
+
+// 任务搜索分页参数
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+// TaskPage 分页的任务列表
+type TaskPage struct {
+	Items    []model.Task `json:"items"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+// SearchTasks 按关键字搜索任务，在名称、描述和命令中不区分大小写地匹配子串
+// 结果按匹配位置排序：名称完全相同、名称前缀、名称包含、描述包含、命令包含，同级按ID排序
+func (s *TaskService) SearchTasks(q string, page, pageSize int) (*TaskPage, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	} else if pageSize > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	}
+
+	q = strings.ToLower(escapeLike(strings.TrimSpace(q)))
+	contains := "%" + q + "%"
+	db := s.db.Model(&model.Task{}).
+		Where("LOWER(name) LIKE ? ESCAPE '!' OR LOWER(description) LIKE ? ESCAPE '!' OR LOWER(command) LIKE ? ESCAPE '!'",
+			contains, contains, contains)
+
+	result := &TaskPage{Page: page, PageSize: pageSize}
+	if err := db.Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+	rank := clause.Expr{
+		SQL: `CASE WHEN LOWER(name) LIKE ? ESCAPE '!' THEN 0
+			WHEN LOWER(name) LIKE ? ESCAPE '!' THEN 1
+			WHEN LOWER(name) LIKE ? ESCAPE '!' THEN 2
+			WHEN LOWER(description) LIKE ? ESCAPE '!' THEN 3
+			ELSE 4 END, id`,
+		Vars: []interface{}{q, q + "%", contains, contains},
+	}
+	if err := db.Clauses(clause.OrderBy{Expression: rank}).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&result.Items).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -1,11 +1,22 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+	"happx1/internal/auth"
 	"happx1/internal/model"
+	"happx1/internal/scheduler"
 )
 
 type TaskHandler struct {
@@ -18,46 +29,211 @@ func NewTaskHandler(taskService *TaskService) *TaskHandler {
 	}
 }
 
-// RegisterRoutes 注册路由
-func (h *TaskHandler) RegisterRoutes(r *gin.Engine) {
-	tasks := r.Group("/api/tasks")
+// RegisterRoutes 注册路由，middlewares 作用于所有任务接口（如认证）
+// 权限：viewer 只读，operator 可执行和启用/禁用任务，admin 可创建、修改、删除任务
+// 更新和删除使用 PUT/DELETE /api/tasks/:id，旧的 POST /:id/update 和 POST /:id/delete 保留兼容
+func (h *TaskHandler) RegisterRoutes(r *gin.Engine, middlewares ...gin.HandlerFunc) {
+	operator := auth.RequireRole(auth.RoleOperator)
+	admin := auth.RequireRole(auth.RoleAdmin)
+
+	tasks := r.Group("/api/tasks", middlewares...)
 	{
 		// 创建任务
-		tasks.POST("", h.CreateTask)
-		// 获取任务列表
+		tasks.POST("", admin, h.CreateTask)
+		// 校验任务定义，不保存
+		tasks.POST("/validate", admin, h.ValidateTask)
+		// 获取任务列表（支持 ?tag=a&tag=b 或 ?tag=a,b 按标签过滤，?include_deleted=true 时包含已删除的任务）
 		tasks.GET("", h.ListTasks)
-		// 获取任务详情
+		// 任务总览
+		tasks.GET("/dashboard", h.Dashboard)
+		// 即将执行的任务时间线（?within=1h 指定时间窗口）
+		tasks.GET("/upcoming", h.Upcoming)
+		// 按关键字搜索任务（?q= 匹配名称、描述和命令，支持 page/page_size 分页）
+		tasks.GET("/search", h.SearchTasks)
+		// 获取任务详情（?include=recent_runs,stats 时附带最近执行和执行统计）
 		tasks.GET("/:id", h.GetTask)
 		// 更新任务
-		tasks.POST("/:id/update", h.UpdateTask)
+		tasks.PUT("/:id", admin, h.UpdateTask)
+		tasks.POST("/:id/update", admin, h.UpdateTask)
+		// 部分更新任务，只修改请求体中出现的字段
+		tasks.PATCH("/:id", admin, h.PatchTask)
 		// 删除任务
-		tasks.POST("/:id/delete", h.DeleteTask)
-		// 立即执行任务
-		tasks.POST("/:id/run", h.RunTask)
-		// 获取任务执行日志
+		tasks.DELETE("/:id", admin, h.DeleteTask)
+		tasks.POST("/:id/delete", admin, h.DeleteTask)
+		// 复制任务，新任务名称加 -copy 后缀且为禁用状态
+		tasks.POST("/:id/clone", admin, h.CloneTask)
+		// 恢复已删除的任务
+		tasks.POST("/:id/restore", admin, h.RestoreTask)
+		// 立即执行任务（?wait=true 时等待执行结束并返回执行日志，已禁用的任务需 ?force=true）
+		tasks.POST("/:id/run", operator, h.RunTask)
+		// 分页获取任务执行日志（支持 page/page_size 分页，status、from/to、min_duration/max_duration 过滤）
 		tasks.GET("/:id/logs", h.GetTaskLogs)
+		// 导出任务的全部执行日志（?format=csv）
+		tasks.GET("/:id/logs/export", h.ExportTaskLogs)
+		// 删除任务的执行日志（?before= 时只删除该时间之前的日志）
+		tasks.DELETE("/:id/logs", admin, h.DeleteTaskLogs)
+		// 获取任务执行统计（?window=24h|7d|30d 时只统计该时间窗口内的执行）
+		tasks.GET("/:id/stats", h.GetTaskStats)
+		// 重置任务执行统计
+		tasks.POST("/:id/stats/reset", operator, h.ResetStats)
+		// 获取任务配置变更历史
+		tasks.GET("/:id/audit", h.GetTaskAudit)
+		// 批量启用/禁用/删除任务（删除需要 admin 角色）
+		tasks.POST("/batch", operator, h.BatchOperate)
+		// 导入 crontab 文件（请求体为文件内容，或 multipart 表单的 file 字段）
+		tasks.POST("/import-crontab", admin, h.ImportCrontab)
+	}
+
+	// 调度器状态（生效时区等）
+	r.Group("/api/scheduler", middlewares...).GET("/status", h.SchedulerStatus)
+
+	// 维护模式：暂停和恢复所有任务的执行
+	maintenance := r.Group("/api/admin", middlewares...)
+	{
+		maintenance.POST("/pause", admin, h.Pause)
+		maintenance.POST("/resume", admin, h.Resume)
 	}
+
+	// 所有任务执行事件的 WebSocket 推送
+	r.Group("/api", middlewares...).GET("/events", h.Events)
 }
 
 // CreateTask 创建任务
+// 请求带 Idempotency-Key 头时，重复提交返回首次创建的任务，响应带 Idempotent-Replayed: true 头
+// @Summary 创建任务
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "幂等键"
+// @Param task body model.Task true "任务定义"
+// @Success 201 {object} model.Task
+// @Header 201 {string} Idempotent-Replayed "重复提交时为 true"
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks [post]
 func (h *TaskHandler) CreateTask(c *gin.Context) {
+	var task model.Task
+	if err := c.ShouldBindBodyWith(&task, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		if err := h.taskService.CreateTask(&task, actorOf(c)); err != nil {
+			h.taskError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, task)
+		return
+	}
+
+	// ShouldBindBodyWith 已缓存请求体
+	cached, _ := c.Get(gin.BodyBytesKey)
+	body, _ := cached.([]byte)
+	result, replayed, err := h.taskService.CreateTaskIdempotent(&task, key, body, actorOf(c))
+	if errors.Is(err, ErrIdempotencyKeyReused) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.taskError(c, err)
+		return
+	}
+	if replayed {
+		c.Header("Idempotent-Replayed", "true")
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+// ValidateTask 校验任务定义，通过时返回规范化后的任务，失败时返回所有校验错误
+// @Summary 校验任务定义
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param task body model.Task true "任务定义"
+// @Success 200 {object} model.Task
+// @Failure 400 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/validate [post]
+func (h *TaskHandler) ValidateTask(c *gin.Context) {
 	var task model.Task
 	if err := c.ShouldBindJSON(&task); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.taskService.CreateTask(&task); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.taskService.ValidateTask(&task); err != nil {
+		h.taskError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, task)
+	c.JSON(http.StatusOK, task)
+}
+
+// actorOf 返回当前请求的操作人，未认证时为空
+func actorOf(c *gin.Context) string {
+	if identity := auth.GetIdentity(c); identity != nil {
+		return identity.Subject
+	}
+	return ""
+}
+
+// ErrorResponse 接口失败时的响应
+type ErrorResponse struct {
+	Error  string   `json:"error"`            // 错误信息
+	Errors []string `json:"errors,omitempty"` // 校验失败时的所有错误
+}
+
+// taskError 返回创建或更新任务失败的响应，校验错误返回 400 并列出所有错误，版本冲突返回 409
+func (h *TaskHandler) taskError(c *gin.Context, err error) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "errors": validationErr.Errors})
+		return
+	}
+	if errors.Is(err, ErrVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
 // ListTasks 获取任务列表
+// @Summary 获取任务列表
+// @Tags tasks
+// @Produce json
+// @Param tag query []string false "按标签过滤，多个标签可重复传入或以逗号分隔" collectionFormat(multi)
+// @Param include_deleted query bool false "是否包含已删除的任务"
+// @Success 200 {array} model.Task
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks [get]
 func (h *TaskHandler) ListTasks(c *gin.Context) {
-	tasks, err := h.taskService.ListTasks()
+	var tags []string
+	for _, v := range c.QueryArray("tag") {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	includeDeleted, _ := strconv.ParseBool(c.Query("include_deleted"))
+
+	var (
+		tasks []model.Task
+		err   error
+	)
+	if len(tags) > 0 {
+		tasks, err = h.taskService.ListTasksByTag(includeDeleted, tags...)
+	} else {
+		tasks, err = h.taskService.ListTasks(includeDeleted)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -66,24 +242,156 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 	c.JSON(http.StatusOK, tasks)
 }
 
+// Dashboard 获取任务总览
+// @Summary 任务总览
+// @Tags tasks
+// @Produce json
+// @Success 200 {object} Dashboard
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/dashboard [get]
+func (h *TaskHandler) Dashboard(c *gin.Context) {
+	dashboard, err := h.taskService.Dashboard()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dashboard)
+}
+
+// SearchTasks 按关键字搜索任务
+// @Summary 搜索任务
+// @Description 在名称、描述和命令中不区分大小写地匹配关键字，按匹配位置排序
+// @Tags tasks
+// @Produce json
+// @Param q query string true "关键字"
+// @Param page query int false "页码，从1开始"
+// @Param page_size query int false "每页条数"
+// @Success 200 {object} TaskPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/search [get]
+func (h *TaskHandler) SearchTasks(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少参数 q"})
+		return
+	}
+	page, err := parseOptionalInt(c, "page")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pageSize, err := parseOptionalInt(c, "page_size")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var p, size int
+	if page != nil {
+		p = *page
+	}
+	if pageSize != nil {
+		size = *pageSize
+	}
+	result, err := h.taskService.SearchTasks(q, p, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// 执行队列视图的时间窗口
+const (
+	defaultUpcomingWithin = time.Hour
+	maxUpcomingWithin     = 7 * 24 * time.Hour
+)
+
+// Upcoming 获取即将执行的任务时间线
+// @Summary 即将执行的任务
+// @Description 列出时间窗口内 cron 任务和一次性任务的触发时间，按时间排序；after 类型任务不包括在内
+// @Tags tasks
+// @Produce json
+// @Param within query string false "时间窗口，如 30m、1h，默认 1h，最长 168h"
+// @Success 200 {array} scheduler.UpcomingRun
+// @Failure 400 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/upcoming [get]
+func (h *TaskHandler) Upcoming(c *gin.Context) {
+	within := defaultUpcomingWithin
+	if raw := c.Query("within"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 || d > maxUpcomingWithin {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的参数 within，应为不超过 %s 的正时长: %s", maxUpcomingWithin, raw)})
+			return
+		}
+		within = d
+	}
+
+	c.JSON(http.StatusOK, h.taskService.Upcoming(within))
+}
+
 // GetTask 获取任务详情
+// @Summary 获取任务详情
+// @Description include 包含 recent_runs 时附带 recent_runs 字段（最近 5 次执行的 run_id、status、start_time、duration），包含 stats 时附带 stats 字段（同 /tasks/{id}/stats）
+// @Tags tasks
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param include query string false "附带的内容，逗号分隔：recent_runs、stats"
+// @Success 200 {object} model.Task
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id} [get]
 func (h *TaskHandler) GetTask(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
 		return
 	}
-
-	task, err := h.taskService.GetTask(uint(id))
+	include, err := ParseInclude(c.QueryArray("include"))
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	detail, err := h.taskService.GetTaskDetail(uint(id), include)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, task)
+	c.JSON(http.StatusOK, detail)
 }
 
 // UpdateTask 更新任务
+// @Summary 更新任务
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param task body model.Task true "任务定义"
+// @Success 200 {object} model.Task
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id} [put]
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -91,19 +399,79 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		return
 	}
 
+	// 请求体必须携带读取任务时的 version，否则绑定到当前任务后会沿用数据库中的版本，过期的修改也能覆盖他人的修改
+	var precondition struct {
+		Version *int `json:"version"`
+	}
+	if err := c.ShouldBindBodyWith(&precondition, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if precondition.Version == nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "缺少 version，请回传读取任务时的版本号"})
+		return
+	}
+
 	task, err := h.taskService.GetTask(uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
 		return
 	}
 
-	if err := c.ShouldBindJSON(task); err != nil {
+	current := *task
+	if err := c.ShouldBindBodyWith(task, binding.JSON); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// 任务ID以路径为准，创建和删除时间由系统维护，请求体中的同名字段不生效
+	task.ID, task.CreatedAt, task.DeletedAt = current.ID, current.CreatedAt, current.DeletedAt
+	// 客户端回传脱敏后的私钥时保留原值
+	if task.ClientKey == model.RedactedSecret {
+		task.ClientKey = current.ClientKey
+	}
 
-	if err := h.taskService.UpdateTask(task); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.taskService.UpdateTask(task, actorOf(c)); err != nil {
+		h.taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// PatchTask 部分更新任务
+// @Summary 部分更新任务
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param patch body model.Task true "要修改的字段，未出现的字段保持不变"
+// @Success 200 {object} model.Task
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id} [patch]
+func (h *TaskHandler) PatchTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.taskService.PatchTask(uint(id), patch, actorOf(c))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+	if err != nil {
+		h.taskError(c, err)
 		return
 	}
 
@@ -111,6 +479,15 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 }
 
 // DeleteTask 删除任务
+// @Summary 删除任务
+// @Tags tasks
+// @Param id path int true "任务ID"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id} [delete]
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -118,7 +495,7 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 		return
 	}
 
-	if err := h.taskService.DeleteTask(uint(id)); err != nil {
+	if err := h.taskService.DeleteTask(uint(id), actorOf(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -126,7 +503,88 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// CloneTask 复制任务
+// @Summary 复制任务
+// @Description 复制任务的配置为一个新任务，名称加 -copy 后缀，新任务为禁用状态；执行统计和日志不复制
+// @Tags tasks
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 201 {object} model.Task
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/clone [post]
+func (h *TaskHandler) CloneTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	task, err := h.taskService.CloneTask(uint(id), actorOf(c))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+	if err != nil {
+		h.taskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, task)
+}
+
+// RestoreTask 恢复已删除的任务
+// @Summary 恢复已删除的任务
+// @Tags tasks
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} model.Task
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/restore [post]
+func (h *TaskHandler) RestoreTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	task, err := h.taskService.RestoreTask(uint(id), actorOf(c))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+	case errors.Is(err, ErrTaskNotDeleted), errors.Is(err, ErrTaskNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		h.taskError(c, err)
+	default:
+		c.JSON(http.StatusOK, task)
+	}
+}
+
 // RunTask 立即执行任务
+// @Summary 立即执行任务
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param wait query bool false "是否等待执行结束并返回执行日志"
+// @Param force query bool false "是否强制执行已禁用的任务"
+// @Param request body RunTaskRequest false "执行参数"
+// @Success 200 {object} model.TaskLog
+// @Success 202 {object} object{run_id=string}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/run [post]
 func (h *TaskHandler) RunTask(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -140,11 +598,73 @@ func (h *TaskHandler) RunTask(c *gin.Context) {
 		return
 	}
 
-	h.taskService.RunTask(task)
-	c.Status(http.StatusAccepted)
+	// 请求体可选：{"params": {"key": "value"}}
+	var req RunTaskRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	force, _ := strconv.ParseBool(c.Query("force"))
+	if wait, _ := strconv.ParseBool(c.Query("wait")); wait {
+		taskLog, err := h.taskService.RunTaskSync(c.Request.Context(), task, req.Params, force)
+		if err != nil {
+			h.runTaskError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, taskLog)
+		return
+	}
+
+	if err := h.taskService.RunTask(task, req.Params, force); err != nil {
+		h.runTaskError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"run_id": task.RunID})
+}
+
+// runTaskError 返回立即执行失败的响应
+func (h *TaskHandler) runTaskError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, scheduler.ErrDuplicateRun), errors.Is(err, scheduler.ErrTooManyManualRuns), errors.Is(err, ErrTaskDisabled),
+		errors.Is(err, scheduler.ErrSchedulerPaused), errors.Is(err, scheduler.ErrConcurrencyLimit):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, scheduler.ErrSchedulerStopped):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "等待执行结果超时，任务仍在后台执行"})
+	case errors.Is(err, context.Canceled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "请求已取消，任务仍在后台执行"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// RunTaskRequest 立即执行请求
+type RunTaskRequest struct {
+	Params map[string]string `json:"params"`
 }
 
 // GetTaskLogs 获取任务执行日志
+// @Summary 分页获取任务执行日志
+// @Tags tasks
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param page query int false "页码，从1开始"
+// @Param page_size query int false "每页条数"
+// @Param status query int false "状态：1-成功，0-失败"
+// @Param from query string false "开始时间（RFC3339）"
+// @Param to query string false "结束时间（RFC3339）"
+// @Param min_duration query int false "最短执行时长（秒）"
+// @Param max_duration query int false "最长执行时长（秒）"
+// @Success 200 {object} LogPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/logs [get]
 func (h *TaskHandler) GetTaskLogs(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -152,7 +672,49 @@ func (h *TaskHandler) GetTaskLogs(c *gin.Context) {
 		return
 	}
 
-	logs, err := h.taskService.GetTaskLogs(uint(id))
+	var query LogQuery
+	if query.MinDuration, err = parseOptionalInt(c, "min_duration"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.MaxDuration, err = parseOptionalInt(c, "max_duration"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.MinDuration != nil && query.MaxDuration != nil && *query.MinDuration > *query.MaxDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_duration 不能大于 max_duration"})
+		return
+	}
+	if query.Status, err = parseOptionalInt(c, "status"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.From, err = parseOptionalTime(c, "from"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.To, err = parseOptionalTime(c, "to"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := parseOptionalInt(c, "page")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if page != nil {
+		query.Page = *page
+	}
+	pageSize, err := parseOptionalInt(c, "page_size")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if pageSize != nil {
+		query.PageSize = *pageSize
+	}
+
+	logs, err := h.taskService.GetTaskLogs(uint(id), query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -160,3 +722,351 @@ func (h *TaskHandler) GetTaskLogs(c *gin.Context) {
 
 	c.JSON(http.StatusOK, logs)
 }
+
+// ExportTaskLogs 导出任务执行日志
+// @Summary 导出任务执行日志
+// @Description 以 CSV 流式导出任务的全部执行日志（含每次尝试），输出和错误信息超过 1000 个字符时截断
+// @Tags tasks
+// @Produce text/csv
+// @Param id path int true "任务ID"
+// @Param format query string false "导出格式" Enums(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/logs/export [get]
+func (h *TaskHandler) ExportTaskLogs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数 format，目前只支持 csv"})
+		return
+	}
+	if _, err := h.taskService.GetTask(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="task-%d-logs.csv"`, id))
+	c.Status(http.StatusOK)
+	// 响应头已发送，导出中途失败时只能记录日志并中断
+	if err := h.taskService.ExportTaskLogsCSV(uint(id), c.Writer); err != nil {
+		slog.Error("导出任务日志失败", "task_id", id, "error", err)
+	}
+}
+
+// DeleteTaskLogsResponse 删除任务日志的结果
+type DeleteTaskLogsResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// DeleteTaskLogs 删除任务执行日志
+// @Summary 删除任务执行日志
+// @Description 物理删除任务的执行日志（含每次尝试），不影响执行统计
+// @Tags tasks
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param before query string false "只删除开始时间早于该时间的日志（RFC3339）"
+// @Success 200 {object} DeleteTaskLogsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/logs [delete]
+func (h *TaskHandler) DeleteTaskLogs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+	before, err := parseOptionalTime(c, "before")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deleted, err := h.taskService.DeleteTaskLogs(uint(id), before, actorOf(c))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteTaskLogsResponse{Deleted: deleted})
+}
+
+// GetTaskStats 获取任务执行统计
+// @Summary 获取任务执行统计
+// @Description 指定 window 时返回 WindowStats，否则返回 model.TaskStats
+// @Tags tasks
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param window query string false "统计时间窗口" Enums(24h, 7d, 30d)
+// @Success 200 {object} model.TaskStats
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/stats [get]
+func (h *TaskHandler) GetTaskStats(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	if window := c.Query("window"); window != "" {
+		if _, ok := StatsWindows[window]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的参数 window，可选 24h、7d、30d"})
+			return
+		}
+		stats, err := h.taskService.GetTaskStatsWindow(uint(id), window)
+		if err != nil {
+			h.statsError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	stats, err := h.taskService.GetTaskStats(uint(id))
+	if err != nil {
+		h.statsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ResetStats 重置任务执行统计
+// @Summary 重置任务执行统计
+// @Tags tasks
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} model.TaskStats
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/stats/reset [post]
+func (h *TaskHandler) ResetStats(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	stats, err := h.taskService.ResetStats(uint(id))
+	if err != nil {
+		h.statsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetTaskAudit 获取任务配置变更历史
+// @Summary 获取任务配置变更历史
+// @Tags tasks
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {array} model.TaskAudit
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/{id}/audit [get]
+func (h *TaskHandler) GetTaskAudit(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	audits, err := h.taskService.GetTaskAudit(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, audits)
+}
+
+// statsError 返回任务统计接口失败的响应
+func (h *TaskHandler) statsError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// BatchOperateRequest 批量操作请求
+type BatchOperateRequest struct {
+	IDs    []uint `json:"ids" binding:"required,min=1"`
+	Action string `json:"action" binding:"required,oneof=enable disable delete"`
+}
+
+// BatchOperate 批量操作任务
+// @Summary 批量启用/禁用/删除任务
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param request body BatchOperateRequest true "批量操作"
+// @Success 200 {object} object{results=[]BatchResult}
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/batch [post]
+func (h *TaskHandler) BatchOperate(c *gin.Context) {
+	var req BatchOperateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Action == BatchActionDelete && !auth.GetIdentity(c).HasRole(auth.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "权限不足，需要 admin 角色"})
+		return
+	}
+
+	results, err := h.taskService.BatchOperate(req.IDs, req.Action, actorOf(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// maxCrontabSize crontab 文件大小上限
+const maxCrontabSize = 1 << 20
+
+// ImportCrontab 导入 crontab 文件
+// @Summary 导入 crontab 文件
+// @Tags tasks
+// @Accept plain,mpfd
+// @Produce json
+// @Param file formData file false "crontab 文件，也可直接作为请求体提交"
+// @Success 200 {object} CrontabImportReport
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tasks/import-crontab [post]
+func (h *TaskHandler) ImportCrontab(c *gin.Context) {
+	var reader io.Reader
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer f.Close()
+		reader = f
+	} else {
+		reader = c.Request.Body
+	}
+
+	content, err := io.ReadAll(io.LimitReader(reader, maxCrontabSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(content) > maxCrontabSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "crontab 文件过大"})
+		return
+	}
+
+	report, err := h.taskService.ImportCrontab(string(content), actorOf(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SchedulerStatus 获取调度器状态
+// @Summary 获取调度器状态
+// @Tags scheduler
+// @Produce json
+// @Success 200 {object} scheduler.Status
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /scheduler/status [get]
+func (h *TaskHandler) SchedulerStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.taskService.SchedulerStatus())
+}
+
+// Pause 暂停所有任务的执行（维护模式）
+// @Summary 暂停调度器
+// @Description 暂停后所有定时触发和手动执行都被跳过，任务保留，状态在重启后保持；已在执行的任务不受影响
+// @Tags admin
+// @Produce json
+// @Success 200 {object} object{paused=bool}
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /admin/pause [post]
+func (h *TaskHandler) Pause(c *gin.Context) {
+	if err := h.taskService.Pause(actorOf(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"paused": true})
+}
+
+// Resume 恢复所有任务的执行
+// @Summary 恢复调度器
+// @Description 恢复后之后的触发正常执行，暂停期间错过的触发不补执行
+// @Tags admin
+// @Produce json
+// @Success 200 {object} object{paused=bool}
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /admin/resume [post]
+func (h *TaskHandler) Resume(c *gin.Context) {
+	if err := h.taskService.Resume(actorOf(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"paused": false})
+}
+
+// parseOptionalInt 解析可选的非负整数查询参数，未提供时返回nil
+func parseOptionalInt(c *gin.Context, key string) (*int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		return nil, fmt.Errorf("无效的参数 %s: %s", key, raw)
+	}
+	return &v, nil
+}
+
+// parseOptionalTime 解析可选的 RFC3339 时间查询参数，未提供时返回nil
+func parseOptionalTime(c *gin.Context, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("无效的参数 %s，应为RFC3339时间: %s", key, raw)
+	}
+	return &t, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"happx1/internal/model"
+)
+
+// 任务详情可附带的内容（?include=recent_runs,stats）
+const (
+	IncludeRecentRuns = "recent_runs"
+	IncludeStats      = "stats"
+)
+
+// recentRunsLimit 任务详情附带的最近执行数
+const recentRunsLimit = 5
+
+// RunSummary 一次执行的摘要
+type RunSummary struct {
+	RunID     string    `json:"run_id"`
+	Status    int       `json:"status"` // 1-成功，0-失败
+	StartTime time.Time `json:"start_time"`
+	Duration  int       `json:"duration"` // 执行时长（秒）
+}
+
+// TaskDetail 任务详情，按请求附带最近执行和执行统计
+type TaskDetail struct {
+	Task       *model.Task
+	RecentRuns []RunSummary     // 最近的执行，按开始时间倒序，未请求时为 nil
+	Stats      *model.TaskStats // 执行统计，未请求时为 nil
+}
+
+// MarshalJSON 在任务的 JSON 对象中追加 recent_runs 和 stats 字段，与不附带时的任务字段保持一致
+func (d TaskDetail) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(d.Task)
+	if err != nil {
+		return nil, err
+	}
+	extra := map[string]interface{}{}
+	if d.RecentRuns != nil {
+		extra[IncludeRecentRuns] = d.RecentRuns
+	}
+	if d.Stats != nil {
+		extra[IncludeStats] = d.Stats
+	}
+	if len(extra) == 0 {
+		return data, nil
+	}
+	fields, err := json.Marshal(extra)
+	if err != nil {
+		return nil, err
+	}
+	// 去掉任务对象的 "}" 和附加对象的 "{"，拼接为一个对象
+	data = bytes.TrimSuffix(bytes.TrimSpace(data), []byte("}"))
+	return append(append(data, ','), fields[1:]...), nil
+}
+
+// ParseInclude 解析逗号分隔的 include 参数，返回请求附带的内容
+func ParseInclude(values []string) (map[string]bool, error) {
+	include := map[string]bool{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			switch item = strings.TrimSpace(item); item {
+			case "":
+			case IncludeRecentRuns, IncludeStats:
+				include[item] = true
+			default:
+				return nil, fmt.Errorf("无效的参数 include: %s，可选 %s、%s", item, IncludeRecentRuns, IncludeStats)
+			}
+		}
+	}
+	return include, nil
+}
+
+// GetTaskDetail 获取任务详情，include 中包含 recent_runs 时附带最近 5 次执行的摘要，包含 stats 时附带执行统计
+func (s *TaskService) GetTaskDetail(id uint, include map[string]bool) (*TaskDetail, error) {
+	task, err := s.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &TaskDetail{Task: task}
+	if include[IncludeRecentRuns] {
+		detail.RecentRuns = []RunSummary{}
+		if err := s.db.Model(&model.TaskLog{}).
+			Select("run_id", "status", "start_time", "duration").
+			Where("task_id = ? AND retried = ?", id, false).
+			Order("start_time desc, id desc").
+			Limit(recentRunsLimit).
+			Find(&detail.RecentRuns).Error; err != nil {
+			return nil, err
+		}
+	}
+	if include[IncludeStats] {
+		if detail.Stats, err = s.GetTaskStats(id); err != nil {
+			return nil, err
+		}
+	}
+	return detail, nil
+}
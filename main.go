@@ -1,26 +1,59 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 
+	_ "happx1/docs"
+	"happx1/internal/auth"
 	"happx1/internal/config"
 	"happx1/internal/database"
+	"happx1/internal/logger"
+	"happx1/internal/model"
+	"happx1/internal/notifications"
+	"happx1/internal/ratelimit"
 	"happx1/internal/scheduler"
+	"happx1/internal/secrets"
 	"happx1/internal/service"
+	"happx1/internal/telemetry"
 
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// @title happX1 API
+// @version 1.0
+// @description 定时任务调度服务接口
+// @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Bearer <token>
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
 	// 初始化配置
 	if err := config.Init(); err != nil {
 		log.Fatalf("初始化配置失败: %v", err)
 	}
 
-	// 初始化MySQL
-	if err := database.InitMySQL(&config.GlobalConfig.MySQL); err != nil {
-		log.Fatalf("初始化MySQL失败: %v", err)
+	// 初始化日志
+	if err := logger.Init(&config.GlobalConfig.Log); err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
+	}
+
+	// 初始化数据库
+	if err := database.InitDB(&config.GlobalConfig.MySQL); err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+
+	// 自动迁移数据库表
+	if err := model.AutoMigrate(database.DB); err != nil {
+		log.Fatalf("数据库迁移失败: %v", err)
 	}
 
 	// 初始化Redis
@@ -28,25 +61,66 @@ func main() {
 		log.Fatalf("初始化Redis失败: %v", err)
 	}
 
+	// 初始化链路追踪
+	shutdownTracing, err := telemetry.Init(context.Background(), &config.GlobalConfig.Telemetry)
+	if err != nil {
+		log.Fatalf("初始化链路追踪失败: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// 初始化密钥来源
+	secretProvider, err := secrets.New(&config.GlobalConfig.Secrets)
+	if err != nil {
+		log.Fatalf("初始化密钥失败: %v", err)
+	}
+
 	// 初始化调度器
-	scheduler := scheduler.NewScheduler()
+	scheduler := scheduler.NewScheduler(database.DB, database.RedisClient, &config.GlobalConfig.Scheduler)
+	scheduler.UseNotifier(notifications.New(&config.GlobalConfig.Notify))
+	scheduler.UseSecrets(secretProvider)
 	if err := scheduler.Start(); err != nil {
 		log.Fatalf("启动调度器失败: %v", err)
 	}
 	defer scheduler.Stop()
 
+	// 配置文件变更时热更新日志级别和调度器配置
+	config.OnChange(func(c *config.Config) {
+		if err := logger.SetLevel(c.Log.Level); err != nil {
+			slog.Error("更新日志级别失败", "error", err)
+		}
+		scheduler.Reload(&c.Scheduler)
+	})
+
 	// 设置gin模式
 	gin.SetMode(config.GlobalConfig.Server.Mode)
 
 	// 创建默认的gin引擎
 	r := gin.Default()
 
+	// 创建认证器
+	authenticator, err := auth.NewAuthenticator(&config.GlobalConfig.Auth)
+	if err != nil {
+		log.Fatalf("初始化认证失败: %v", err)
+	}
+
 	// 创建服务层
-	taskService := service.NewTaskService(scheduler, database.DB)
+	taskService := service.NewTaskService(scheduler, database.DB, &config.GlobalConfig.Task)
+	apiKeyService := service.NewAPIKeyService(database.DB)
+	authenticator.UseAPIKeys(apiKeyService)
+
+	// 按客户端限流，放在认证之后以便按身份限流，登录接口按客户端IP限流
+	limiter := ratelimit.New(database.RedisClient, &config.GlobalConfig.RateLimit)
 
-	// 创建并注册处理器
+	// 创建并注册处理器，健康检查和登录无需认证
+	service.NewHandler(taskService).RegisterRoutes(r)
+	service.NewAuthHandler(authenticator).RegisterRoutes(r, limiter.Middleware())
 	taskHandler := service.NewTaskHandler(taskService)
-	taskHandler.RegisterRoutes(r)
+	taskHandler.RegisterRoutes(r, authenticator.Middleware(), limiter.Middleware())
+	apiKeyHandler := service.NewAPIKeyHandler(apiKeyService)
+	apiKeyHandler.RegisterRoutes(r, authenticator.Middleware(), limiter.Middleware())
+
+	// 接口文档，由 swag init -g main.go -o docs --parseInternal --parseDependency --propertyStrategy pascalcase 生成
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// 启动服务器
 	addr := fmt.Sprintf(":%d", config.GlobalConfig.Server.Port)
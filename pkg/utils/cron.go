@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// CronParser cron 表达式解析器，调度器和校验使用同一个解析器，保证校验通过的表达式都能注册
+// 开启秒字段时为 6 位（含秒）表达式，否则为标准 5 位表达式，都支持 @daily 等描述符
+type CronParser struct {
+	parser      cron.Parser
+	withSeconds bool
+}
+
+// NewCronParser 创建 cron 表达式解析器，withSeconds 表示表达式是否包含秒字段
+func NewCronParser(withSeconds bool) CronParser {
+	fields := cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor
+	if withSeconds {
+		fields |= cron.Second
+	}
+	return CronParser{parser: cron.NewParser(fields), withSeconds: withSeconds}
+}
+
+// WithSeconds 表达式是否包含秒字段
+func (p CronParser) WithSeconds() bool {
+	return p.withSeconds
+}
+
+// Parse 解析 cron 表达式，用于计算触发时间，实现 cron.ScheduleParser
+func (p CronParser) Parse(spec string) (cron.Schedule, error) {
+	schedule, err := p.parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 cron 表达式 %q: %v", spec, err)
+	}
+	return schedule, nil
+}
+
+// Validate 校验 cron 表达式
+func (p CronParser) Validate(spec string) error {
+	_, err := p.Parse(spec)
+	return err
+}
+
+// MinInterval 计算 cron 表达式从 from 开始的 samples 次触发之间的最短间隔，用于限制触发频率
+func (p CronParser) MinInterval(spec string, from time.Time, samples int) (time.Duration, error) {
+	schedule, err := p.Parse(spec)
+	if err != nil {
+		return 0, err
+	}
+	var minInterval time.Duration
+	prev := schedule.Next(from)
+	for i := 1; i < samples && !prev.IsZero(); i++ {
+		next := schedule.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if interval := next.Sub(prev); minInterval == 0 || interval < minInterval {
+			minInterval = interval
+		}
+		prev = next
+	}
+	return minInterval, nil
+}
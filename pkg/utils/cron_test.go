@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronParserSecondsField(t *testing.T) {
+	cases := []struct {
+		withSeconds bool
+		spec        string
+		valid       bool
+	}{
+		{false, "*/5 * * * *", true},
+		{false, "0 */5 * * * *", false},
+		{false, "@daily", true},
+		{true, "0 */5 * * * *", true},
+		{true, "*/5 * * * *", false},
+		{true, "@every 30s", true},
+	}
+	for _, c := range cases {
+		err := NewCronParser(c.withSeconds).Validate(c.spec)
+		if (err == nil) != c.valid {
+			t.Errorf("秒字段=%v %q: 错误为 %v，期望有效=%v", c.withSeconds, c.spec, err, c.valid)
+		}
+	}
+}
+
+func TestCronParserMinInterval(t *testing.T) {
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		withSeconds bool
+		spec        string
+		want        time.Duration
+	}{
+		{false, "*/5 * * * *", 5 * time.Minute},
+		{false, "0,10 * * * *", 10 * time.Minute},
+		{true, "*/10 * * * * *", 10 * time.Second},
+	}
+	for _, c := range cases {
+		got, err := NewCronParser(c.withSeconds).MinInterval(c.spec, from, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("%q 的最短间隔为 %v，期望 %v", c.spec, got, c.want)
+		}
+	}
+}
@@ -2,20 +2,30 @@ package utils
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"runtime/debug"
+
+	"happx1/internal/notifications"
 )
 
-// Recover 用于恢复协程中的 panic
-func Recover(name string, ctx context.Context) {
+// Recover 用于恢复协程中的 panic，notifier 不为 nil 时发送告警
+func Recover(name string, ctx context.Context, notifier notifications.Notifier) {
 	if err := recover(); err != nil {
 		// 获取堆栈信息
 		stack := debug.Stack()
 
 		// 记录错误日志
-		log.Printf("[PANIC] %s: %v\n%s", name, err, string(stack))
+		slog.ErrorContext(ctx, "协程发生panic", "goroutine", name, "error", err, "stack", string(stack))
 
-		// 这里可以添加告警通知，比如发送邮件、钉钉等
-		// TODO: 实现告警通知
+		// 发送告警通知，未配置时跳过
+		if notifier == nil {
+			return
+		}
+		title := fmt.Sprintf("[PANIC] %s", name)
+		content := fmt.Sprintf("%v\n\n%s", err, stack)
+		if err := notifier.Notify(ctx, title, content); err != nil {
+			slog.ErrorContext(ctx, "发送panic告警失败", "goroutine", name, "error", err)
+		}
 	}
 }
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// recordingNotifier 记录收到的告警
+type recordingNotifier struct {
+	title, content string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, title, content string) error {
+	n.title, n.content = title, content
+	return nil
+}
+
+// panicWorker 在受 Recover 保护的协程中 panic，协程结束后返回；notifier 为 nil 时传入 nil 接口而不是 nil 指针
+func panicWorker(notifier *recordingNotifier) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if notifier == nil {
+			defer Recover("worker", context.Background(), nil)
+		} else {
+			defer Recover("worker", context.Background(), notifier)
+		}
+		panic("boom")
+	}()
+	<-done
+}
+
+func TestRecoverNotifiesPanic(t *testing.T) {
+	notifier := &recordingNotifier{}
+	panicWorker(notifier)
+
+	if notifier.title != "[PANIC] worker" {
+		t.Fatalf("告警标题为 %q", notifier.title)
+	}
+	if !strings.HasPrefix(notifier.content, "boom\n") || !strings.Contains(notifier.content, "panicWorker") {
+		t.Fatalf("告警内容缺少错误或堆栈: %s", notifier.content)
+	}
+}
+
+func TestRecoverWithoutNotifier(t *testing.T) {
+	// 未配置告警时只记录日志，不会因 nil 通知器再次 panic
+	panicWorker(nil)
+}